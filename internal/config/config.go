@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"sync"
+	"time"
 
 	"go.yaml.in/yaml/v3"
 )
@@ -48,11 +51,17 @@ func Load(path string) (*Config, error) {
 	return cfg, nil
 }
 
+// saveMu keeps two saves from writing the config file at the same time.
+var saveMu sync.Mutex
+
 func (cfg *Config) Save() error {
 	if cfg.Path == "" {
 		return fmt.Errorf("config: no path set")
 	}
 
+	saveMu.Lock()
+	defer saveMu.Unlock()
+
 	dir := filepath.Dir(cfg.Path)
 	if err := os.MkdirAll(dir, 0o755); err != nil {
 		return fmt.Errorf("creating config dir: %w", err)
@@ -63,13 +72,26 @@ func (cfg *Config) Save() error {
 		return fmt.Errorf("marshaling config: %w", err)
 	}
 
-	if err := os.WriteFile(cfg.Path, data, 0o644); err != nil {
+	// The file may hold passwords; WriteFile only applies the mode to new files
+	if err := os.WriteFile(cfg.Path, data, 0o600); err != nil {
 		return fmt.Errorf("writing config file: %w", err)
 	}
+	if err := os.Chmod(cfg.Path, 0o600); err != nil {
+		return fmt.Errorf("restricting config file permissions: %w", err)
+	}
 
 	return nil
 }
 
+// Clone copies cfg, so the copy can be saved while cfg keeps changing.
+// Connections are replaced rather than edited through their pointers and
+// maps, so copying the list is enough.
+func (cfg *Config) Clone() *Config {
+	c := *cfg
+	c.Connections = slices.Clone(cfg.Connections)
+	return &c
+}
+
 func validate(cfg *Config) error {
 	names := make(map[string]bool)
 	for i, c := range cfg.Connections {
@@ -82,8 +104,16 @@ func validate(cfg *Config) error {
 		}
 		names[c.Name] = true
 
-		if c.URL == "" && c.Host == "" {
-			return fmt.Errorf("config: connection %q must have either url or host", c.Name)
+		if !c.HasTarget() {
+			return fmt.Errorf("config: connection %q must have a url, host or service", c.Name)
+		}
+
+		if c.SSH != nil && c.SSH.Host == "" {
+			return fmt.Errorf("config: connection %q: ssh.host is required", c.Name)
+		}
+
+		if err := c.Pool.Validate(); err != nil {
+			return fmt.Errorf("config: connection %q: %w", c.Name, err)
 		}
 	}
 
@@ -99,24 +129,28 @@ func validate(cfg *Config) error {
 		cfg.Settings.NullDisplay = "NULL"
 	}
 
-	return nil
-}
+	if cfg.Settings.TimestampFormat == "" {
+		cfg.Settings.TimestampFormat = DefaultTimestampFormat
+	}
 
-// DSN means Data Source Name
-func (c *Connection) DSN() string {
-	if c.URL != "" {
-		return c.URL
+	if cfg.Settings.RefreshInterval <= 0 {
+		cfg.Settings.RefreshInterval = 2
 	}
 
-	port := c.Port
-	if port == 0 {
-		port = 5432
+	if cfg.Settings.EnvironmentColors == nil {
+		cfg.Settings.EnvironmentColors = make(map[string]string)
 	}
-	sslmode := c.SSLMode
-	if sslmode == "" {
-		sslmode = "disable"
+	for env, color := range DefaultEnvironmentColors() {
+		if _, ok := cfg.Settings.EnvironmentColors[env]; !ok {
+			cfg.Settings.EnvironmentColors[env] = color
+		}
 	}
 
-	return fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=%s", c.User, c.Password, c.Host, port, c.Database, sslmode)
+	if cfg.Settings.TimeZone != "" {
+		if _, err := time.LoadLocation(cfg.Settings.TimeZone); err != nil {
+			return fmt.Errorf("config: invalid time_zone %q: %w", cfg.Settings.TimeZone, err)
+		}
+	}
 
+	return nil
 }
@@ -0,0 +1,278 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"net"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// DSN means Data Source Name. It is built in libpq key=value form with only
+// the fields that are set, so pgx fills in the rest from PGHOST, PGUSER etc.,
+// the service entry in ~/.pg_service.conf, and PGPASSWORD or ~/.pgpass.
+// pgx turns the ssl* settings into the tls.Config it connects with, and the
+// pool settings into pool_* parameters for pgxpool.
+func (c *Connection) DSN() string {
+	// Explicit params win over the pool settings
+	settings := c.Pool.dsnParams()
+	maps.Copy(settings, c.Params)
+
+	set := func(key, value string) {
+		if value != "" {
+			settings[key] = value
+		}
+	}
+	set("sslrootcert", ExpandHome(c.SSLRootCert))
+	set("sslcert", ExpandHome(c.SSLCert))
+	set("sslkey", ExpandHome(c.SSLKey))
+	set("sslpassword", c.SSLPassword)
+
+	if c.URL != "" {
+		return withParams(c.URL, settings)
+	}
+
+	set("service", c.Service)
+	set("host", c.Host)
+	if c.Port != 0 {
+		set("port", strconv.Itoa(c.Port))
+	}
+	set("user", c.User)
+	set("password", c.Password)
+	set("dbname", c.Database)
+	set("sslmode", c.SSLMode)
+
+	// Keep the historical default unless the environment or a service decides
+	if settings["sslmode"] == "" && c.Service == "" && os.Getenv("PGSSLMODE") == "" && os.Getenv("PGSERVICE") == "" {
+		settings["sslmode"] = "disable"
+	}
+
+	return keywordDSN(settings)
+}
+
+// HasTarget reports whether the connection says where to connect, either
+// itself or through PGHOST/PGSERVICE.
+func (c Connection) HasTarget() bool {
+	return c.URL != "" || c.Host != "" || c.Service != "" ||
+		os.Getenv("PGHOST") != "" || os.Getenv("PGSERVICE") != ""
+}
+
+func keywordDSN(settings map[string]string) string {
+	parts := make([]string, 0, len(settings))
+	for _, k := range slices.Sorted(maps.Keys(settings)) {
+		parts = append(parts, k+"="+quoteDSNValue(settings[k]))
+	}
+	return strings.Join(parts, " ")
+}
+
+func quoteDSNValue(v string) string {
+	if v != "" && !strings.ContainsAny(v, ` '\`) {
+		return v
+	}
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, `'`, `\'`)
+	return "'" + v + "'"
+}
+
+// withParams adds params to a URL or key=value DSN without overriding
+// parameters the DSN already has.
+func withParams(dsn string, params map[string]string) string {
+	if len(params) == 0 {
+		return dsn
+	}
+
+	if !isURL(dsn) {
+		existing, err := parseKeywordDSN(dsn)
+		if err != nil {
+			return dsn
+		}
+		extra := make(map[string]string)
+		for k, v := range params {
+			if _, ok := existing[k]; !ok {
+				extra[k] = v
+			}
+		}
+		if len(extra) == 0 {
+			return dsn
+		}
+		return dsn + " " + keywordDSN(extra)
+	}
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return dsn
+	}
+	q := u.Query()
+	for k, v := range params {
+		if !q.Has(k) {
+			q.Set(k, v)
+		}
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+func isURL(dsn string) bool {
+	return strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://")
+}
+
+// ParseDSN splits a postgres:// URL or libpq key=value string into
+// connection fields. Parameters without a field of their own end up in Params.
+// Nothing is read from the environment.
+func ParseDSN(dsn string) (Connection, error) {
+	dsn = strings.TrimSpace(dsn)
+
+	var settings map[string]string
+	var err error
+	if isURL(dsn) {
+		settings, err = parseURLDSN(dsn)
+	} else {
+		settings, err = parseKeywordDSN(dsn)
+	}
+	if err != nil {
+		return Connection{}, err
+	}
+
+	var c Connection
+	if p, ok := settings["port"]; ok {
+		port, err := strconv.Atoi(p)
+		if err != nil {
+			return Connection{}, fmt.Errorf("invalid port %q", p)
+		}
+		c.Port = port
+	}
+
+	fields := map[string]*string{
+		"host":        &c.Host,
+		"user":        &c.User,
+		"password":    &c.Password,
+		"dbname":      &c.Database,
+		"sslmode":     &c.SSLMode,
+		"sslrootcert": &c.SSLRootCert,
+		"sslcert":     &c.SSLCert,
+		"sslkey":      &c.SSLKey,
+		"sslpassword": &c.SSLPassword,
+		"service":     &c.Service,
+	}
+	for k, v := range settings {
+		if f, ok := fields[k]; ok {
+			*f = v
+			continue
+		}
+		if k == "port" {
+			continue
+		}
+		if c.Params == nil {
+			c.Params = make(map[string]string)
+		}
+		c.Params[k] = v
+	}
+
+	return c, nil
+}
+
+func parseURLDSN(dsn string) (map[string]string, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("parsing connection URL: %w", err)
+	}
+
+	settings := make(map[string]string)
+	if u.User != nil {
+		settings["user"] = u.User.Username()
+		if pw, ok := u.User.Password(); ok {
+			settings["password"] = pw
+		}
+	}
+
+	// Multiple hosts (h1:5432,h2:5432) are kept as one host string
+	if strings.Contains(u.Host, ",") {
+		settings["host"] = u.Host
+	} else if u.Host != "" {
+		host, port, err := net.SplitHostPort(u.Host)
+		if err != nil {
+			host = u.Host
+		}
+		settings["host"] = host
+		if port != "" {
+			settings["port"] = port
+		}
+	}
+
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		settings["dbname"] = db
+	}
+
+	for k, v := range u.Query() {
+		if len(v) > 0 {
+			settings[k] = v[len(v)-1]
+		}
+	}
+
+	return settings, nil
+}
+
+// parseKeywordDSN parses key=value pairs. Values may be single-quoted, with
+// backslash escaping a quote or backslash, as in libpq.
+func parseKeywordDSN(dsn string) (map[string]string, error) {
+	settings := make(map[string]string)
+	s := strings.TrimSpace(dsn)
+
+	for s != "" {
+		eq := strings.IndexRune(s, '=')
+		if eq < 0 {
+			return nil, fmt.Errorf("invalid DSN: missing '=' after %q", s)
+		}
+		key := strings.TrimSpace(s[:eq])
+		if key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("invalid DSN: bad key %q", key)
+		}
+		s = strings.TrimLeft(s[eq+1:], " \t")
+
+		var val strings.Builder
+		if strings.HasPrefix(s, "'") {
+			i := 1
+			for ; i < len(s) && s[i] != '\''; i++ {
+				if s[i] == '\\' && i+1 < len(s) {
+					i++
+				}
+				val.WriteByte(s[i])
+			}
+			if i >= len(s) {
+				return nil, fmt.Errorf("invalid DSN: unterminated quote for %s", key)
+			}
+			s = s[i+1:]
+		} else {
+			i := 0
+			for ; i < len(s) && s[i] != ' ' && s[i] != '\t'; i++ {
+				if s[i] == '\\' && i+1 < len(s) {
+					i++
+				}
+				val.WriteByte(s[i])
+			}
+			s = s[i:]
+		}
+
+		settings[key] = val.String()
+		s = strings.TrimLeft(s, " \t")
+	}
+
+	return settings, nil
+}
+
+// FormatParams renders params as sorted key=value pairs for display and editing.
+func FormatParams(params map[string]string) string {
+	return keywordDSN(params)
+}
+
+// ParseParams is the inverse of FormatParams.
+func ParseParams(s string) (map[string]string, error) {
+	params, err := parseKeywordDSN(s)
+	if err != nil || len(params) == 0 {
+		return nil, err
+	}
+	return params, nil
+}
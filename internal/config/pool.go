@@ -0,0 +1,110 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// QueryExecModes are the values pgx accepts for default_query_exec_mode.
+var QueryExecModes = []string{"cache_statement", "cache_describe", "describe_exec", "exec", "simple_protocol"}
+
+// PoolSettings size and tune the connection pool. Durations use Go syntax,
+// e.g. 30s, 5m, 1h. Zero values keep the pgx defaults.
+type PoolSettings struct {
+	MaxConns          int32  `yaml:"max_conns,omitempty"`
+	MinConns          int32  `yaml:"min_conns,omitempty"`
+	MaxConnLifetime   string `yaml:"max_conn_lifetime,omitempty"`
+	MaxConnIdleTime   string `yaml:"max_conn_idle_time,omitempty"`
+	HealthCheckPeriod string `yaml:"health_check_period,omitempty"`
+	ConnectTimeout    string `yaml:"connect_timeout,omitempty"`
+
+	// QueryExecMode picks how pgx prepares statements, one of QueryExecModes.
+	QueryExecMode string `yaml:"query_exec_mode,omitempty"`
+
+	// SimpleProtocol is short for query_exec_mode: simple_protocol. PgBouncer
+	// in transaction mode needs it, as prepared statements don't survive
+	// being moved between server connections.
+	SimpleProtocol bool `yaml:"simple_protocol,omitempty"`
+}
+
+// Validate checks the values parse and fit together.
+func (p PoolSettings) Validate() error {
+	if p.MaxConns < 0 || p.MinConns < 0 {
+		return fmt.Errorf("pool: max_conns and min_conns can't be negative")
+	}
+	if p.MaxConns > 0 && p.MinConns > p.MaxConns {
+		return fmt.Errorf("pool: min_conns (%d) is above max_conns (%d)", p.MinConns, p.MaxConns)
+	}
+
+	durations := []struct {
+		key   string
+		value string
+	}{
+		{"max_conn_lifetime", p.MaxConnLifetime},
+		{"max_conn_idle_time", p.MaxConnIdleTime},
+		{"health_check_period", p.HealthCheckPeriod},
+		{"connect_timeout", p.ConnectTimeout},
+	}
+	for _, d := range durations {
+		if d.value == "" {
+			continue
+		}
+		v, err := time.ParseDuration(d.value)
+		if err != nil {
+			return fmt.Errorf("pool: invalid %s %q: %w", d.key, d.value, err)
+		}
+		if v <= 0 {
+			return fmt.Errorf("pool: %s must be positive", d.key)
+		}
+	}
+
+	if p.QueryExecMode != "" {
+		if !slices.Contains(QueryExecModes, p.QueryExecMode) {
+			return fmt.Errorf("pool: invalid query_exec_mode %q", p.QueryExecMode)
+		}
+		if p.SimpleProtocol && p.QueryExecMode != "simple_protocol" {
+			return fmt.Errorf("pool: simple_protocol conflicts with query_exec_mode %s", p.QueryExecMode)
+		}
+	}
+
+	return nil
+}
+
+// dsnParams turns the settings into the pool_* and other parameters that
+// pgxpool.ParseConfig reads from the connection string.
+func (p PoolSettings) dsnParams() map[string]string {
+	params := make(map[string]string)
+
+	if p.MaxConns > 0 {
+		params["pool_max_conns"] = strconv.Itoa(int(p.MaxConns))
+	}
+	if p.MinConns > 0 {
+		params["pool_min_conns"] = strconv.Itoa(int(p.MinConns))
+	}
+	if p.MaxConnLifetime != "" {
+		params["pool_max_conn_lifetime"] = p.MaxConnLifetime
+	}
+	if p.MaxConnIdleTime != "" {
+		params["pool_max_conn_idle_time"] = p.MaxConnIdleTime
+	}
+	if p.HealthCheckPeriod != "" {
+		params["pool_health_check_period"] = p.HealthCheckPeriod
+	}
+	if d, err := time.ParseDuration(p.ConnectTimeout); err == nil && d > 0 {
+		// libpq takes whole seconds
+		params["connect_timeout"] = strconv.Itoa(int(math.Ceil(d.Seconds())))
+	}
+
+	mode := p.QueryExecMode
+	if p.SimpleProtocol {
+		mode = "simple_protocol"
+	}
+	if mode != "" {
+		params["default_query_exec_mode"] = mode
+	}
+
+	return params
+}
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+var envRefPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// passwordCommandTimeout bounds how long password_command may run (e.g. waiting on a gpg agent).
+const passwordCommandTimeout = 30 * time.Second
+
+// Resolve returns a copy of the connection ready to connect with: ${ENV_VAR}
+// references are expanded and password_command is run when no password is set.
+// The receiver is left untouched so saving keeps the references.
+//
+// A still-empty password is fine: pgx falls back to PGPASSWORD and ~/.pgpass
+// (or PGPASSFILE) the same way libpq does. ctx bounds password_command.
+func (c Connection) Resolve(ctx context.Context) (Connection, error) {
+	fields := []*string{
+		&c.Service, &c.Host, &c.User, &c.Password, &c.Database, &c.URL, &c.PasswordCommand,
+		&c.SSLMode, &c.SSLRootCert, &c.SSLCert, &c.SSLKey, &c.SSLPassword,
+		&c.Session.Role, &c.Session.SearchPath, &c.Session.ApplicationName,
+	}
+	for _, f := range fields {
+		expanded, err := interpolateEnv(*f)
+		if err != nil {
+			return c, fmt.Errorf("connection %q: %w", c.Name, err)
+		}
+		*f = expanded
+	}
+
+	if c.SSH != nil {
+		ssh := *c.SSH
+		ssh.JumpHosts = slices.Clone(ssh.JumpHosts)
+		sshFields := []*string{&ssh.Host, &ssh.User, &ssh.KeyPath, &ssh.KnownHosts}
+		for i := range ssh.JumpHosts {
+			sshFields = append(sshFields, &ssh.JumpHosts[i])
+		}
+		for _, f := range sshFields {
+			expanded, err := interpolateEnv(*f)
+			if err != nil {
+				return c, fmt.Errorf("connection %q: ssh: %w", c.Name, err)
+			}
+			*f = expanded
+		}
+		c.SSH = &ssh
+	}
+
+	// Copy so expanding params doesn't write through to the saved config
+	if c.Params != nil {
+		params := make(map[string]string, len(c.Params))
+		for k, v := range c.Params {
+			expanded, err := interpolateEnv(v)
+			if err != nil {
+				return c, fmt.Errorf("connection %q: params.%s: %w", c.Name, k, err)
+			}
+			params[k] = expanded
+		}
+		c.Params = params
+	}
+
+	if c.Password == "" && c.PasswordCommand != "" {
+		pw, err := runPasswordCommand(ctx, c.PasswordCommand)
+		if err != nil {
+			return c, fmt.Errorf("connection %q: password_command: %w", c.Name, err)
+		}
+		c.Password = pw
+	}
+
+	return c, nil
+}
+
+// interpolateEnv expands ${VAR} references. Bare $VAR is left alone so
+// passwords containing '$' don't need escaping.
+func interpolateEnv(s string) (string, error) {
+	var missing string
+	out := envRefPattern.ReplaceAllStringFunc(s, func(ref string) string {
+		name := envRefPattern.FindStringSubmatch(ref)[1]
+		val, ok := os.LookupEnv(name)
+		if !ok && missing == "" {
+			missing = name
+		}
+		return val
+	})
+
+	if missing != "" {
+		return "", fmt.Errorf("environment variable %s is not set", missing)
+	}
+	return out, nil
+}
+
+func runPasswordCommand(ctx context.Context, command string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, passwordCommandTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stdin = nil
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+
+	// Only the first line counts, like `pass show` output
+	pw, _, _ := strings.Cut(string(out), "\n")
+	return strings.TrimRight(pw, "\r"), nil
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// State is what ezpg remembers between runs that isn't configuration, kept
+// in state.yaml next to the config file so the config stays hand-editable.
+type State struct {
+	LastConnected map[string]time.Time `yaml:"last_connected"`
+	Path          string               `yaml:"-"`
+}
+
+// StatePath returns the state file that belongs to the config at configPath.
+func StatePath(configPath string) string {
+	if configPath == "" {
+		configPath = DefaultPath()
+	}
+	if configPath == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(configPath), "state.yaml")
+}
+
+// LoadState reads the state file. A missing file gives an empty state.
+func LoadState(path string) (*State, error) {
+	st := &State{
+		LastConnected: make(map[string]time.Time),
+		Path:          path,
+	}
+	if path == "" {
+		return st, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return st, nil
+		}
+		return st, fmt.Errorf("reading state file %s: %w", path, err)
+	}
+
+	if err := yaml.Unmarshal(data, st); err != nil {
+		return st, fmt.Errorf("parsing state file %s: %w", path, err)
+	}
+	if st.LastConnected == nil {
+		st.LastConnected = make(map[string]time.Time)
+	}
+
+	return st, nil
+}
+
+func (st *State) Save() error {
+	if st.Path == "" {
+		return fmt.Errorf("state: no path set")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(st.Path), 0o755); err != nil {
+		return fmt.Errorf("creating state dir: %w", err)
+	}
+
+	data, err := yaml.Marshal(st)
+	if err != nil {
+		return fmt.Errorf("marshaling state: %w", err)
+	}
+
+	if err := os.WriteFile(st.Path, data, 0o600); err != nil {
+		return fmt.Errorf("writing state file: %w", err)
+	}
+
+	return nil
+}
+
+// Clone copies st, so the copy can be saved while st keeps changing.
+func (st *State) Clone() *State {
+	return &State{LastConnected: maps.Clone(st.LastConnected), Path: st.Path}
+}
+
+// Touch records a successful connect to name.
+func (st *State) Touch(name string) {
+	st.LastConnected[name] = time.Now()
+}
+
+// Rename moves the history of oldName to newName; an empty newName forgets it.
+func (st *State) Rename(oldName, newName string) {
+	t, ok := st.LastConnected[oldName]
+	delete(st.LastConnected, oldName)
+	if ok && newName != "" {
+		st.LastConnected[newName] = t
+	}
+}
+
+// Recent returns up to n connection names, most recently connected first.
+func (st *State) Recent(n int) []string {
+	names := make([]string, 0, len(st.LastConnected))
+	for name := range st.LastConnected {
+		names = append(names, name)
+	}
+	slices.SortFunc(names, func(a, b string) int {
+		return st.LastConnected[b].Compare(st.LastConnected[a])
+	})
+	return names[:min(n, len(names))]
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// SSLModes are the sslmode values libpq accepts.
+var SSLModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+// CheckTLS validates sslmode and that the certificate and key files exist.
+// Paths still holding ${ENV} references are checked once resolved.
+func (c Connection) CheckTLS() error {
+	if c.SSLMode != "" && !slices.Contains(SSLModes, c.SSLMode) {
+		return fmt.Errorf("invalid sslmode %q (want one of %s)", c.SSLMode, strings.Join(SSLModes, ", "))
+	}
+
+	if (c.SSLCert == "") != (c.SSLKey == "") {
+		return fmt.Errorf("sslcert and sslkey must be set together")
+	}
+
+	files := []struct{ name, path string }{
+		{"sslrootcert", c.SSLRootCert},
+		{"sslcert", c.SSLCert},
+		{"sslkey", c.SSLKey},
+	}
+	for _, f := range files {
+		// "system" asks for the OS trust store rather than a file
+		if f.path == "" || strings.Contains(f.path, "${") || (f.name == "sslrootcert" && f.path == "system") {
+			continue
+		}
+
+		info, err := os.Stat(ExpandHome(f.path))
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.name, err)
+		}
+		if info.IsDir() {
+			return fmt.Errorf("%s: %s is a directory", f.name, f.path)
+		}
+	}
+
+	return nil
+}
+
+// ExpandHome replaces a leading ~/ with the user's home directory.
+func ExpandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return path
+}
@@ -1,5 +1,13 @@
 package config
 
+import (
+	"strings"
+	"time"
+)
+
+// DefaultTimestampFormat mirrors how psql prints timestamptz values.
+const DefaultTimestampFormat = "2006-01-02 15:04:05.999999-07"
+
 type Config struct {
 	Connections []Connection `yaml:"connections"`
 	Settings    Settings     `yaml:"settings"`
@@ -8,14 +16,92 @@ type Config struct {
 
 type Connection struct {
 	Name     string `yaml:"name"`
+	Group    string `yaml:"group,omitempty"` // folder on the home screen, nested with "/", e.g. clients/acme
+	Favorite bool   `yaml:"favorite,omitempty"`
+	Service  string `yaml:"service"` // entry in ~/.pg_service.conf or PGSERVICEFILE
 	Host     string `yaml:"host"`
 	Port     int    `yaml:"port"`
 	User     string `yaml:"user"`
 	Password string `yaml:"password"`
 	Database string `yaml:"database"`
 	SSLMode  string `yaml:"sslmode"`
+
+	// Client certificates and CA, as in libpq. SSLPassword decrypts SSLKey.
+	SSLRootCert string `yaml:"sslrootcert"`
+	SSLCert     string `yaml:"sslcert"`
+	SSLKey      string `yaml:"sslkey"`
+	SSLPassword string `yaml:"sslpassword"`
+
 	URL      string `yaml:"url"`
 	ReadOnly bool   `yaml:"readonly"`
+
+	// ServerReadOnly sets default_transaction_read_only on every session, so
+	// the server rejects writes whatever issues them.
+	ServerReadOnly bool `yaml:"server_readonly"`
+
+	// Environment tags the connection, e.g. dev, staging or prod. Prod
+	// connections ask for the name to be typed before running DML/DDL.
+	Environment string `yaml:"environment"`
+
+	// Params holds other libpq parameters, e.g. application_name,
+	// connect_timeout, target_session_attrs or options.
+	Params map[string]string `yaml:"params,omitempty"`
+
+	// PasswordCommand is run through sh at connect time when no password is
+	// set; the first line of its output is used as the password.
+	PasswordCommand string `yaml:"password_command"`
+
+	// Session settings applied to every connection in the pool
+	Session SessionSettings `yaml:"session,omitempty"`
+
+	Pool PoolSettings `yaml:"pool,omitempty"`
+
+	// ShowDatabases lists every database on the server in the sidebar
+	// instead of only the configured one's tables.
+	ShowDatabases bool `yaml:"show_databases,omitempty"`
+
+	// SSH, when set, reaches the database through an SSH port forward.
+	SSH *SSHConfig `yaml:"ssh,omitempty"`
+}
+
+// SessionSettings are set on each new server session, in this order. Values
+// use Postgres syntax, e.g. statement_timeout: 30s, time_zone: UTC.
+type SessionSettings struct {
+	Role                            string `yaml:"role,omitempty"`        // SET ROLE
+	SearchPath                      string `yaml:"search_path,omitempty"` // e.g. "app, public"
+	StatementTimeout                string `yaml:"statement_timeout,omitempty"`
+	LockTimeout                     string `yaml:"lock_timeout,omitempty"`
+	IdleInTransactionSessionTimeout string `yaml:"idle_in_transaction_session_timeout,omitempty"`
+	TimeZone                        string `yaml:"time_zone,omitempty"`
+	ApplicationName                 string `yaml:"application_name,omitempty"`
+}
+
+// With returns s with the non-empty fields of o taking precedence.
+func (s SessionSettings) With(o SessionSettings) SessionSettings {
+	pick := func(a, b string) string {
+		if b != "" {
+			return b
+		}
+		return a
+	}
+	return SessionSettings{
+		Role:                            pick(s.Role, o.Role),
+		SearchPath:                      pick(s.SearchPath, o.SearchPath),
+		StatementTimeout:                pick(s.StatementTimeout, o.StatementTimeout),
+		LockTimeout:                     pick(s.LockTimeout, o.LockTimeout),
+		IdleInTransactionSessionTimeout: pick(s.IdleInTransactionSessionTimeout, o.IdleInTransactionSessionTimeout),
+		TimeZone:                        pick(s.TimeZone, o.TimeZone),
+		ApplicationName:                 pick(s.ApplicationName, o.ApplicationName),
+	}
+}
+
+type SSHConfig struct {
+	Host       string   `yaml:"host"` // bastion, host or host:port
+	User       string   `yaml:"user"`
+	KeyPath    string   `yaml:"key_path"`
+	Agent      bool     `yaml:"agent"`       // authenticate with SSH_AUTH_SOCK
+	KnownHosts string   `yaml:"known_hosts"` // defaults to ~/.ssh/known_hosts
+	JumpHosts  []string `yaml:"jump_hosts"`  // [user@]host[:port], dialed in order before Host
 }
 
 type Settings struct {
@@ -23,6 +109,21 @@ type Settings struct {
 	ConfirmDestructive bool   `yaml:"confirm_destructive"`
 	EditorTabSize      int    `yaml:"editor_tab_size"`
 	NullDisplay        string `yaml:"null_display"`
+	TimeZone           string `yaml:"time_zone"`        // IANA name used to display timestamptz values, empty for local
+	TimestampFormat    string `yaml:"timestamp_format"` // Go time layout
+	RefreshInterval    int    `yaml:"refresh_interval"` // seconds between refreshes of the admin screens
+
+	// EnvironmentColors maps environment tags to colors (hex or ANSI number)
+	EnvironmentColors map[string]string `yaml:"environment_colors"`
+}
+
+// DefaultEnvironmentColors follow the usual traffic-light convention.
+func DefaultEnvironmentColors() map[string]string {
+	return map[string]string{
+		"dev":     "#a6e3a1",
+		"staging": "#f9e2af",
+		"prod":    "#f38ba8",
+	}
 }
 
 func DefaultSettings() Settings {
@@ -31,5 +132,49 @@ func DefaultSettings() Settings {
 		ConfirmDestructive: true,
 		EditorTabSize:      4,
 		NullDisplay:        "NULL",
+		TimestampFormat:    DefaultTimestampFormat,
+		RefreshInterval:    2,
+		EnvironmentColors:  DefaultEnvironmentColors(),
+	}
+}
+
+// GroupPath splits Group into its nested folder names.
+func (c Connection) GroupPath() []string {
+	var path []string
+	for _, part := range strings.Split(c.Group, "/") {
+		if part = strings.TrimSpace(part); part != "" {
+			path = append(path, part)
+		}
 	}
+	return path
+}
+
+// CleanGroup normalizes a group path, dropping empty and padded segments.
+func CleanGroup(group string) string {
+	return strings.Join(Connection{Group: group}.GroupPath(), "/")
+}
+
+// IsReadOnly reports whether writes are blocked, in the TUI or on the server.
+func (c Connection) IsReadOnly() bool {
+	return c.ReadOnly || c.ServerReadOnly
+}
+
+// IsProduction reports whether the connection is tagged as production.
+func (c Connection) IsProduction() bool {
+	env := strings.ToLower(c.Environment)
+	return env == "prod" || env == "production"
+}
+
+// Location returns the display time zone, falling back to local time.
+func (s Settings) Location() *time.Location {
+	if s.TimeZone == "" {
+		return time.Local
+	}
+
+	loc, err := time.LoadLocation(s.TimeZone)
+	if err != nil {
+		return time.Local
+	}
+
+	return loc
 }
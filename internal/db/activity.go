@@ -0,0 +1,141 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Activity is one server process from pg_stat_activity.
+type Activity struct {
+	PID         int32
+	User        string
+	Database    string
+	Application string
+	Client      string // address, "local" for a Unix socket, empty for background workers
+	BackendType string
+	State       string // active, idle, idle in transaction, ...
+	WaitEvent   string // "Type:Event", empty when not waiting
+	Query       string // current query, or the last one when idle
+	Duration    time.Duration
+	Self        bool // the session running this listing
+}
+
+// ListActivity returns the server's sessions, longest running first. Duration
+// counts from the start of the current query, or of the state for idle ones.
+func (m *Manager) ListActivity(ctx context.Context, connName string) ([]Activity, error) {
+	pool, err := m.Pool(connName)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := pool.Query(ctx, `
+		SELECT pid,
+			coalesce(usename, ''),
+			coalesce(datname, ''),
+			coalesce(application_name, ''),
+			CASE WHEN client_addr IS NOT NULL THEN host(client_addr)
+				WHEN client_port = -1 THEN 'local'
+				ELSE '' END,
+			coalesce(backend_type, ''),
+			coalesce(state, ''),
+			coalesce(wait_event_type || ':' || wait_event, ''),
+			coalesce(query, ''),
+			coalesce(extract(epoch FROM now() - CASE WHEN state = 'active' THEN query_start ELSE state_change END), 0)::float8,
+			pid = pg_backend_pid()
+		FROM pg_stat_activity
+		ORDER BY 10 DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("listing activity: %w", err)
+	}
+	defer rows.Close()
+
+	var list []Activity
+	for rows.Next() {
+		var a Activity
+		var secs float64
+		if err := rows.Scan(&a.PID, &a.User, &a.Database, &a.Application, &a.Client,
+			&a.BackendType, &a.State, &a.WaitEvent, &a.Query, &secs, &a.Self); err != nil {
+			return nil, fmt.Errorf("scanning activity: %w", err)
+		}
+		a.Duration = time.Duration(max(secs, 0) * float64(time.Second))
+		list = append(list, a)
+	}
+
+	return list, rows.Err()
+}
+
+// CancelBackend cancels the current query of pid. It reports false when the
+// server found no such process, or the signal could not be sent.
+func (m *Manager) CancelBackend(ctx context.Context, connName string, pid int32) (bool, error) {
+	return m.signalBackend(ctx, connName, "pg_cancel_backend", pid)
+}
+
+// TerminateBackend ends the session of pid, like CancelBackend.
+func (m *Manager) TerminateBackend(ctx context.Context, connName string, pid int32) (bool, error) {
+	return m.signalBackend(ctx, connName, "pg_terminate_backend", pid)
+}
+
+func (m *Manager) signalBackend(ctx context.Context, connName, fn string, pid int32) (bool, error) {
+	pool, err := m.Pool(connName)
+	if err != nil {
+		return false, err
+	}
+
+	var ok bool
+	if err := pool.QueryRow(ctx, "SELECT "+fn+"($1)", pid).Scan(&ok); err != nil {
+		return false, fmt.Errorf("%s(%d): %w", fn, pid, err)
+	}
+	return ok, nil
+}
+
+// paramRe finds $n placeholders, which plain EXPLAIN can't plan without values.
+var paramRe = regexp.MustCompile(`\$\d+`)
+
+// ExplainQuery returns the estimated plan of query, without running it.
+// Parameterized queries, as most drivers send them, get a generic plan,
+// which needs PostgreSQL 16 or later.
+func (m *Manager) ExplainQuery(ctx context.Context, connName, query string) (string, error) {
+	pool, err := m.Pool(connName)
+	if err != nil {
+		return "", err
+	}
+
+	// One statement only: with the simple protocol the rest would run
+	stmts := splitStatements(query)
+	switch len(stmts) {
+	case 0:
+		return "", fmt.Errorf("no query to explain")
+	case 1:
+		query = stmts[0]
+	default:
+		return "", fmt.Errorf("can only explain a single statement, got %d", len(stmts))
+	}
+	explain := "EXPLAIN "
+	if paramRe.MatchString(query) {
+		explain = "EXPLAIN (GENERIC_PLAN) "
+	}
+
+	rows, err := pool.Query(ctx, explain+query)
+	if err != nil {
+		return "", fmt.Errorf("explaining query: %w", err)
+	}
+	defer rows.Close()
+
+	var b strings.Builder
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return "", fmt.Errorf("scanning plan: %w", err)
+		}
+		b.WriteString(line + "\n")
+	}
+	if err := rows.Err(); err != nil {
+		return "", fmt.Errorf("explaining query: %w", err)
+	}
+
+	return strings.TrimRight(b.String(), "\n"), nil
+}
@@ -0,0 +1,131 @@
+package db
+
+import (
+	"regexp"
+	"strings"
+)
+
+// safeKeywords start statements that never modify data or schema.
+var safeKeywords = map[string]bool{
+	"select": true, "with": true, "show": true, "explain": true, "values": true,
+	"table": true, "fetch": true, "begin": true, "commit": true, "rollback": true,
+	"set": true, "reset": true,
+}
+
+var (
+	writeInCTE     = regexp.MustCompile(`(?i)\b(insert|update|delete|merge)\b`)
+	selectInto     = regexp.MustCompile(`(?i)\binto\b`)
+	explainAnalyze = regexp.MustCompile(`(?i)^explain\s*(\([^)]*\banalyze\b[^)]*\)|analyze\b)`)
+	targetPattern  = regexp.MustCompile(`(?i)^(?:update(?:\s+only)?|insert\s+into|delete\s+from(?:\s+only)?|merge\s+into|truncate(?:\s+table)?(?:\s+only)?|(?:alter|drop|create(?:\s+or\s+replace)?)\s+(?:table|view|materialized\s+view|index|sequence)(?:\s+concurrently)?(?:\s+if\s+(?:not\s+)?exists)?)\s+("[^"]+"|[^\s(;,]+)`)
+)
+
+// IsWriteQuery reports whether sql contains DML or DDL. It errs towards
+// true, so callers can use it to ask for confirmation.
+func IsWriteQuery(sql string) bool {
+	for _, stmt := range splitStatements(sql) {
+		if isWriteStatement(stmt) {
+			return true
+		}
+	}
+	return false
+}
+
+func isWriteStatement(stmt string) bool {
+	// EXPLAIN ANALYZE executes the statement
+	if m := explainAnalyze.FindStringIndex(stmt); m != nil {
+		return isWriteStatement(strings.TrimSpace(stmt[m[1]:]))
+	}
+
+	word := strings.ToLower(firstWord(stmt))
+	if !safeKeywords[word] {
+		return word != ""
+	}
+
+	switch word {
+	case "with":
+		return writeInCTE.MatchString(stmt)
+	case "select":
+		return selectInto.MatchString(stmt) // SELECT ... INTO creates a table
+	}
+	return false
+}
+
+// StatementTarget guesses the table a DML/DDL statement acts on, or "".
+func StatementTarget(sql string) string {
+	for _, stmt := range splitStatements(sql) {
+		if m := targetPattern.FindStringSubmatch(stmt); m != nil {
+			name := m[1]
+			if i := strings.LastIndex(name, "."); i >= 0 {
+				name = name[i+1:]
+			}
+			return strings.Trim(name, `"`)
+		}
+	}
+	return ""
+}
+
+func firstWord(s string) string {
+	s = strings.TrimLeft(s, "( \t\n")
+	end := strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '_')
+	})
+	if end < 0 {
+		return s
+	}
+	return s[:end]
+}
+
+// splitStatements splits sql on semicolons outside quotes and strips
+// comments, which is enough to classify each statement.
+func splitStatements(sql string) []string {
+	var stmts []string
+	var b strings.Builder
+
+	flush := func() {
+		if s := strings.TrimSpace(b.String()); s != "" {
+			stmts = append(stmts, s)
+		}
+		b.Reset()
+	}
+
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case c == '-' && i+1 < len(sql) && sql[i+1] == '-':
+			for i < len(sql) && sql[i] != '\n' {
+				i++
+			}
+			b.WriteByte(' ')
+		case c == '/' && i+1 < len(sql) && sql[i+1] == '*':
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				i = len(sql)
+			} else {
+				i += end + 3
+			}
+			b.WriteByte(' ')
+		case c == '\'' || c == '"':
+			// Copy the quoted text through, '' and "" escapes included
+			j := i + 1
+			for j < len(sql) {
+				if sql[j] == c {
+					if j+1 < len(sql) && sql[j+1] == c {
+						j += 2
+						continue
+					}
+					break
+				}
+				j++
+			}
+			b.WriteString(sql[i:min(j+1, len(sql))])
+			i = j
+		case c == ';':
+			flush()
+		default:
+			b.WriteByte(c)
+		}
+	}
+	flush()
+
+	return stmts
+}
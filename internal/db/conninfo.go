@@ -0,0 +1,155 @@
+package db
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/zaffron/ezpg/internal/config"
+)
+
+// TLSState returns the negotiated TLS state of conn, or nil when it is unencrypted.
+func TLSState(conn *pgconn.PgConn) *tls.ConnectionState {
+	tc, ok := conn.Conn().(*tls.Conn)
+	if !ok {
+		return nil
+	}
+	state := tc.ConnectionState()
+	return &state
+}
+
+// TLSSummary describes the encryption of a pooled connection to name,
+// e.g. "TLS 1.3, TLS_AES_128_GCM_SHA256" or "no TLS".
+func (m *Manager) TLSSummary(ctx context.Context, name string) (string, error) {
+	pool, err := m.Pool(name)
+	if err != nil {
+		return "", err
+	}
+
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return "", fmt.Errorf("acquiring connection: %w", err)
+	}
+	defer conn.Release()
+
+	return formatTLS(TLSState(conn.Conn().PgConn())), nil
+}
+
+func formatTLS(state *tls.ConnectionState) string {
+	if state == nil {
+		return "no TLS"
+	}
+	return tls.VersionName(state.Version) + ", " + tls.CipherSuiteName(state.CipherSuite)
+}
+
+// Diagnostics describes a successful test connection.
+type Diagnostics struct {
+	ServerVersion string
+	User          string
+	Database      string
+	TLS           string
+	Latency       time.Duration // round trip of a trivial query
+	HotStandby    bool
+}
+
+// TestConnection connects to conn with a throwaway pool, collects diagnostics
+// and closes it again. Nothing is added to the manager's pools.
+func (m *Manager) TestConnection(ctx context.Context, conn config.Connection) (*Diagnostics, error) {
+	m.mu.RLock()
+	prompted, hasPrompted := m.passwords[conn.Name]
+	m.mu.RUnlock()
+
+	pool, tunnel, err := openPool(ctx, conn, "", prompted, hasPrompted, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		pool.Close()
+		if tunnel != nil {
+			tunnel.Close()
+		}
+	}()
+
+	c, err := pool.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("acquiring connection: %w", err)
+	}
+	defer c.Release()
+
+	d := &Diagnostics{TLS: formatTLS(TLSState(c.Conn().PgConn()))}
+
+	start := time.Now()
+	if _, err := c.Exec(ctx, "SELECT 1"); err != nil {
+		return nil, err
+	}
+	d.Latency = time.Since(start)
+
+	err = c.QueryRow(ctx,
+		`SELECT current_setting('server_version'), current_user, current_database(), pg_is_in_recovery()`,
+	).Scan(&d.ServerVersion, &d.User, &d.Database, &d.HotStandby)
+	if err != nil {
+		return nil, fmt.Errorf("reading server info: %w", err)
+	}
+
+	return d, nil
+}
+
+// DescribeConnectError explains a failed connection attempt in plain words.
+func DescribeConnectError(err error) string {
+	var (
+		tunnelErr *TunnelError
+		pgErr     *pgconn.PgError
+		dnsErr    *net.DNSError
+		certErr   *tls.CertificateVerificationError
+		alertErr  tls.AlertError
+		recordErr tls.RecordHeaderError
+	)
+
+	switch {
+	case errors.As(err, &tunnelErr):
+		return "SSH tunnel failed: " + tunnelErr.Err.Error()
+
+	case errors.Is(err, ErrPasswordRequired):
+		return "Authentication failed: no password was found for this connection"
+
+	case errors.As(err, &pgErr):
+		switch pgErr.Code {
+		case "28P01":
+			return "Authentication failed: " + pgErr.Message
+		case "28000":
+			return "Authentication rejected by pg_hba.conf: " + pgErr.Message
+		case "3D000":
+			return "Database does not exist: " + pgErr.Message
+		}
+		return "Server refused the connection: " + pgErr.Message
+
+	case errors.As(err, &dnsErr):
+		if dnsErr.IsNotFound {
+			return "DNS lookup failed: host " + dnsErr.Name + " not found"
+		}
+		return "DNS lookup failed: " + dnsErr.Error()
+
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return "TCP connection refused: nothing is listening on that host and port"
+
+	case errors.Is(err, syscall.ECONNRESET):
+		return "Connection reset: the server or a firewall dropped the connection"
+
+	case errors.As(err, &certErr), errors.As(err, &alertErr), errors.As(err, &recordErr),
+		strings.Contains(err.Error(), "server refused TLS connection"):
+		return "SSL failed: " + err.Error()
+
+	case errors.Is(err, context.DeadlineExceeded), os.IsTimeout(err):
+		return "Timed out: the server did not answer (firewall or wrong host?)"
+	}
+
+	// pgx lists one line per attempted host
+	return strings.ReplaceAll(err.Error(), "\n\t", "; ")
+}
@@ -0,0 +1,363 @@
+package db
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/zaffron/ezpg/internal/config"
+)
+
+// byteaPreview is how many bytes of a bytea value are shown as hex before truncating.
+const byteaPreview = 32
+
+// typeMap is only used to look up element types of builtin arrays and ranges.
+var typeMap = pgtype.NewMap()
+
+// Formatter renders values decoded by pgx as Postgres literal text.
+type Formatter struct {
+	NullDisplay     string
+	Location        *time.Location
+	TimestampFormat string
+}
+
+func NewFormatter(s config.Settings) Formatter {
+	return Formatter{
+		NullDisplay:     s.NullDisplay,
+		Location:        s.Location(),
+		TimestampFormat: s.TimestampFormat,
+	}
+}
+
+// Format renders v, which was decoded from a column of type oid.
+func (f Formatter) Format(v any, oid uint32) string {
+	if v == nil {
+		return f.NullDisplay
+	}
+
+	switch val := v.(type) {
+	case string:
+		return val
+	case bool:
+		return strconv.FormatBool(val)
+	case float32:
+		return strconv.FormatFloat(float64(val), 'g', -1, 32)
+	case float64:
+		return strconv.FormatFloat(val, 'g', -1, 64)
+	case []byte:
+		if oid == pgtype.JSONOID || oid == pgtype.JSONBOID {
+			return string(val)
+		}
+		return formatBytea(val)
+	case [16]byte:
+		return formatUUID(val)
+	case time.Time:
+		return f.formatTime(val, oid)
+	case pgtype.InfinityModifier:
+		if val == pgtype.Infinity {
+			return "infinity"
+		}
+		return "-infinity"
+	case pgtype.Interval:
+		return formatInterval(val)
+	case pgtype.Time:
+		return formatTimeOfDay(val)
+	case pgtype.Range[any]:
+		return f.formatRange(val, elementOID(oid))
+	case pgtype.Multirange[pgtype.Range[any]]:
+		parts := make([]string, len(val))
+		for i, r := range val {
+			parts[i] = f.formatRange(r, multirangeElementOID(oid))
+		}
+		return "{" + strings.Join(parts, ",") + "}"
+	case []any:
+		if oid == pgtype.JSONOID || oid == pgtype.JSONBOID {
+			return formatJSON(val)
+		}
+		return f.formatArray(val, elementOID(oid))
+	case map[string]any:
+		return formatJSON(val)
+	case map[string]*string:
+		return formatHstore(val)
+	case netip.Prefix:
+		if oid == pgtype.InetOID && val.Bits() == val.Addr().BitLen() {
+			return val.Addr().String()
+		}
+		return val.String()
+	case net.HardwareAddr:
+		return val.String()
+	}
+
+	if oid == pgtype.JSONOID || oid == pgtype.JSONBOID {
+		return formatJSON(v)
+	}
+
+	if valuer, ok := v.(driver.Valuer); ok {
+		if dv, err := valuer.Value(); err == nil {
+			if s, ok := dv.(string); ok {
+				return s
+			}
+		}
+	}
+
+	return fmt.Sprintf("%v", v)
+}
+
+// EditText renders v as editable input: unlike Format it never truncates
+// and always produces text Postgres can parse back. NULL becomes "".
+func (f Formatter) EditText(v any, oid uint32) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case []byte:
+		if oid != pgtype.JSONOID && oid != pgtype.JSONBOID {
+			return `\x` + hex.EncodeToString(val)
+		}
+	case time.Time:
+		if oid == pgtype.TimestamptzOID {
+			return val.In(f.location()).Format("2006-01-02 15:04:05.999999-07:00")
+		}
+	}
+
+	return f.Format(v, oid)
+}
+
+func (f Formatter) formatTime(t time.Time, oid uint32) string {
+	switch oid {
+	case pgtype.DateOID:
+		return t.Format("2006-01-02")
+	case pgtype.TimestampOID:
+		return t.Format("2006-01-02 15:04:05.999999")
+	}
+
+	layout := f.TimestampFormat
+	if layout == "" {
+		layout = config.DefaultTimestampFormat
+	}
+
+	return t.In(f.location()).Format(layout)
+}
+
+func (f Formatter) location() *time.Location {
+	if f.Location == nil {
+		return time.Local
+	}
+
+	return f.Location
+}
+
+func (f Formatter) formatArray(elems []any, elemOID uint32) string {
+	parts := make([]string, len(elems))
+	for i, e := range elems {
+		if e == nil {
+			parts[i] = "NULL"
+			continue
+		}
+		if nested, ok := e.([]any); ok {
+			parts[i] = f.formatArray(nested, elemOID)
+			continue
+		}
+		parts[i] = quoteArrayElem(f.Format(e, elemOID))
+	}
+
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func (f Formatter) formatRange(r pgtype.Range[any], elemOID uint32) string {
+	if !r.Valid {
+		return f.NullDisplay
+	}
+	if r.LowerType == pgtype.Empty {
+		return "empty"
+	}
+
+	var b strings.Builder
+	if r.LowerType == pgtype.Inclusive {
+		b.WriteByte('[')
+	} else {
+		b.WriteByte('(')
+	}
+	if r.LowerType != pgtype.Unbounded {
+		b.WriteString(quoteRangeBound(f.Format(r.Lower, elemOID)))
+	}
+	b.WriteByte(',')
+	if r.UpperType != pgtype.Unbounded {
+		b.WriteString(quoteRangeBound(f.Format(r.Upper, elemOID)))
+	}
+	if r.UpperType == pgtype.Inclusive {
+		b.WriteByte(']')
+	} else {
+		b.WriteByte(')')
+	}
+
+	return b.String()
+}
+
+func elementOID(oid uint32) uint32 {
+	t, ok := typeMap.TypeForOID(oid)
+	if !ok {
+		return 0
+	}
+
+	switch c := t.Codec.(type) {
+	case *pgtype.ArrayCodec:
+		return c.ElementType.OID
+	case *pgtype.RangeCodec:
+		return c.ElementType.OID
+	}
+
+	return 0
+}
+
+func multirangeElementOID(oid uint32) uint32 {
+	t, ok := typeMap.TypeForOID(oid)
+	if !ok {
+		return 0
+	}
+
+	if c, ok := t.Codec.(*pgtype.MultirangeCodec); ok {
+		return elementOID(c.ElementType.OID)
+	}
+
+	return 0
+}
+
+func quoteArrayElem(s string) string {
+	if s != "" && !strings.EqualFold(s, "NULL") && !strings.ContainsAny(s, "{},\"\\ \t\n") {
+		return s
+	}
+
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+func quoteRangeBound(s string) string {
+	if s != "" && !strings.ContainsAny(s, "()[],\"\\ \t\n") {
+		return s
+	}
+
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+func formatBytea(b []byte) string {
+	if len(b) <= byteaPreview {
+		return fmt.Sprintf(`\x%s (%s)`, hex.EncodeToString(b), FormatSize(int64(len(b))))
+	}
+
+	return fmt.Sprintf(`\x%s… (%s)`, hex.EncodeToString(b[:byteaPreview]), FormatSize(int64(len(b))))
+}
+
+func formatUUID(u [16]byte) string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16])
+}
+
+func formatJSON(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+
+	return string(data)
+}
+
+func formatHstore(h map[string]*string) string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	quote := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		if h[k] == nil {
+			parts[i] = fmt.Sprintf(`"%s"=>NULL`, quote.Replace(k))
+		} else {
+			parts[i] = fmt.Sprintf(`"%s"=>"%s"`, quote.Replace(k), quote.Replace(*h[k]))
+		}
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// formatInterval follows the IntervalStyle=postgres output, e.g. "1 year 2 mons 3 days 04:05:06".
+func formatInterval(iv pgtype.Interval) string {
+	if !iv.Valid {
+		return ""
+	}
+
+	var parts []string
+	unit := func(n int64, singular, plural string) {
+		if n == 0 {
+			return
+		}
+		if n == 1 {
+			parts = append(parts, "1 "+singular)
+			return
+		}
+		parts = append(parts, fmt.Sprintf("%d %s", n, plural))
+	}
+
+	unit(int64(iv.Months/12), "year", "years")
+	unit(int64(iv.Months%12), "mon", "mons")
+	unit(int64(iv.Days), "day", "days")
+
+	if iv.Microseconds != 0 || len(parts) == 0 {
+		parts = append(parts, formatClock(iv.Microseconds))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func formatTimeOfDay(t pgtype.Time) string {
+	if !t.Valid {
+		return ""
+	}
+
+	return formatClock(t.Microseconds)
+}
+
+func formatClock(us int64) string {
+	sign := ""
+	if us < 0 {
+		sign = "-"
+		us = -us
+	}
+
+	s := fmt.Sprintf("%s%02d:%02d:%02d", sign,
+		us/int64(time.Hour/time.Microsecond),
+		us/int64(time.Minute/time.Microsecond)%60,
+		us/int64(time.Second/time.Microsecond)%60)
+
+	if frac := us % int64(time.Second/time.Microsecond); frac != 0 {
+		s += strings.TrimRight(fmt.Sprintf(".%06d", frac), "0")
+	}
+
+	return s
+}
+
+// FormatSize renders a byte count using binary units, e.g. "1.5 KiB".
+func FormatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		if n == 1 {
+			return "1 byte"
+		}
+		return fmt.Sprintf("%d bytes", n)
+	}
+
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
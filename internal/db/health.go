@@ -0,0 +1,175 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/zaffron/ezpg/internal/config"
+)
+
+// Health is the state of a connection as seen by the last check.
+type Health int
+
+const (
+	HealthConnected Health = iota
+	HealthDegraded         // answering, but slowly
+	HealthLost             // not answering; being reconnected
+)
+
+func (h Health) String() string {
+	switch h {
+	case HealthConnected:
+		return "connected"
+	case HealthDegraded:
+		return "degraded"
+	case HealthLost:
+		return "lost"
+	}
+	return "unknown"
+}
+
+// degradedLatency is the ping round trip above which a connection counts as degraded.
+const degradedLatency = time.Second
+
+// CheckHealth pings the pool name is working against.
+func (m *Manager) CheckHealth(ctx context.Context, name string) (Health, time.Duration, error) {
+	pool, err := m.Pool(name)
+	if err != nil {
+		return HealthLost, 0, err
+	}
+
+	start := time.Now()
+	if err := pool.Ping(ctx); err != nil {
+		return HealthLost, 0, err
+	}
+	latency := time.Since(start)
+
+	if latency > degradedLatency {
+		return HealthDegraded, latency, nil
+	}
+	return HealthConnected, latency, nil
+}
+
+// Connected returns the names of the connections with open pools.
+func (m *Manager) Connected() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	var names []string
+	for key := range m.pools {
+		if !seen[key.conn] {
+			seen[key.conn] = true
+			names = append(names, key.conn)
+		}
+	}
+	return names
+}
+
+// Reconnect replaces name's pools and tunnels with new ones, keeping the
+// database in use and any role or search_path chosen at run time. The old
+// pools stay in place if reconnecting fails. Dialing happens without holding
+// the lock, so the UI can keep reading the manager meanwhile.
+func (m *Manager) Reconnect(ctx context.Context, name string) error {
+	m.mu.RLock()
+	conn, ok := m.conns[name]
+	var keys []poolKey
+	for key := range m.pools {
+		if key.conn == name {
+			keys = append(keys, key)
+		}
+	}
+	prompted, hasPrompted := m.passwords[name]
+	m.mu.RUnlock()
+
+	if !ok || len(keys) == 0 {
+		return fmt.Errorf("not connected to %s", name)
+	}
+
+	override := func() config.SessionSettings { return m.sessionOverride(name) }
+	pools := make(map[poolKey]*pgxpool.Pool, len(keys))
+	tunnels := make(map[poolKey]*Tunnel)
+	closeNew := func() {
+		for key, pool := range pools {
+			pool.Close()
+			if t := tunnels[key]; t != nil {
+				t.Close()
+			}
+		}
+	}
+	for _, key := range keys {
+		pool, tunnel, err := openPool(ctx, *conn, key.database, prompted, hasPrompted, override)
+		if err != nil {
+			closeNew()
+			return err
+		}
+		pools[key] = pool
+		if tunnel != nil {
+			tunnels[key] = tunnel
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key, pool := range pools {
+		old, ok := m.pools[key]
+		if !ok {
+			// Disconnected while we were dialing
+			pool.Close()
+			if t := tunnels[key]; t != nil {
+				t.Close()
+			}
+			continue
+		}
+		old.Close()
+		if t := m.tunnels[key]; t != nil {
+			t.Close()
+			delete(m.tunnels, key)
+		}
+		m.pools[key] = pool
+		if t := tunnels[key]; t != nil {
+			m.tunnels[key] = t
+		}
+	}
+
+	return nil
+}
+
+// IsConnectionLost reports whether err means the server or the network went
+// away, as opposed to the statement itself failing. Such statements are
+// worth retrying after reconnecting.
+func IsConnectionLost(err error) bool {
+	// A statement running out of time says nothing about the connection
+	if err == nil || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// Class 08 is connection_exception; 57P01-03 are shutdowns and startup
+		return strings.HasPrefix(pgErr.Code, "08") ||
+			pgErr.Code == "57P01" || pgErr.Code == "57P02" || pgErr.Code == "57P03"
+	}
+
+	var connectErr *pgconn.ConnectError
+	var netErr net.Error
+	var tunnelErr *TunnelError
+	return errors.As(err, &connectErr) ||
+		errors.As(err, &netErr) ||
+		errors.As(err, &tunnelErr) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		strings.Contains(err.Error(), "conn closed")
+}
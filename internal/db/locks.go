@@ -0,0 +1,96 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// BlockingProcess is a session that waits on a lock, or holds one that
+// another session waits on.
+type BlockingProcess struct {
+	PID       int32
+	BlockedBy []int32 // from pg_blocking_pids, empty for the root of a chain
+	User      string
+	Database  string
+	State     string
+	Query     string
+	Relation  string // relation waited on, or held by a blocker; the lock type for other locks
+	Mode      string // lock mode waited for, or held
+	Granted   bool   // Mode is held rather than waited for
+	Waiting   time.Duration
+	XactAge   time.Duration // since the transaction started
+}
+
+// ListBlocking returns the sessions that are part of a blocking chain, those
+// waiting and those they wait on.
+func (m *Manager) ListBlocking(ctx context.Context, connName string) ([]BlockingProcess, error) {
+	pool, err := m.Pool(connName)
+	if err != nil {
+		return nil, err
+	}
+
+	/**
+	* Every session blocked by another, plus the ones blocking them. A waiter
+	* shows the lock it asked for; a blocker that isn't waiting itself shows
+	* the lock it holds on a relation someone else waits for.
+	 */
+	rows, err := pool.Query(ctx, `
+		WITH procs AS (
+			SELECT pid, pg_blocking_pids(pid) AS blocked_by, usename, datname,
+				state, query, query_start, xact_start
+			FROM pg_stat_activity
+		), involved AS (
+			SELECT pid FROM procs WHERE cardinality(blocked_by) > 0
+			UNION
+			SELECT unnest(blocked_by) FROM procs
+		)
+		SELECT p.pid, p.blocked_by,
+			coalesce(p.usename, ''), coalesce(p.datname, ''),
+			coalesce(p.state, ''), coalesce(p.query, ''),
+			coalesce(w.target, h.target, ''), coalesce(w.mode, h.mode, ''),
+			w.mode IS NULL AND h.mode IS NOT NULL,
+			CASE WHEN w.mode IS NOT NULL
+				THEN coalesce(extract(epoch FROM now() - p.query_start), 0) ELSE 0 END::float8,
+			coalesce(extract(epoch FROM now() - p.xact_start), 0)::float8
+		FROM procs p
+		JOIN involved USING (pid)
+		LEFT JOIN LATERAL (
+			SELECT coalesce(l.relation::regclass::text, l.locktype) AS target, l.mode
+			FROM pg_locks l
+			WHERE l.pid = p.pid AND NOT l.granted
+			LIMIT 1
+		) w ON true
+		LEFT JOIN LATERAL (
+			SELECT l.relation::regclass::text AS target, l.mode
+			FROM pg_locks l
+			WHERE l.pid = p.pid AND l.granted AND l.relation IN (
+				SELECT relation FROM pg_locks WHERE NOT granted AND relation IS NOT NULL
+			)
+			ORDER BY array_position(ARRAY['AccessExclusiveLock', 'ExclusiveLock',
+				'ShareRowExclusiveLock', 'ShareLock', 'ShareUpdateExclusiveLock',
+				'RowExclusiveLock', 'RowShareLock', 'AccessShareLock'], l.mode)
+			LIMIT 1
+		) h ON true
+		ORDER BY p.xact_start NULLS LAST, p.pid
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("listing blocking sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var list []BlockingProcess
+	for rows.Next() {
+		var p BlockingProcess
+		var waiting, xactAge float64
+		if err := rows.Scan(&p.PID, &p.BlockedBy, &p.User, &p.Database, &p.State, &p.Query,
+			&p.Relation, &p.Mode, &p.Granted, &waiting, &xactAge); err != nil {
+			return nil, fmt.Errorf("scanning blocking session: %w", err)
+		}
+		p.Waiting = time.Duration(max(waiting, 0) * float64(time.Second))
+		p.XactAge = time.Duration(max(xactAge, 0) * float64(time.Second))
+		list = append(list, p)
+	}
+
+	return list, rows.Err()
+}
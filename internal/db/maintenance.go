@@ -0,0 +1,226 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// MaintenanceKind is the command a Maintenance runs.
+type MaintenanceKind int
+
+const (
+	MaintenanceVacuum MaintenanceKind = iota
+	MaintenanceAnalyze
+	MaintenanceReindex
+	MaintenanceRefresh // materialized views only
+	MaintenanceTruncate
+	MaintenanceDrop
+)
+
+// Maintenance is a maintenance command on one table or materialized view.
+type Maintenance struct {
+	Kind    MaintenanceKind
+	Schema  string
+	Table   string
+	MatView bool
+
+	Full            bool // VACUUM
+	Analyze         bool // VACUUM
+	Verbose         bool // VACUUM, ANALYZE
+	Concurrently    bool // REINDEX, REFRESH
+	RestartIdentity bool // TRUNCATE
+	Cascade         bool // TRUNCATE, DROP
+}
+
+// parts returns what goes before and after the table name in the statement.
+func (mt Maintenance) parts() (head, tail string) {
+	switch mt.Kind {
+	case MaintenanceVacuum:
+		var opts []string
+		if mt.Full {
+			opts = append(opts, "FULL")
+		}
+		if mt.Verbose {
+			opts = append(opts, "VERBOSE")
+		}
+		if mt.Analyze {
+			opts = append(opts, "ANALYZE")
+		}
+		head = "VACUUM"
+		if len(opts) > 0 {
+			head += " (" + strings.Join(opts, ", ") + ")"
+		}
+	case MaintenanceAnalyze:
+		head = "ANALYZE"
+		if mt.Verbose {
+			head += " (VERBOSE)"
+		}
+	case MaintenanceReindex:
+		head = "REINDEX TABLE"
+		if mt.Concurrently {
+			head += " CONCURRENTLY"
+		}
+	case MaintenanceRefresh:
+		head = "REFRESH MATERIALIZED VIEW"
+		if mt.Concurrently {
+			head += " CONCURRENTLY"
+		}
+	case MaintenanceTruncate:
+		head = "TRUNCATE"
+		if mt.RestartIdentity {
+			tail += " RESTART IDENTITY"
+		}
+		if mt.Cascade {
+			tail += " CASCADE"
+		}
+	case MaintenanceDrop:
+		head = "DROP TABLE"
+		if mt.MatView {
+			head = "DROP MATERIALIZED VIEW"
+		}
+		if mt.Cascade {
+			tail = " CASCADE"
+		}
+	}
+	return head, tail
+}
+
+// Label names the command without its table, e.g. "VACUUM (FULL, ANALYZE)".
+func (mt Maintenance) Label() string {
+	head, tail := mt.parts()
+	return head + tail
+}
+
+// SQL is the statement that runs the command.
+func (mt Maintenance) SQL() string {
+	head, tail := mt.parts()
+	return head + " " + pgx.Identifier{mt.Schema, mt.Table}.Sanitize() + tail
+}
+
+// Destructive reports whether the command throws away data.
+func (mt Maintenance) Destructive() bool {
+	return mt.Kind == MaintenanceTruncate || mt.Kind == MaintenanceDrop
+}
+
+// Notice is a NOTICE, INFO or WARNING the server sent while running a command.
+type Notice struct {
+	Severity string
+	Message  string // with the detail, if any, on the lines below
+}
+
+// noticeHandlers routes notices to whoever runs a command on the session that
+// raised them. Pools send every notice through routeNotice; sessions nobody
+// listens on drop theirs, as pgx would by default.
+var noticeHandlers sync.Map // *pgconn.PgConn -> func(*pgconn.Notice)
+
+func routeNotice(c *pgconn.PgConn, n *pgconn.Notice) {
+	if h, ok := noticeHandlers.Load(c); ok {
+		h.(func(*pgconn.Notice))(n)
+	}
+}
+
+// RunMaintenance runs mt on a session of its own, so its notices can be told
+// apart. started is called with the session's pid before the command is sent,
+// notice with each notice it raises. Cancelling ctx only abandons the
+// session, leaving the command running on the server; to stop it, cancel its
+// pid with CancelBackend.
+func (m *Manager) RunMaintenance(ctx context.Context, connName string, mt Maintenance, started func(pid int32), notice func(Notice)) error {
+	pool, err := m.Pool(connName)
+	if err != nil {
+		return err
+	}
+
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquiring a session: %w", err)
+	}
+	defer conn.Release()
+
+	pc := conn.Conn().PgConn()
+	noticeHandlers.Store(pc, func(n *pgconn.Notice) {
+		msg := n.Message
+		if n.Detail != "" {
+			msg += "\n" + n.Detail
+		}
+		notice(Notice{Severity: n.Severity, Message: msg})
+	})
+	defer noticeHandlers.Delete(pc)
+
+	started(int32(pc.PID()))
+	if _, err := conn.Exec(ctx, mt.SQL()); err != nil {
+		return fmt.Errorf("%s: %w", mt.Label(), err)
+	}
+	return nil
+}
+
+// Progress is how far a running command has got, from pg_stat_progress_*.
+type Progress struct {
+	Phase string
+	Done  int64 // blocks
+	Total int64
+}
+
+// progressView is a pg_stat_progress_* view and its block counters.
+type progressView struct {
+	name, done, total string
+}
+
+// progressViews lists where the progress of mt shows up, in the order its
+// phases run. REFRESH, TRUNCATE and DROP report none.
+func progressViews(mt Maintenance) []progressView {
+	analyze := progressView{"pg_stat_progress_analyze", "sample_blks_scanned", "sample_blks_total"}
+	switch mt.Kind {
+	case MaintenanceVacuum:
+		// VACUUM FULL rewrites the table the way CLUSTER does
+		views := []progressView{{"pg_stat_progress_vacuum", "heap_blks_scanned", "heap_blks_total"}}
+		if mt.Full {
+			views = []progressView{{"pg_stat_progress_cluster", "heap_blks_scanned", "heap_blks_total"}}
+		}
+		if mt.Analyze {
+			views = append(views, analyze)
+		}
+		return views
+	case MaintenanceAnalyze:
+		return []progressView{analyze}
+	case MaintenanceReindex:
+		return []progressView{{"pg_stat_progress_create_index", "blocks_done", "blocks_total"}}
+	}
+	return nil
+}
+
+// MaintenanceProgress returns the progress of mt running as pid. It reports
+// false when the server has none to give: the command doesn't report any, the
+// server predates the view, or it is between phases.
+func (m *Manager) MaintenanceProgress(ctx context.Context, connName string, mt Maintenance, pid int32) (Progress, bool, error) {
+	pool, err := m.Pool(connName)
+	if err != nil {
+		return Progress{}, false, err
+	}
+
+	for _, v := range progressViews(mt) {
+		var p Progress
+		err := pool.QueryRow(ctx, fmt.Sprintf(`
+			SELECT phase, coalesce(%s, 0), coalesce(%s, 0)
+			FROM %s
+			WHERE pid = $1
+		`, v.done, v.total, v.name), pid).Scan(&p.Phase, &p.Done, &p.Total)
+
+		var pgErr *pgconn.PgError
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			continue
+		case errors.As(err, &pgErr) && pgErr.Code == "42P01": // undefined_table
+			continue
+		case err != nil:
+			return Progress{}, false, fmt.Errorf("reading %s: %w", v.name, err)
+		}
+		return p, true, nil
+	}
+	return Progress{}, false, nil
+}
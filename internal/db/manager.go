@@ -2,68 +2,311 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
+	"strconv"
+	"strings"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/zaffron/ezpg/internal/config"
 )
 
 func NewManager(connections []config.Connection) *Manager {
 	m := &Manager{
-		pools: make(map[string]*pgxpool.Pool),
-		conns: make(map[string]*config.Connection),
+		pools:     make(map[poolKey]*pgxpool.Pool),
+		conns:     make(map[string]*config.Connection),
+		passwords: make(map[string]string),
+		tunnels:   make(map[poolKey]*Tunnel),
+		current:   make(map[string]string),
+		defaultDB: make(map[string]string),
+		sessions:  make(map[string]config.SessionSettings),
 	}
 
-	for i := range connections {
-		m.conns[connections[i].Name] = &connections[i]
+	// Copy, so reordering or deleting in the config slice can't change what a name points to
+	for _, conn := range connections {
+		m.conns[conn.Name] = &conn
 	}
 
 	return m
 }
 
 func (m *Manager) Connect(ctx context.Context, name string) error {
+	m.mu.RLock()
+	database := m.current[name]
+	m.mu.RUnlock()
+
+	return m.open(ctx, poolKey{conn: name, database: database})
+}
+
+// UseDatabase makes name work against database from now on, opening a pool
+// for it unless one is open already. The connection's other pools stay open.
+func (m *Manager) UseDatabase(ctx context.Context, name, database string) error {
+	m.mu.RLock()
+	if database == m.defaultDB[name] {
+		database = ""
+	}
+	m.mu.RUnlock()
+
+	key := poolKey{conn: name, database: database}
+	if err := m.open(ctx, key); err != nil {
+		return err
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if _, ok := m.pools[name]; ok {
-		return nil // already connected
+	if _, ok := m.pools[key]; !ok {
+		// Disconnected while we were dialing
+		return fmt.Errorf("not connected to %s", name)
 	}
+	m.current[name] = database
+	return nil
+}
 
-	conn, ok := m.conns[name]
+// SwitchDatabase is UseDatabase for a database whose pool is already open.
+// It reports false, changing nothing, when it isn't.
+func (m *Manager) SwitchDatabase(name, database string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if database == m.defaultDB[name] {
+		database = ""
+	}
+	if _, ok := m.pools[poolKey{conn: name, database: database}]; !ok {
+		return false
+	}
+
+	m.current[name] = database
+	return true
+}
+
+// Database returns the name of the database name is working against, or ""
+// when not connected.
+func (m *Manager) Database(name string) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if db := m.current[name]; db != "" {
+		return db
+	}
+	return m.defaultDB[name]
+}
+
+// open opens the pool for key if it isn't open. Like Reconnect, it dials
+// without holding the lock and only takes it to install the pool.
+func (m *Manager) open(ctx context.Context, key poolKey) error {
+	m.mu.RLock()
+	_, connected := m.pools[key]
+	conn, ok := m.conns[key.conn]
+	prompted, hasPrompted := m.passwords[key.conn]
+	m.mu.RUnlock()
+
+	if connected {
+		return nil
+	}
 	if !ok {
-		return fmt.Errorf("unknown connection: %s", name)
+		return fmt.Errorf("unknown connection: %s", key.conn)
 	}
 
-	pool, err := pgxpool.New(ctx, conn.DSN())
+	override := func() config.SessionSettings { return m.sessionOverride(key.conn) }
+	pool, tunnel, err := openPool(ctx, *conn, key.database, prompted, hasPrompted, override)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if errors.Is(err, ErrPasswordRequired) {
+		if pw, ok := m.passwords[key.conn]; ok && pw == prompted {
+			delete(m.passwords, key.conn) // ask again
+		}
+	}
 	if err != nil {
-		return fmt.Errorf("connecting to %s: %w", name, err)
+		return err
 	}
 
-	if err := pool.Ping(ctx); err != nil {
+	closeNew := func() {
 		pool.Close()
-		return fmt.Errorf("pinging %s: %w", name, err)
+		if tunnel != nil {
+			tunnel.Close()
+		}
+	}
+	if m.conns[key.conn] != conn {
+		// Edited or removed while we were dialing
+		closeNew()
+		return fmt.Errorf("connection %s changed while connecting", key.conn)
+	}
+	if _, ok := m.pools[key]; ok {
+		// Another connect got there first
+		closeNew()
+		return nil
 	}
 
-	m.pools[name] = pool
+	m.pools[key] = pool
+	if tunnel != nil {
+		m.tunnels[key] = tunnel
+	}
+	if key.database == "" {
+		m.defaultDB[key.conn] = pool.Config().ConnConfig.Database
+	}
 
 	return nil
 }
 
+// openPool resolves conn, opens its SSH tunnel if it has one, and returns a
+// pinged pool. Nothing is registered with the manager. database, when set,
+// replaces the configured database. prompted overrides the configured
+// password when hasPrompted is set. override, if not nil, is consulted on
+// every new session for settings that take precedence over conn.Session.
+func openPool(ctx context.Context, conn config.Connection, database, prompted string, hasPrompted bool, override func() config.SessionSettings) (*pgxpool.Pool, *Tunnel, error) {
+	name := conn.Name
+
+	resolved, err := conn.Resolve(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := resolved.CheckTLS(); err != nil {
+		return nil, nil, fmt.Errorf("connection %s: %w", name, err)
+	}
+	if hasPrompted {
+		resolved.Password = prompted
+	}
+
+	// ParseConfig also picks up PGPASSWORD and ~/.pgpass when the DSN has no password
+	poolCfg, err := pgxpool.ParseConfig(resolved.DSN())
+	if err != nil {
+		return nil, nil, fmt.Errorf("parsing connection %s: %w", name, err)
+	}
+	// The DSN only carries the password in key=value form, so a url:
+	// connection gets it here, over any password in the URL itself
+	if resolved.Password != "" {
+		poolCfg.ConnConfig.Password = resolved.Password
+	}
+	noPassword := poolCfg.ConnConfig.Password == ""
+	if database != "" {
+		poolCfg.ConnConfig.Database = database
+	}
+
+	poolCfg.AfterConnect = afterConnect(func() []sessionSetting {
+		session := resolved.Session
+		if override != nil {
+			session = session.With(override())
+		}
+		return sessionSettings(session, resolved.ServerReadOnly)
+	})
+	poolCfg.ConnConfig.OnNotice = routeNotice
+
+	var tunnel *Tunnel
+	if resolved.SSH != nil {
+		tunnel, err = tunnelPool(ctx, *resolved.SSH, poolCfg)
+		if err != nil {
+			return nil, nil, fmt.Errorf("connecting to %s: %w", name, err)
+		}
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolCfg)
+	if err != nil {
+		if tunnel != nil {
+			tunnel.Close()
+		}
+		return nil, nil, fmt.Errorf("connecting to %s: %w", name, err)
+	}
+
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		if tunnel != nil {
+			tunnel.Close()
+			if terr := tunnel.Err(); terr != nil {
+				return nil, nil, fmt.Errorf("connecting to %s: %w", name, terr)
+			}
+		}
+		if isAuthError(err) && (noPassword || hasPrompted) {
+			return nil, nil, fmt.Errorf("connecting to %s: %w", name, ErrPasswordRequired)
+		}
+		return nil, nil, fmt.Errorf("pinging %s: %w", name, err)
+	}
+
+	return pool, tunnel, nil
+}
+
+// tunnelPool forwards to the database host through SSH and points poolCfg at
+// the local end. The host name is kept so TLS still verifies the real server.
+func tunnelPool(ctx context.Context, cfg config.SSHConfig, poolCfg *pgxpool.Config) (*Tunnel, error) {
+	cc := poolCfg.ConnConfig
+	if strings.HasPrefix(cc.Host, "/") {
+		return nil, &TunnelError{Err: fmt.Errorf("cannot tunnel to unix socket %s", cc.Host)}
+	}
+
+	remote := net.JoinHostPort(cc.Host, strconv.Itoa(int(cc.Port)))
+	tunnel, err := OpenTunnel(ctx, cfg, remote)
+	if err != nil {
+		return nil, err
+	}
+
+	// The database host may only resolve on the bastion's side
+	cc.LookupFunc = func(ctx context.Context, host string) ([]string, error) {
+		return []string{host}, nil
+	}
+	cc.DialFunc = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, "tcp", tunnel.Addr())
+	}
+	cc.Fallbacks = nil
+
+	return tunnel, nil
+}
+
+// closeLocked closes every pool and tunnel for name. m.mu must be held.
+func (m *Manager) closeLocked(name string) {
+	for key, pool := range m.pools {
+		if key.conn == name {
+			pool.Close()
+			delete(m.pools, key)
+		}
+	}
+	for key, tunnel := range m.tunnels {
+		if key.conn == name {
+			tunnel.Close()
+			delete(m.tunnels, key)
+		}
+	}
+	delete(m.current, name)
+	delete(m.defaultDB, name)
+
+	m.sessMu.Lock()
+	delete(m.sessions, name)
+	m.sessMu.Unlock()
+}
+
+// ErrPasswordRequired is returned by Connect when the server rejected the
+// login and no password came from the config, PGPASSWORD or ~/.pgpass.
+var ErrPasswordRequired = errors.New("password required")
+
+func isAuthError(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "28P01" // invalid_password
+}
+
+// SetPassword supplies a password for the next Connect to name. It is never saved.
+func (m *Manager) SetPassword(name, password string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.passwords[name] = password
+}
+
 func (m *Manager) Disconnect(name string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if pool, ok := m.pools[name]; ok {
-		pool.Close()
-		delete(m.pools, name)
-	}
+	m.closeLocked(name)
 }
 
 func (m *Manager) Pool(name string) (*pgxpool.Pool, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	pool, ok := m.pools[name]
+	pool, ok := m.pools[poolKey{conn: name, database: m.current[name]}]
 	if !ok {
 		return nil, fmt.Errorf("not connected to %s", name)
 	}
@@ -75,7 +318,7 @@ func (m *Manager) IsConnected(name string) bool {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	_, ok := m.pools[name]
+	_, ok := m.pools[poolKey{conn: name, database: m.current[name]}]
 	return ok
 }
 
@@ -98,11 +341,9 @@ func (m *Manager) RemoveConnection(name string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if pool, ok := m.pools[name]; ok {
-		pool.Close()
-		delete(m.pools, name)
-	}
+	m.closeLocked(name)
 	delete(m.conns, name)
+	delete(m.passwords, name)
 }
 
 func (m *Manager) UpdateConnection(oldName string, conn config.Connection) {
@@ -110,11 +351,9 @@ func (m *Manager) UpdateConnection(oldName string, conn config.Connection) {
 	defer m.mu.Unlock()
 
 	// Close old pool if connected
-	if pool, ok := m.pools[oldName]; ok {
-		pool.Close()
-		delete(m.pools, oldName)
-	}
+	m.closeLocked(oldName)
 	delete(m.conns, oldName)
+	delete(m.passwords, oldName)
 	m.conns[conn.Name] = &conn
 }
 
@@ -122,8 +361,7 @@ func (m *Manager) CloseAll() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	for name, pool := range m.pools {
-		pool.Close()
-		delete(m.pools, name)
+	for key := range m.pools {
+		m.closeLocked(key.conn)
 	}
 }
@@ -0,0 +1,220 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Statement is a single parameterized SQL statement.
+type Statement struct {
+	SQL  string
+	Args []any
+}
+
+// String renders the statement with its arguments inlined, for previews only.
+func (s Statement) String() string {
+	var b strings.Builder
+	// One pass, so $1 never clobbers $10 and a substituted value that
+	// happens to contain $2 is left alone. Quoted text is copied as is.
+	var quote byte
+	for i := 0; i < len(s.SQL); i++ {
+		c := s.SQL[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '$':
+			j := i + 1
+			for j < len(s.SQL) && s.SQL[j] >= '0' && s.SQL[j] <= '9' {
+				j++
+			}
+			if n, err := strconv.Atoi(s.SQL[i+1 : j]); err == nil && n >= 1 && n <= len(s.Args) {
+				b.WriteString(previewLiteral(s.Args[n-1]))
+				i = j - 1
+				continue
+			}
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// previewLiteral renders an argument the way Postgres would read it back:
+// bytea as \x hex, uuid in its dashed form, timestamps with their offset.
+func previewLiteral(v any) string {
+	if v == nil {
+		return "NULL"
+	}
+	var oid uint32
+	if _, ok := v.(time.Time); ok {
+		oid = pgtype.TimestamptzOID
+	}
+	text := Formatter{Location: time.UTC}.EditText(v, oid)
+	return "'" + strings.ReplaceAll(text, "'", "''") + "'"
+}
+
+// PreviewStatements renders up to limit statements, one per line.
+func PreviewStatements(stmts []Statement, limit int) string {
+	var b strings.Builder
+	for i, s := range stmts {
+		if i == limit {
+			fmt.Fprintf(&b, "-- ... and %d more\n", len(stmts)-limit)
+			break
+		}
+		b.WriteString(s.String() + ";\n")
+	}
+	return b.String()
+}
+
+// rowWhere builds a WHERE condition matching row by its primary key columns,
+// or by all columns when the table has none. Placeholders continue after args.
+func rowWhere(columns, pkCols []string, row []any, args []any) (string, []any) {
+	whereCols := pkCols
+	if len(whereCols) == 0 {
+		whereCols = columns
+	}
+
+	var conds []string
+	for _, col := range whereCols {
+		for i, c := range columns {
+			if c != col {
+				continue
+			}
+			if row[i] == nil {
+				conds = append(conds, fmt.Sprintf("%q IS NULL", col))
+			} else {
+				args = append(args, row[i])
+				conds = append(conds, fmt.Sprintf("%q = $%d", col, len(args)))
+			}
+			break
+		}
+	}
+
+	return strings.Join(conds, " AND "), args
+}
+
+func DeleteRowStatement(schema, table string, columns, pkCols []string, row []any) Statement {
+	where, args := rowWhere(columns, pkCols, row, nil)
+	return Statement{
+		SQL: fmt.Sprintf(
+			`DELETE FROM %q.%q WHERE ctid = (SELECT ctid FROM %q.%q WHERE %s LIMIT 1)`,
+			schema, table, schema, table, where,
+		),
+		Args: args,
+	}
+}
+
+func UpdateCellStatement(schema, table string, columns, pkCols []string, row []any, col int, v CellValue) Statement {
+	var args []any
+	var set string
+	switch v.Kind {
+	case CellNull:
+		set = fmt.Sprintf("%q = NULL", columns[col])
+	case CellDefault:
+		set = fmt.Sprintf("%q = DEFAULT", columns[col])
+	default:
+		args = append(args, v.Text)
+		set = fmt.Sprintf("%q = $1", columns[col])
+	}
+
+	where, args := rowWhere(columns, pkCols, row, args)
+	return Statement{
+		SQL:  fmt.Sprintf(`UPDATE %q.%q SET %s WHERE %s`, schema, table, set, where),
+		Args: args,
+	}
+}
+
+// InsertRowStatement builds an INSERT ... RETURNING *. Columns left at
+// DEFAULT are omitted so the server fills them in.
+func InsertRowStatement(schema, table string, columns []string, values []CellValue) Statement {
+	var cols, exprs []string
+	var args []any
+	for i, col := range columns {
+		switch values[i].Kind {
+		case CellDefault:
+			continue
+		case CellNull:
+			exprs = append(exprs, "NULL")
+		default:
+			args = append(args, values[i].Text)
+			exprs = append(exprs, fmt.Sprintf("$%d", len(args)))
+		}
+		cols = append(cols, fmt.Sprintf("%q", col))
+	}
+
+	if len(cols) == 0 {
+		return Statement{SQL: fmt.Sprintf(`INSERT INTO %q.%q DEFAULT VALUES RETURNING *`, schema, table)}
+	}
+
+	return Statement{
+		SQL: fmt.Sprintf(`INSERT INTO %q.%q (%s) VALUES (%s) RETURNING *`,
+			schema, table, strings.Join(cols, ", "), strings.Join(exprs, ", ")),
+		Args: args,
+	}
+}
+
+// UpsertRowStatement inserts a row, updating the other given columns when
+// a row with the same primary key already exists.
+func UpsertRowStatement(schema, table string, columns, pkCols []string, values []CellValue) Statement {
+	st := InsertRowStatement(schema, table, columns, values)
+	st.SQL = strings.TrimSuffix(st.SQL, " RETURNING *")
+
+	isPK := make(map[string]bool, len(pkCols))
+	quotedPK := make([]string, len(pkCols))
+	for i, pk := range pkCols {
+		isPK[pk] = true
+		quotedPK[i] = fmt.Sprintf("%q", pk)
+	}
+
+	var sets []string
+	for i, col := range columns {
+		if !isPK[col] && values[i].Kind != CellDefault {
+			sets = append(sets, fmt.Sprintf("%q = EXCLUDED.%q", col, col))
+		}
+	}
+
+	if len(sets) == 0 {
+		st.SQL += fmt.Sprintf(" ON CONFLICT (%s) DO NOTHING", strings.Join(quotedPK, ", "))
+	} else {
+		st.SQL += fmt.Sprintf(" ON CONFLICT (%s) DO UPDATE SET %s", strings.Join(quotedPK, ", "), strings.Join(sets, ", "))
+	}
+
+	return st
+}
+
+// ExecTx runs stmts in a single transaction and returns the total number of affected rows.
+func (m *Manager) ExecTx(ctx context.Context, connName string, stmts []Statement) (int64, error) {
+	pool, err := m.Pool(connName)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback(ctx) // no-op once committed
+
+	var affected int64
+	for i, s := range stmts {
+		tag, err := tx.Exec(ctx, s.SQL, s.Args...)
+		if err != nil {
+			return 0, fmt.Errorf("statement %d of %d: %w", i+1, len(stmts), err)
+		}
+		affected += tag.RowsAffected()
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("committing transaction: %w", err)
+	}
+
+	return affected, nil
+}
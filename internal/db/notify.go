@@ -0,0 +1,167 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Notification is a NOTIFY received by a Listener.
+type Notification struct {
+	At      time.Time
+	Channel string
+	Payload string
+	PID     uint32 // of the session that sent it
+}
+
+// ErrListenerStopped is returned for requests made after a Listener stopped.
+var ErrListenerStopped = errors.New("listener stopped")
+
+// Listener is a connection of its own that LISTENs on channels. The pool
+// can't be used for this as a notification only reaches the session that
+// listens, and pooled sessions come and go.
+type Listener struct {
+	conn     *pgx.Conn
+	requests chan listenRequest
+	done     chan struct{}
+
+	mu   sync.Mutex
+	wake context.CancelFunc // interrupts the wait for a notification
+}
+
+type listenRequest struct {
+	sql    string
+	result chan error
+}
+
+// NewListener opens a connection for LISTEN to the database connName is
+// using, with the same settings as the pool's: its tunnel, and the role,
+// search_path and read-only mode the pool sets on each session.
+func (m *Manager) NewListener(ctx context.Context, connName string) (*Listener, error) {
+	pool, err := m.Pool(connName)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := pool.Config()
+	conn, err := pgx.ConnectConfig(ctx, cfg.ConnConfig.Copy())
+	if err != nil {
+		return nil, fmt.Errorf("opening listener connection: %w", err)
+	}
+	if cfg.AfterConnect != nil {
+		if err := cfg.AfterConnect(ctx, conn); err != nil {
+			conn.Close(context.Background())
+			return nil, err
+		}
+	}
+
+	return &Listener{
+		conn:     conn,
+		requests: make(chan listenRequest, 8),
+		done:     make(chan struct{}),
+	}, nil
+}
+
+// Close closes the connection of a Listener that was never Run; Run closes
+// it otherwise.
+func (l *Listener) Close(ctx context.Context) error {
+	return l.conn.Close(ctx)
+}
+
+// Run waits for notifications and hands them to notify until ctx is
+// cancelled or the connection fails, then closes the connection. LISTEN
+// and UNLISTEN are run in between, since the connection can only do one
+// thing at a time.
+func (l *Listener) Run(ctx context.Context, notify func(Notification)) error {
+	defer close(l.done)
+	defer l.conn.Close(context.Background())
+
+	for {
+		for pending := true; pending; {
+			select {
+			case req := <-l.requests:
+				_, err := l.conn.Exec(ctx, req.sql)
+				req.result <- err
+			default:
+				pending = false
+			}
+		}
+
+		waitCtx, cancel := context.WithCancel(ctx)
+		l.mu.Lock()
+		l.wake = cancel
+		l.mu.Unlock()
+		// A request sent since the queue was drained may have woken the
+		// previous wait rather than this one
+		if len(l.requests) > 0 {
+			cancel()
+		}
+
+		n, err := l.conn.WaitForNotification(waitCtx)
+		cancel()
+		switch {
+		case err == nil:
+			notify(Notification{At: time.Now(), Channel: n.Channel, Payload: n.Payload, PID: n.PID})
+		case ctx.Err() != nil:
+			return nil
+		case waitCtx.Err() != nil:
+			// woken up for a request
+		default:
+			return fmt.Errorf("waiting for notifications: %w", err)
+		}
+	}
+}
+
+// Listen starts listening on channel.
+func (l *Listener) Listen(ctx context.Context, channel string) error {
+	return l.exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize())
+}
+
+// Unlisten stops listening on channel.
+func (l *Listener) Unlisten(ctx context.Context, channel string) error {
+	return l.exec(ctx, "UNLISTEN "+pgx.Identifier{channel}.Sanitize())
+}
+
+// exec has Run execute sql between two waits, and waits for it to be done.
+func (l *Listener) exec(ctx context.Context, sql string) error {
+	req := listenRequest{sql: sql, result: make(chan error, 1)}
+	select {
+	case l.requests <- req:
+	case <-l.done:
+		return ErrListenerStopped
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	l.mu.Lock()
+	if l.wake != nil {
+		l.wake()
+	}
+	l.mu.Unlock()
+
+	select {
+	case err := <-req.result:
+		return err
+	case <-l.done:
+		return ErrListenerStopped
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Notify sends payload on channel from a pooled session.
+func (m *Manager) Notify(ctx context.Context, connName, channel, payload string) error {
+	pool, err := m.Pool(connName)
+	if err != nil {
+		return err
+	}
+
+	if _, err := pool.Exec(ctx, "SELECT pg_notify($1, $2)", channel, payload); err != nil {
+		return fmt.Errorf("sending notification: %w", err)
+	}
+	return nil
+}
@@ -4,16 +4,38 @@ import (
 	"context"
 	"fmt"
 	"time"
+
+	"github.com/jackc/pgx/v5"
 )
 
-func (m *Manager) ExecQuery(ctx context.Context, connName, query string, nullDisplay string) (*QueryResult, error) {
+func (m *Manager) ExecQuery(ctx context.Context, connName, query string, f Formatter, args ...any) (*QueryResult, error) {
 	pool, err := m.Pool(connName)
 	if err != nil {
 		return nil, err
 	}
 
+	var q interface {
+		Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	} = pool
+
+	// Read-only connections run everything in a read-only transaction so
+	// the server rejects writes from the editor too. One statement only: with
+	// the simple protocol a COMMIT among several would end the transaction
+	// and run the rest outside it
+	if conn, ok := m.ConnectionConfig(connName); ok && conn.ReadOnly {
+		if n := len(splitStatements(query)); n > 1 {
+			return nil, fmt.Errorf("read-only connection: run one statement at a time, got %d", n)
+		}
+		tx, err := pool.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+		if err != nil {
+			return nil, fmt.Errorf("beginning read-only transaction: %w", err)
+		}
+		defer tx.Rollback(ctx) // nothing to commit
+		q = tx
+	}
+
 	start := time.Now()
-	rows, err := pool.Query(ctx, query)
+	rows, err := q.Query(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("executing query: %w", err)
 	}
@@ -21,10 +43,12 @@ func (m *Manager) ExecQuery(ctx context.Context, connName, query string, nullDis
 
 	fields := rows.FieldDescriptions()
 	result := &QueryResult{
-		Columns: make([]string, len(fields)),
+		Columns:     make([]string, len(fields)),
+		ColumnTypes: make([]uint32, len(fields)),
 	}
-	for i, f := range fields {
-		result.Columns[i] = f.Name
+	for i, fd := range fields {
+		result.Columns[i] = fd.Name
+		result.ColumnTypes[i] = fd.DataTypeOID
 	}
 
 	for rows.Next() {
@@ -35,13 +59,10 @@ func (m *Manager) ExecQuery(ctx context.Context, connName, query string, nullDis
 
 		row := make([]string, len(values))
 		for i, v := range values {
-			if v == nil {
-				row[i] = nullDisplay
-			} else {
-				row[i] = fmt.Sprintf("%v", v)
-			}
+			row[i] = f.Format(v, result.ColumnTypes[i])
 		}
 		result.Rows = append(result.Rows, row)
+		result.Values = append(result.Values, values)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("iterating rows: %w", err)
@@ -58,10 +79,10 @@ func (m *Manager) ExecQuery(ctx context.Context, connName, query string, nullDis
 	return result, nil
 }
 
-func (m *Manager) QueryTableData(ctx context.Context, connName, schema, table string, limit, offset int, nullDisplay string) (*QueryResult, error) {
+func (m *Manager) QueryTableData(ctx context.Context, connName, schema, table string, limit, offset int, f Formatter) (*QueryResult, error) {
 	query := fmt.Sprintf(
 		`SELECT * FROM %q.%q LIMIT %d OFFSET %d`,
 		schema, table, limit, offset,
 	)
-	return m.ExecQuery(ctx, connName, query, nullDisplay)
+	return m.ExecQuery(ctx, connName, query, f)
 }
@@ -0,0 +1,259 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ReplicationStatus is the replication state of a server as seen from the
+// current database, which scopes the publications and subscriptions.
+type ReplicationStatus struct {
+	InRecovery    bool
+	WALPosition   string // current LSN on a primary, last replayed on a standby
+	Standbys      []Standby
+	Slots         []ReplicationSlot
+	Receiver      *WALReceiver // set on a standby that is streaming
+	Publications  []Publication
+	Subscriptions []Subscription
+}
+
+// Standby is a WAL sender serving a standby or a logical subscriber, from
+// pg_stat_replication.
+type Standby struct {
+	PID         int32
+	User        string
+	Application string
+	Client      string
+	State       string // startup, catchup, streaming, ...
+	SyncState   string // async, sync, potential, quorum
+	SentLSN     string
+	ReplayLSN   string
+	BytesBehind int64 // WAL not yet replayed, -1 when unknown
+	WriteLag    time.Duration
+	FlushLag    time.Duration
+	ReplayLag   time.Duration
+}
+
+// ReplicationSlot is a slot from pg_replication_slots.
+type ReplicationSlot struct {
+	Name        string
+	Type        string // physical or logical
+	Plugin      string
+	Database    string
+	Active      bool
+	RetainedWAL int64  // WAL kept for the slot, -1 when unknown
+	WALStatus   string // reserved, extended, unreserved or lost; empty before PostgreSQL 13
+}
+
+// WALReceiver is the WAL receiver of a standby, from pg_stat_wal_receiver.
+type WALReceiver struct {
+	Status      string
+	Sender      string // host:port, empty without the privileges to see it
+	Slot        string
+	ReceivedLSN string
+	LastMessage time.Time
+	ReplayDelay time.Duration // since the last replayed commit, -1 when none yet
+}
+
+// Publication is a logical replication publication of the current database.
+type Publication struct {
+	Name       string
+	Owner      string
+	AllTables  bool
+	Tables     int
+	Operations []string // insert, update, delete, truncate
+}
+
+// Subscription is a logical replication subscription of the current database.
+type Subscription struct {
+	Name         string
+	Enabled      bool
+	Publications string
+	WorkerPID    int32 // 0 when no apply worker runs
+	ReceivedLSN  string
+	LastMessage  time.Time
+	Delay        time.Duration // since the last position reported to the publisher, -1 when none yet
+}
+
+// ReplicationStatus returns the replication state of connName's server.
+func (m *Manager) ReplicationStatus(ctx context.Context, connName string) (ReplicationStatus, error) {
+	pool, err := m.Pool(connName)
+	if err != nil {
+		return ReplicationStatus{}, err
+	}
+
+	var st ReplicationStatus
+	err = pool.QueryRow(ctx, `
+		SELECT pg_is_in_recovery(),
+			coalesce(CASE WHEN pg_is_in_recovery() THEN pg_last_wal_replay_lsn() ELSE pg_current_wal_lsn() END::text, '')
+	`).Scan(&st.InRecovery, &st.WALPosition)
+	if err != nil {
+		return ReplicationStatus{}, fmt.Errorf("reading WAL position: %w", err)
+	}
+
+	/**
+	* Positions are measured against the newest WAL the server has: the
+	* current insert position on a primary, the last received on a standby,
+	* where a cascading standby or a slot can also hang off it. Columns
+	* that only exist on newer servers are read through to_jsonb, which
+	* gives NULL instead of failing on older ones.
+	 */
+	const head = `CASE WHEN pg_is_in_recovery() THEN pg_last_wal_receive_lsn() ELSE pg_current_wal_lsn() END`
+
+	rows, err := pool.Query(ctx, `
+		SELECT pid, coalesce(usename, ''), application_name,
+			CASE WHEN client_addr IS NULL THEN 'local' ELSE host(client_addr) END,
+			coalesce(state, ''), coalesce(sync_state, ''),
+			coalesce(sent_lsn::text, ''), coalesce(replay_lsn::text, ''),
+			coalesce(pg_wal_lsn_diff(`+head+`, replay_lsn), -1)::int8,
+			coalesce(extract(epoch FROM write_lag), 0)::float8,
+			coalesce(extract(epoch FROM flush_lag), 0)::float8,
+			coalesce(extract(epoch FROM replay_lag), 0)::float8
+		FROM pg_stat_replication
+		ORDER BY application_name, pid
+	`)
+	if err != nil {
+		return ReplicationStatus{}, fmt.Errorf("listing standbys: %w", err)
+	}
+	for rows.Next() {
+		var s Standby
+		var write, flush, replay float64
+		if err := rows.Scan(&s.PID, &s.User, &s.Application, &s.Client, &s.State, &s.SyncState,
+			&s.SentLSN, &s.ReplayLSN, &s.BytesBehind, &write, &flush, &replay); err != nil {
+			rows.Close()
+			return ReplicationStatus{}, fmt.Errorf("scanning standby: %w", err)
+		}
+		s.WriteLag = seconds(write)
+		s.FlushLag = seconds(flush)
+		s.ReplayLag = seconds(replay)
+		st.Standbys = append(st.Standbys, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return ReplicationStatus{}, fmt.Errorf("listing standbys: %w", err)
+	}
+
+	rows, err = pool.Query(ctx, `
+		SELECT slot_name, slot_type, coalesce(plugin, ''), coalesce(database, ''), active,
+			coalesce(pg_wal_lsn_diff(`+head+`, restart_lsn), -1)::int8,
+			coalesce(to_jsonb(s)->>'wal_status', '')
+		FROM pg_replication_slots s
+		ORDER BY slot_name
+	`)
+	if err != nil {
+		return ReplicationStatus{}, fmt.Errorf("listing replication slots: %w", err)
+	}
+	for rows.Next() {
+		var s ReplicationSlot
+		if err := rows.Scan(&s.Name, &s.Type, &s.Plugin, &s.Database, &s.Active, &s.RetainedWAL, &s.WALStatus); err != nil {
+			rows.Close()
+			return ReplicationStatus{}, fmt.Errorf("scanning replication slot: %w", err)
+		}
+		st.Slots = append(st.Slots, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return ReplicationStatus{}, fmt.Errorf("listing replication slots: %w", err)
+	}
+
+	if st.InRecovery {
+		var r WALReceiver
+		var last *time.Time
+		var delay float64
+		err := pool.QueryRow(ctx, `
+			SELECT status,
+				coalesce((to_jsonb(r)->>'sender_host') || ':' || (to_jsonb(r)->>'sender_port'), ''),
+				coalesce(slot_name, ''),
+				coalesce(to_jsonb(r)->>'flushed_lsn', to_jsonb(r)->>'received_lsn', ''),
+				last_msg_receipt_time,
+				coalesce(extract(epoch FROM now() - pg_last_xact_replay_timestamp()), -1)::float8
+			FROM pg_stat_wal_receiver r
+		`).Scan(&r.Status, &r.Sender, &r.Slot, &r.ReceivedLSN, &last, &delay)
+		switch {
+		case err == nil:
+			if last != nil {
+				r.LastMessage = *last
+			}
+			r.ReplayDelay = seconds(delay)
+			st.Receiver = &r
+		case !errors.Is(err, pgx.ErrNoRows):
+			return ReplicationStatus{}, fmt.Errorf("reading WAL receiver: %w", err)
+		}
+	}
+
+	rows, err = pool.Query(ctx, `
+		SELECT p.pubname, pg_get_userbyid(p.pubowner), p.puballtables,
+			(SELECT count(*) FROM pg_publication_tables t WHERE t.pubname = p.pubname)::int4,
+			array_remove(ARRAY[
+				CASE WHEN p.pubinsert THEN 'insert' END,
+				CASE WHEN p.pubupdate THEN 'update' END,
+				CASE WHEN p.pubdelete THEN 'delete' END,
+				CASE WHEN (to_jsonb(p)->>'pubtruncate')::bool THEN 'truncate' END
+			], NULL)
+		FROM pg_publication p
+		ORDER BY p.pubname
+	`)
+	if err != nil {
+		return ReplicationStatus{}, fmt.Errorf("listing publications: %w", err)
+	}
+	for rows.Next() {
+		var p Publication
+		if err := rows.Scan(&p.Name, &p.Owner, &p.AllTables, &p.Tables, &p.Operations); err != nil {
+			rows.Close()
+			return ReplicationStatus{}, fmt.Errorf("scanning publication: %w", err)
+		}
+		st.Publications = append(st.Publications, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return ReplicationStatus{}, fmt.Errorf("listing publications: %w", err)
+	}
+
+	// pg_subscription is shared by all databases; the apply worker shows up
+	// in pg_stat_subscription as the row without a relation
+	rows, err = pool.Query(ctx, `
+		SELECT s.subname, s.subenabled, array_to_string(s.subpublication, ', '),
+			coalesce(w.pid, 0), coalesce(w.received_lsn::text, ''),
+			w.last_msg_receipt_time,
+			coalesce(extract(epoch FROM now() - w.latest_end_time), -1)::float8
+		FROM pg_subscription s
+		LEFT JOIN pg_stat_subscription w ON w.subid = s.oid AND w.relid IS NULL
+		WHERE s.subdbid = (SELECT oid FROM pg_database WHERE datname = current_database())
+		ORDER BY s.subname
+	`)
+	if err != nil {
+		return ReplicationStatus{}, fmt.Errorf("listing subscriptions: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var s Subscription
+		var last *time.Time
+		var delay float64
+		if err := rows.Scan(&s.Name, &s.Enabled, &s.Publications, &s.WorkerPID, &s.ReceivedLSN, &last, &delay); err != nil {
+			return ReplicationStatus{}, fmt.Errorf("scanning subscription: %w", err)
+		}
+		if last != nil {
+			s.LastMessage = *last
+		}
+		s.Delay = seconds(delay)
+		st.Subscriptions = append(st.Subscriptions, s)
+	}
+	if err := rows.Err(); err != nil {
+		return ReplicationStatus{}, fmt.Errorf("listing subscriptions: %w", err)
+	}
+
+	return st, nil
+}
+
+// seconds turns a number of seconds from extract(epoch ...) into a duration,
+// keeping -1 as the marker for "unknown".
+func seconds(s float64) time.Duration {
+	if s < 0 {
+		return -1
+	}
+	return time.Duration(s * float64(time.Second))
+}
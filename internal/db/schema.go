@@ -15,15 +15,25 @@ func (m *Manager) ListTables(ctx context.Context, connName string) ([]TableInfo,
 	* So, this function basically does the following:
 	* 1. after geting the connection pool, it executes a query to get the table information
 	* 2. it filters the results by excluding system tables and only returning "real" tables
-	* 3. it orders the results by the schema and table name
-	* 4. it returns a slice of TableInfo structs containing the table information
+	* 3. it adds the materialized views, which information_schema leaves out, if they can be read
+	* 4. it orders the results by the schema and table name
+	* 5. it returns a slice of TableInfo structs containing the table information
 	 */
 	rows, err := pool.Query(ctx, `
-		SELECT table_schema, table_name
+		SELECT table_schema, table_name,
+			pg_table_is_visible(format('%I.%I', table_schema, table_name)::regclass),
+			false
 		FROM information_schema.tables
 		WHERE table_schema NOT IN ('pg_catalog', 'information_schema')
 			AND table_type = 'BASE TABLE'
-		ORDER BY table_schema, table_name
+		UNION ALL
+		SELECT schemaname, matviewname,
+			pg_table_is_visible(format('%I.%I', schemaname, matviewname)::regclass),
+			true
+		FROM pg_matviews
+		WHERE schemaname NOT IN ('pg_catalog', 'information_schema')
+			AND has_table_privilege(format('%I.%I', schemaname, matviewname), 'SELECT')
+		ORDER BY 1, 2
 	`)
 	if err != nil {
 		return nil, fmt.Errorf("listing tables: %w", err)
@@ -33,7 +43,7 @@ func (m *Manager) ListTables(ctx context.Context, connName string) ([]TableInfo,
 	var tables []TableInfo
 	for rows.Next() {
 		var t TableInfo
-		if err := rows.Scan(&t.Schema, &t.Name); err != nil {
+		if err := rows.Scan(&t.Schema, &t.Name, &t.Visible, &t.MatView); err != nil {
 			return nil, fmt.Errorf("scanning table: %w", err)
 		}
 		tables = append(tables, t)
@@ -60,8 +70,11 @@ func (m *Manager) ListColumns(ctx context.Context, connName string, schema, tabl
 	rows, err := pool.Query(ctx, `
 		SELECT
 			c.column_name,
-			c.data_type,
+			CASE WHEN c.data_type IN ('ARRAY', 'USER-DEFINED') THEN c.udt_name ELSE c.data_type END,
 			c.is_nullable = 'YES',
+			COALESCE(c.column_default, ''),
+			COALESCE(c.identity_generation, ''),
+			c.is_generated = 'ALWAYS',
 			COALESCE(
 				(
 					SELECT true FROM information_schema.key_column_usage kcu
@@ -89,7 +102,7 @@ func (m *Manager) ListColumns(ctx context.Context, connName string, schema, tabl
 	var cols []ColumnInfo
 	for rows.Next() {
 		var c ColumnInfo
-		if err := rows.Scan(&c.Name, &c.DataType, &c.IsNullable, &c.IsPrimary); err != nil {
+		if err := rows.Scan(&c.Name, &c.DataType, &c.IsNullable, &c.Default, &c.Identity, &c.IsGenerated, &c.IsPrimary); err != nil {
 			return nil, fmt.Errorf("scanning column: %w", err)
 		}
 		cols = append(cols, c)
@@ -98,3 +111,34 @@ func (m *Manager) ListColumns(ctx context.Context, connName string, schema, tabl
 	return cols, rows.Err()
 
 }
+
+// ListDatabases returns the databases on connName's server that accept
+// connections, templates excluded.
+func (m *Manager) ListDatabases(ctx context.Context, connName string) ([]string, error) {
+	pool, err := m.Pool(connName)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := pool.Query(ctx, `
+		SELECT datname
+		FROM pg_database
+		WHERE datallowconn AND NOT datistemplate
+		ORDER BY datname
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("listing databases: %w", err)
+	}
+	defer rows.Close()
+
+	var databases []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("scanning database: %w", err)
+		}
+		databases = append(databases, name)
+	}
+
+	return databases, rows.Err()
+}
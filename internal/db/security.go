@@ -0,0 +1,305 @@
+package db
+
+import (
+	"context"
+	"fmt"
+)
+
+// TablePrivileges are the privileges a table can be granted, in the order
+// GRANT lists them.
+var TablePrivileges = []string{"SELECT", "INSERT", "UPDATE", "DELETE", "TRUNCATE", "REFERENCES", "TRIGGER"}
+
+// RoleInfo is a role with its attributes and the roles it is a member of.
+type RoleInfo struct {
+	Name        string
+	Superuser   bool
+	Login       bool
+	CreateDB    bool
+	CreateRole  bool
+	Replication bool
+	BypassRLS   bool
+	Inherit     bool
+	ConnLimit   int    // -1 for no limit
+	ValidUntil  string // password expiry, empty when it never expires
+	MemberOf    []string
+}
+
+// Attributes lists the attributes set on the role the way CREATE ROLE
+// spells them, e.g. "LOGIN, CREATEDB, CONNECTION LIMIT 5".
+func (r RoleInfo) Attributes() []string {
+	var attrs []string
+	flag := func(set bool, name string) {
+		if set {
+			attrs = append(attrs, name)
+		}
+	}
+	flag(r.Superuser, "SUPERUSER")
+	flag(r.Login, "LOGIN")
+	flag(r.CreateDB, "CREATEDB")
+	flag(r.CreateRole, "CREATEROLE")
+	flag(r.Replication, "REPLICATION")
+	flag(r.BypassRLS, "BYPASSRLS")
+	flag(!r.Inherit, "NOINHERIT")
+	if r.ConnLimit >= 0 {
+		attrs = append(attrs, fmt.Sprintf("CONNECTION LIMIT %d", r.ConnLimit))
+	}
+	if r.ValidUntil != "" {
+		attrs = append(attrs, "VALID UNTIL "+r.ValidUntil)
+	}
+	return attrs
+}
+
+// TableAccess is what a role can do with a table, and why.
+type TableAccess struct {
+	Schema     string
+	Table      string
+	Role       string
+	Login      bool   // the role can log in, rather than being a group
+	Privileges []bool // effective, parallel to TablePrivileges
+	Via        string // where they come from: superuser, owner, granted, PUBLIC, member of ...
+	RLS        string // how row-level security applies to the role, empty when it is off
+}
+
+// Grant is the privileges granted directly on a table to a role, or to
+// PUBLIC, as GRANT and REVOKE change them.
+type Grant struct {
+	Role       string
+	Privileges []bool // parallel to TablePrivileges
+}
+
+// Policy is a row-level security policy, from pg_policies.
+type Policy struct {
+	Name       string
+	Command    string // ALL, SELECT, INSERT, UPDATE or DELETE
+	Permissive bool   // or restrictive
+	Roles      []string
+	Using      string
+	WithCheck  string
+}
+
+// RowSecurity is the row-level security setup of a table.
+type RowSecurity struct {
+	Enabled  bool
+	Forced   bool // applies to the owner too
+	Policies []Policy
+}
+
+// ListRoleInfo returns the roles of the server, leaving out the predefined
+// pg_ ones.
+func (m *Manager) ListRoleInfo(ctx context.Context, connName string) ([]RoleInfo, error) {
+	pool, err := m.Pool(connName)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := pool.Query(ctx, `
+		SELECT r.rolname, r.rolsuper, r.rolcanlogin, r.rolcreatedb, r.rolcreaterole,
+			r.rolreplication, r.rolbypassrls, r.rolinherit, r.rolconnlimit,
+			coalesce(CASE WHEN r.rolvaliduntil <> 'infinity' THEN to_char(r.rolvaliduntil, 'YYYY-MM-DD HH24:MI') END, ''),
+			ARRAY(SELECT g.rolname
+				FROM pg_auth_members am
+				JOIN pg_roles g ON g.oid = am.roleid
+				WHERE am.member = r.oid
+				ORDER BY g.rolname)
+		FROM pg_roles r
+		WHERE r.rolname !~ '^pg_'
+		ORDER BY r.rolname
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("listing roles: %w", err)
+	}
+	defer rows.Close()
+
+	var list []RoleInfo
+	for rows.Next() {
+		var r RoleInfo
+		if err := rows.Scan(&r.Name, &r.Superuser, &r.Login, &r.CreateDB, &r.CreateRole,
+			&r.Replication, &r.BypassRLS, &r.Inherit, &r.ConnLimit, &r.ValidUntil, &r.MemberOf); err != nil {
+			return nil, fmt.Errorf("scanning role: %w", err)
+		}
+		list = append(list, r)
+	}
+
+	return list, rows.Err()
+}
+
+// RoleAccess returns the tables of the current database role can do
+// anything with.
+func (m *Manager) RoleAccess(ctx context.Context, connName, role string) ([]TableAccess, error) {
+	return m.tableAccess(ctx, connName, `r.rolname = $2`, `nspname, relname`, role)
+}
+
+// TableAccess returns the roles that can do anything with a table.
+func (m *Manager) TableAccess(ctx context.Context, connName, schema, table string) ([]TableAccess, error) {
+	return m.tableAccess(ctx, connName, `n.nspname = $2 AND c.relname = $3`, `rolname`, schema, table)
+}
+
+// tableAccess lists the effective privileges of roles on tables, the pairs
+// picked by where, keeping those with at least one.
+func (m *Manager) tableAccess(ctx context.Context, connName, where, orderBy string, args ...any) ([]TableAccess, error) {
+	pool, err := m.Pool(connName)
+	if err != nil {
+		return nil, err
+	}
+
+	/**
+	* has_table_privilege gives what a role can do, through ownership,
+	* superuser, grants and memberships alike. Where that comes from is
+	* read back from the ACL: grants to the role itself, to PUBLIC, and to
+	* roles it inherits from. The owner's own entry is left out as it is
+	* reported as ownership. pg_has_role is kept away from PUBLIC, which
+	* has no role to look up.
+	 */
+	rows, err := pool.Query(ctx, `
+		SELECT nspname, relname, rolname, rolcanlogin, privs, via, rls
+		FROM (
+			SELECT n.nspname, c.relname, r.rolname, r.rolcanlogin,
+				ARRAY(SELECT has_table_privilege(r.oid, c.oid, p)
+					FROM unnest($1::text[]) WITH ORDINALITY AS t(p, i)
+					ORDER BY i) AS privs,
+				CASE WHEN r.rolsuper THEN 'superuser' ELSE concat_ws(', ',
+					CASE WHEN r.oid = c.relowner THEN 'owner'
+						WHEN pg_has_role(r.oid, c.relowner, 'USAGE') THEN 'member of owner ' || pg_get_userbyid(c.relowner)
+					END,
+					CASE WHEN g.direct THEN 'granted' END,
+					CASE WHEN g.public THEN 'PUBLIC' END,
+					'member of ' || g.via)
+				END AS via,
+				CASE
+					WHEN NOT c.relrowsecurity THEN ''
+					WHEN r.rolsuper OR r.rolbypassrls THEN 'bypassed'
+					WHEN r.oid = c.relowner AND NOT c.relforcerowsecurity THEN 'bypassed as owner'
+					ELSE coalesce((
+						SELECT string_agg(p.polname, ', ' ORDER BY p.polname)
+						FROM pg_policy p
+						WHERE p.polrelid = c.oid
+							AND EXISTS (
+								SELECT 1 FROM unnest(p.polroles) AS pr(oid)
+								WHERE CASE WHEN pr.oid = 0 THEN true ELSE pg_has_role(r.oid, pr.oid, 'USAGE') END)
+					), 'no policy, sees no rows')
+				END AS rls
+			FROM pg_class c
+			JOIN pg_namespace n ON n.oid = c.relnamespace
+			CROSS JOIN pg_roles r
+			CROSS JOIN LATERAL (
+				SELECT coalesce(bool_or(a.grantee = r.oid), false) AS direct,
+					coalesce(bool_or(a.grantee = 0), false) AS public,
+					string_agg(DISTINCT pg_get_userbyid(a.grantee), ', ')
+						FILTER (WHERE CASE WHEN a.grantee IN (0, r.oid) THEN false ELSE pg_has_role(r.oid, a.grantee, 'USAGE') END) AS via
+				FROM aclexplode(c.relacl) a
+				WHERE a.grantee <> c.relowner
+			) g
+			WHERE c.relkind IN ('r', 'p', 'v', 'm', 'f')
+				AND n.nspname NOT IN ('pg_catalog', 'information_schema')
+				AND n.nspname !~ '^pg_toast'
+				AND r.rolname !~ '^pg_'
+				AND `+where+`
+		) s
+		WHERE true = ANY (privs)
+		ORDER BY `+orderBy+`
+	`, append([]any{TablePrivileges}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("listing table privileges: %w", err)
+	}
+	defer rows.Close()
+
+	var list []TableAccess
+	for rows.Next() {
+		var t TableAccess
+		if err := rows.Scan(&t.Schema, &t.Table, &t.Role, &t.Login, &t.Privileges, &t.Via, &t.RLS); err != nil {
+			return nil, fmt.Errorf("scanning table privileges: %w", err)
+		}
+		list = append(list, t)
+	}
+
+	return list, rows.Err()
+}
+
+// TableGrants returns the privileges granted directly on a table to PUBLIC
+// and to each role, PUBLIC first, including roles granted nothing.
+func (m *Manager) TableGrants(ctx context.Context, connName, schema, table string) ([]Grant, error) {
+	pool, err := m.Pool(connName)
+	if err != nil {
+		return nil, err
+	}
+
+	// A NULL relacl stands for the default: everything to the owner
+	rows, err := pool.Query(ctx, `
+		WITH acl AS (
+			SELECT a.grantee, a.privilege_type
+			FROM pg_class c
+			JOIN pg_namespace n ON n.oid = c.relnamespace
+			CROSS JOIN LATERAL aclexplode(coalesce(c.relacl, acldefault('r', c.relowner))) a
+			WHERE n.nspname = $2 AND c.relname = $3
+		)
+		SELECT r.rolname,
+			ARRAY(SELECT EXISTS (SELECT 1 FROM acl WHERE acl.grantee = r.oid AND acl.privilege_type = t.p)
+				FROM unnest($1::text[]) WITH ORDINALITY AS t(p, i)
+				ORDER BY t.i)
+		FROM (
+			SELECT 0::oid AS oid, 'PUBLIC'::text AS rolname, 0 AS ord
+			UNION ALL
+			SELECT oid, rolname::text, 1 FROM pg_roles WHERE rolname !~ '^pg_'
+		) r
+		ORDER BY r.ord, r.rolname
+	`, TablePrivileges, schema, table)
+	if err != nil {
+		return nil, fmt.Errorf("listing grants: %w", err)
+	}
+	defer rows.Close()
+
+	var list []Grant
+	for rows.Next() {
+		var g Grant
+		if err := rows.Scan(&g.Role, &g.Privileges); err != nil {
+			return nil, fmt.Errorf("scanning grant: %w", err)
+		}
+		list = append(list, g)
+	}
+
+	return list, rows.Err()
+}
+
+// RowSecurity returns whether row-level security is on for a table, and
+// its policies.
+func (m *Manager) RowSecurity(ctx context.Context, connName, schema, table string) (RowSecurity, error) {
+	pool, err := m.Pool(connName)
+	if err != nil {
+		return RowSecurity{}, err
+	}
+
+	var rs RowSecurity
+	err = pool.QueryRow(ctx, `
+		SELECT c.relrowsecurity, c.relforcerowsecurity
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1 AND c.relname = $2
+	`, schema, table).Scan(&rs.Enabled, &rs.Forced)
+	if err != nil {
+		return RowSecurity{}, fmt.Errorf("reading row security: %w", err)
+	}
+
+	// permissive only exists from PostgreSQL 10, every policy was before
+	rows, err := pool.Query(ctx, `
+		SELECT policyname, cmd,
+			coalesce(to_jsonb(p)->>'permissive', 'PERMISSIVE') = 'PERMISSIVE',
+			roles::text[], coalesce(qual, ''), coalesce(with_check, '')
+		FROM pg_policies p
+		WHERE schemaname = $1 AND tablename = $2
+		ORDER BY policyname
+	`, schema, table)
+	if err != nil {
+		return RowSecurity{}, fmt.Errorf("listing policies: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p Policy
+		if err := rows.Scan(&p.Name, &p.Command, &p.Permissive, &p.Roles, &p.Using, &p.WithCheck); err != nil {
+			return RowSecurity{}, fmt.Errorf("scanning policy: %w", err)
+		}
+		rs.Policies = append(rs.Policies, p)
+	}
+
+	return rs, rows.Err()
+}
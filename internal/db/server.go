@@ -0,0 +1,154 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ServerInfo is an overview of the server a connection is on.
+type ServerInfo struct {
+	Version        string // as version() reports it
+	StartedAt      time.Time
+	DataSize       int64 // of the databases the user may connect to
+	InRecovery     bool  // a standby, or a primary still replaying WAL
+	Connections    int   // client sessions
+	MaxConnections int
+	Reserved       int // of MaxConnections, kept for superusers
+}
+
+// Extension is an extension installed in the current database.
+type Extension struct {
+	Name        string
+	Schema      string
+	Version     string
+	Available   string // the version CREATE EXTENSION would install now
+	Description string
+}
+
+// Upgradable reports whether a newer version than the installed one is
+// available to ALTER EXTENSION ... UPDATE to.
+func (e Extension) Upgradable() bool {
+	return e.Available != "" && e.Available != e.Version
+}
+
+// Setting is one run-time parameter from pg_settings.
+type Setting struct {
+	Name           string
+	Value          string
+	Unit           string
+	Category       string
+	Description    string
+	Context        string // when it can be changed: postmaster, sighup, user, ...
+	Type           string
+	Source         string // where the value comes from: default, configuration file, ...
+	SourceFile     string // only shown to superusers and pg_read_all_settings
+	SourceLine     int
+	BootValue      string
+	ResetValue     string
+	Min            string
+	Max            string
+	EnumValues     []string
+	PendingRestart bool // changed in the file but needs a restart to apply
+}
+
+// NonDefault reports whether the value was set somewhere rather than being
+// built in.
+func (s Setting) NonDefault() bool {
+	return s.Source != "default" && s.Source != "override"
+}
+
+// ServerInfo returns an overview of connName's server.
+func (m *Manager) ServerInfo(ctx context.Context, connName string) (ServerInfo, error) {
+	pool, err := m.Pool(connName)
+	if err != nil {
+		return ServerInfo{}, err
+	}
+
+	var info ServerInfo
+	err = pool.QueryRow(ctx, `
+		SELECT version(),
+			pg_postmaster_start_time(),
+			(SELECT coalesce(sum(pg_database_size(oid)), 0)::int8
+				FROM pg_database
+				WHERE has_database_privilege(oid, 'CONNECT')),
+			pg_is_in_recovery(),
+			(SELECT count(*) FROM pg_stat_activity WHERE backend_type = 'client backend')::int4,
+			current_setting('max_connections')::int4,
+			current_setting('superuser_reserved_connections')::int4
+	`).Scan(&info.Version, &info.StartedAt, &info.DataSize, &info.InRecovery,
+		&info.Connections, &info.MaxConnections, &info.Reserved)
+	if err != nil {
+		return ServerInfo{}, fmt.Errorf("reading server info: %w", err)
+	}
+
+	return info, nil
+}
+
+// ListExtensions returns the extensions installed in the current database.
+func (m *Manager) ListExtensions(ctx context.Context, connName string) ([]Extension, error) {
+	pool, err := m.Pool(connName)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := pool.Query(ctx, `
+		SELECT e.extname, n.nspname, e.extversion,
+			coalesce(a.default_version, ''), coalesce(a.comment, '')
+		FROM pg_extension e
+		JOIN pg_namespace n ON n.oid = e.extnamespace
+		LEFT JOIN pg_available_extensions a ON a.name = e.extname
+		ORDER BY e.extname
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("listing extensions: %w", err)
+	}
+	defer rows.Close()
+
+	var list []Extension
+	for rows.Next() {
+		var e Extension
+		if err := rows.Scan(&e.Name, &e.Schema, &e.Version, &e.Available, &e.Description); err != nil {
+			return nil, fmt.Errorf("scanning extension: %w", err)
+		}
+		list = append(list, e)
+	}
+
+	return list, rows.Err()
+}
+
+// ListSettings returns the server's run-time parameters, by name.
+func (m *Manager) ListSettings(ctx context.Context, connName string) ([]Setting, error) {
+	pool, err := m.Pool(connName)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := pool.Query(ctx, `
+		SELECT name, coalesce(setting, ''), coalesce(unit, ''), category,
+			concat_ws(' ', short_desc, extra_desc), context, vartype, source,
+			coalesce(sourcefile, ''), coalesce(sourceline, 0),
+			coalesce(boot_val, ''), coalesce(reset_val, ''),
+			coalesce(min_val, ''), coalesce(max_val, ''),
+			coalesce(enumvals, '{}'), pending_restart
+		FROM pg_settings
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("listing settings: %w", err)
+	}
+	defer rows.Close()
+
+	var list []Setting
+	for rows.Next() {
+		var s Setting
+		if err := rows.Scan(&s.Name, &s.Value, &s.Unit, &s.Category, &s.Description,
+			&s.Context, &s.Type, &s.Source, &s.SourceFile, &s.SourceLine,
+			&s.BootValue, &s.ResetValue, &s.Min, &s.Max, &s.EnumValues, &s.PendingRestart); err != nil {
+			return nil, fmt.Errorf("scanning setting: %w", err)
+		}
+		list = append(list, s)
+	}
+
+	return list, rows.Err()
+}
@@ -0,0 +1,159 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/zaffron/ezpg/internal/config"
+)
+
+// sessionSetting is one run-time parameter applied after connecting.
+type sessionSetting struct {
+	name  string
+	value string
+}
+
+// sessionSettings lists what to set on a new session, skipping empty values.
+func sessionSettings(s config.SessionSettings, serverReadOnly bool) []sessionSetting {
+	all := []sessionSetting{
+		{"role", s.Role},
+		{"search_path", s.SearchPath},
+		{"statement_timeout", s.StatementTimeout},
+		{"lock_timeout", s.LockTimeout},
+		{"idle_in_transaction_session_timeout", s.IdleInTransactionSessionTimeout},
+		{"TimeZone", s.TimeZone},
+		{"application_name", s.ApplicationName},
+	}
+	if serverReadOnly {
+		all = append(all, sessionSetting{"default_transaction_read_only", "on"})
+	}
+
+	var set []sessionSetting
+	for _, st := range all {
+		if st.value != "" {
+			set = append(set, st)
+		}
+	}
+	return set
+}
+
+// afterConnect applies the settings with set_config, which takes the value
+// as a parameter instead of needing it quoted into SET.
+func afterConnect(settings func() []sessionSetting) func(context.Context, *pgx.Conn) error {
+	return func(ctx context.Context, c *pgx.Conn) error {
+		for _, st := range settings() {
+			if _, err := c.Exec(ctx, "SELECT set_config($1, $2, false)", st.name, st.value); err != nil {
+				return fmt.Errorf("setting %s: %w", st.name, err)
+			}
+		}
+		return nil
+	}
+}
+
+// SetSessionRole switches name's sessions to role ("none" resets it). The
+// pools are reset, so the next queries run on fresh sessions with it applied.
+func (m *Manager) SetSessionRole(name, role string) {
+	m.setSession(name, func(s *config.SessionSettings) { s.Role = role })
+}
+
+// SetSearchPath sets search_path for name's sessions, like SetSessionRole.
+func (m *Manager) SetSearchPath(name, path string) {
+	m.setSession(name, func(s *config.SessionSettings) { s.SearchPath = path })
+}
+
+func (m *Manager) setSession(name string, update func(*config.SessionSettings)) {
+	m.sessMu.Lock()
+	s := m.sessions[name]
+	update(&s)
+	m.sessions[name] = s
+	m.sessMu.Unlock()
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for key, pool := range m.pools {
+		if key.conn == name {
+			pool.Reset()
+		}
+	}
+}
+
+// sessionOverride returns what SetSessionRole and SetSearchPath changed.
+func (m *Manager) sessionOverride(name string) config.SessionSettings {
+	m.sessMu.Lock()
+	defer m.sessMu.Unlock()
+
+	return m.sessions[name]
+}
+
+// ListRoles returns the roles the session user may SET ROLE to, and the
+// current role.
+func (m *Manager) ListRoles(ctx context.Context, connName string) ([]string, string, error) {
+	pool, err := m.Pool(connName)
+	if err != nil {
+		return nil, "", err
+	}
+
+	rows, err := pool.Query(ctx, `
+		SELECT rolname, rolname = current_user
+		FROM pg_roles
+		WHERE pg_has_role(session_user, oid, 'MEMBER')
+		ORDER BY rolname
+	`)
+	if err != nil {
+		return nil, "", fmt.Errorf("listing roles: %w", err)
+	}
+	defer rows.Close()
+
+	var roles []string
+	var current string
+	for rows.Next() {
+		var name string
+		var isCurrent bool
+		if err := rows.Scan(&name, &isCurrent); err != nil {
+			return nil, "", fmt.Errorf("scanning role: %w", err)
+		}
+		roles = append(roles, name)
+		if isCurrent {
+			current = name
+		}
+	}
+
+	return roles, current, rows.Err()
+}
+
+// ListSchemas returns the user schemas and the current search_path.
+func (m *Manager) ListSchemas(ctx context.Context, connName string) ([]string, string, error) {
+	pool, err := m.Pool(connName)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var searchPath string
+	if err := pool.QueryRow(ctx, "SELECT current_setting('search_path')").Scan(&searchPath); err != nil {
+		return nil, "", fmt.Errorf("reading search_path: %w", err)
+	}
+
+	rows, err := pool.Query(ctx, `
+		SELECT nspname
+		FROM pg_namespace
+		WHERE nspname NOT LIKE 'pg\_%' AND nspname <> 'information_schema'
+		ORDER BY nspname
+	`)
+	if err != nil {
+		return nil, "", fmt.Errorf("listing schemas: %w", err)
+	}
+	defer rows.Close()
+
+	var schemas []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, "", fmt.Errorf("scanning schema: %w", err)
+		}
+		schemas = append(schemas, name)
+	}
+
+	return schemas, searchPath, rows.Err()
+}
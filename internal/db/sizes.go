@@ -0,0 +1,143 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// TableSize is the disk usage and upkeep of one table or materialized view.
+type TableSize struct {
+	Schema      string
+	Name        string
+	Total       int64 // table, TOAST and indexes together
+	Table       int64
+	Toast       int64
+	Indexes     int64
+	Bloat       int64 // estimated bytes beyond what the live rows need, -1 without statistics
+	LiveTuples  int64
+	DeadTuples  int64
+	LastVacuum  time.Time // manual or auto, zero when never
+	LastAnalyze time.Time
+}
+
+// IndexSize is the disk usage and use of one index.
+type IndexSize struct {
+	Schema string
+	Name   string
+	Table  string
+	Size   int64
+	Scans  int64 // since the statistics were last reset
+	Unique bool  // backs a unique or primary key constraint, so needed even if never scanned
+}
+
+// ListTableSizes returns the tables of the current database, largest first.
+func (m *Manager) ListTableSizes(ctx context.Context, connName string) ([]TableSize, error) {
+	pool, err := m.Pool(connName)
+	if err != nil {
+		return nil, err
+	}
+
+	/**
+	* Bloat is estimated like most monitoring queries do it: the pages the
+	* live rows would fill, from the planner's row count and the average
+	* column widths in pg_stats plus the tuple header, against the pages the
+	* table really has. Tables without statistics get -1.
+	 */
+	rows, err := pool.Query(ctx, `
+		WITH widths AS (
+			SELECT schemaname, tablename, sum(avg_width) AS width
+			FROM pg_stats
+			GROUP BY schemaname, tablename
+		), fill AS (
+			SELECT c.oid, coalesce((
+				SELECT substring(opt FROM 'fillfactor=(\d+)')::numeric
+				FROM unnest(c.reloptions) opt
+				WHERE opt LIKE 'fillfactor=%'
+			), 100) / 100 AS ff
+			FROM pg_class c
+		)
+		SELECT n.nspname, c.relname,
+			pg_total_relation_size(c.oid),
+			pg_relation_size(c.oid),
+			CASE WHEN c.reltoastrelid = 0 THEN 0 ELSE pg_total_relation_size(c.reltoastrelid) END,
+			pg_indexes_size(c.oid),
+			CASE WHEN w.width IS NULL OR c.reltuples <= 0 THEN -1
+				ELSE greatest(c.relpages - ceil(c.reltuples * (w.width + 28) / ((b.size - 24) * f.ff)), 0) * b.size
+			END::int8,
+			coalesce(s.n_live_tup, 0), coalesce(s.n_dead_tup, 0),
+			greatest(s.last_vacuum, s.last_autovacuum),
+			greatest(s.last_analyze, s.last_autoanalyze)
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		JOIN fill f ON f.oid = c.oid
+		CROSS JOIN (SELECT current_setting('block_size')::numeric AS size) b
+		LEFT JOIN pg_stat_user_tables s ON s.relid = c.oid
+		LEFT JOIN widths w ON w.schemaname = n.nspname AND w.tablename = c.relname
+		WHERE c.relkind IN ('r', 'm')
+			AND n.nspname NOT IN ('pg_catalog', 'information_schema')
+			AND n.nspname NOT LIKE 'pg\_toast%'
+		ORDER BY 3 DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("listing table sizes: %w", err)
+	}
+	defer rows.Close()
+
+	var list []TableSize
+	for rows.Next() {
+		var t TableSize
+		var vacuum, analyze *time.Time
+		if err := rows.Scan(&t.Schema, &t.Name, &t.Total, &t.Table, &t.Toast, &t.Indexes,
+			&t.Bloat, &t.LiveTuples, &t.DeadTuples, &vacuum, &analyze); err != nil {
+			return nil, fmt.Errorf("scanning table size: %w", err)
+		}
+		if vacuum != nil {
+			t.LastVacuum = *vacuum
+		}
+		if analyze != nil {
+			t.LastAnalyze = *analyze
+		}
+		list = append(list, t)
+	}
+
+	return list, rows.Err()
+}
+
+// ListIndexSizes returns the indexes of the current database, largest first.
+func (m *Manager) ListIndexSizes(ctx context.Context, connName string) ([]IndexSize, error) {
+	pool, err := m.Pool(connName)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := pool.Query(ctx, `
+		SELECT n.nspname, i.relname, t.relname,
+			pg_relation_size(i.oid),
+			coalesce(s.idx_scan, 0),
+			x.indisunique OR x.indisprimary
+		FROM pg_index x
+		JOIN pg_class i ON i.oid = x.indexrelid
+		JOIN pg_class t ON t.oid = x.indrelid
+		JOIN pg_namespace n ON n.oid = i.relnamespace
+		LEFT JOIN pg_stat_user_indexes s ON s.indexrelid = i.oid
+		WHERE n.nspname NOT IN ('pg_catalog', 'information_schema')
+			AND n.nspname NOT LIKE 'pg\_toast%'
+		ORDER BY 4 DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("listing index sizes: %w", err)
+	}
+	defer rows.Close()
+
+	var list []IndexSize
+	for rows.Next() {
+		var ix IndexSize
+		if err := rows.Scan(&ix.Schema, &ix.Name, &ix.Table, &ix.Size, &ix.Scans, &ix.Unique); err != nil {
+			return nil, fmt.Errorf("scanning index size: %w", err)
+		}
+		list = append(list, ix)
+	}
+
+	return list, rows.Err()
+}
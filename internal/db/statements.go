@@ -0,0 +1,142 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+	// ErrStatStatementsMissing means CREATE EXTENSION pg_stat_statements has
+	// not been run in the database.
+	ErrStatStatementsMissing = errors.New("pg_stat_statements is not installed in this database")
+	// ErrStatStatementsNotLoaded means the extension exists but the server
+	// was not started with it in shared_preload_libraries.
+	ErrStatStatementsNotLoaded = errors.New("pg_stat_statements is not in shared_preload_libraries")
+)
+
+// StatementStats is one normalized statement from pg_stat_statements.
+type StatementStats struct {
+	QueryID   int64
+	Query     string // constants replaced by $n
+	User      string
+	Database  string
+	Calls     int64
+	TotalTime time.Duration
+	MeanTime  time.Duration
+	Rows      int64
+	HitRatio  float64 // shared buffer hits over blocks read, -1 when nothing was read
+}
+
+// statStatements returns the quoted schema pg_stat_statements is installed
+// in and the names of its total and mean time columns, which gained "exec"
+// in version 1.8.
+func statStatements(ctx context.Context, q interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}) (schema, total, mean string, err error) {
+	var version string
+	err = q.QueryRow(ctx, `
+		SELECT n.nspname, e.extversion
+		FROM pg_extension e
+		JOIN pg_namespace n ON n.oid = e.extnamespace
+		WHERE e.extname = 'pg_stat_statements'
+	`).Scan(&schema, &version)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", "", "", ErrStatStatementsMissing
+	}
+	if err != nil {
+		return "", "", "", fmt.Errorf("looking up pg_stat_statements: %w", err)
+	}
+
+	schema = pgx.Identifier{schema}.Sanitize()
+	major, minor, _ := strings.Cut(version, ".")
+	maj, _ := strconv.Atoi(major)
+	mnr, _ := strconv.Atoi(minor)
+	if maj > 1 || (maj == 1 && mnr >= 8) {
+		return schema, "total_exec_time", "mean_exec_time", nil
+	}
+	return schema, "total_time", "mean_time", nil
+}
+
+// statStatementsErr turns the error the view raises when the library isn't
+// preloaded into ErrStatStatementsNotLoaded.
+func statStatementsErr(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "55000" && strings.Contains(pgErr.Message, "shared_preload_libraries") {
+		return ErrStatStatementsNotLoaded
+	}
+	return err
+}
+
+// ListStatements returns the limit statements with the most total execution
+// time, across all databases of the server.
+func (m *Manager) ListStatements(ctx context.Context, connName string, limit int) ([]StatementStats, error) {
+	pool, err := m.Pool(connName)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, total, mean, err := statStatements(ctx, pool)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := pool.Query(ctx, fmt.Sprintf(`
+		SELECT coalesce(s.queryid, 0), coalesce(s.query, ''),
+			coalesce(r.rolname, ''), coalesce(d.datname, ''),
+			s.calls, s.%[2]s, s.%[3]s, s.rows,
+			CASE WHEN s.shared_blks_hit + s.shared_blks_read = 0 THEN -1
+				ELSE s.shared_blks_hit::float8 / (s.shared_blks_hit + s.shared_blks_read) END
+		FROM %[1]s.pg_stat_statements s
+		LEFT JOIN pg_roles r ON r.oid = s.userid
+		LEFT JOIN pg_database d ON d.oid = s.dbid
+		ORDER BY s.%[2]s DESC
+		LIMIT $1
+	`, schema, total, mean), limit)
+	if err != nil {
+		return nil, fmt.Errorf("listing statements: %w", statStatementsErr(err))
+	}
+	defer rows.Close()
+
+	var list []StatementStats
+	for rows.Next() {
+		var s StatementStats
+		var totalMS, meanMS float64
+		if err := rows.Scan(&s.QueryID, &s.Query, &s.User, &s.Database,
+			&s.Calls, &totalMS, &meanMS, &s.Rows, &s.HitRatio); err != nil {
+			return nil, fmt.Errorf("scanning statement: %w", err)
+		}
+		s.TotalTime = time.Duration(totalMS * float64(time.Millisecond))
+		s.MeanTime = time.Duration(meanMS * float64(time.Millisecond))
+		list = append(list, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("listing statements: %w", statStatementsErr(err))
+	}
+
+	return list, nil
+}
+
+// ResetStatements discards the statistics gathered by pg_stat_statements.
+func (m *Manager) ResetStatements(ctx context.Context, connName string) error {
+	pool, err := m.Pool(connName)
+	if err != nil {
+		return err
+	}
+
+	schema, _, _, err := statStatements(ctx, pool)
+	if err != nil {
+		return err
+	}
+
+	if _, err := pool.Exec(ctx, "SELECT "+schema+".pg_stat_statements_reset()"); err != nil {
+		return fmt.Errorf("resetting statements: %w", statStatementsErr(err))
+	}
+	return nil
+}
@@ -0,0 +1,316 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zaffron/ezpg/internal/config"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const sshDialTimeout = 10 * time.Second
+
+// TunnelError marks a failure of the SSH tunnel rather than of Postgres.
+type TunnelError struct {
+	Err error
+}
+
+func (e *TunnelError) Error() string { return "ssh tunnel: " + e.Err.Error() }
+func (e *TunnelError) Unwrap() error { return e.Err }
+
+// Tunnel forwards a local port to a remote address through a chain of SSH hosts.
+type Tunnel struct {
+	remote   string
+	clients  []*ssh.Client // jump hosts first, the bastion last
+	listener net.Listener
+	closers  []io.Closer // e.g. the agent socket
+
+	mu      sync.Mutex
+	lastErr error
+	wg      sync.WaitGroup
+}
+
+// sshHop is one host in the chain with its own client config.
+type sshHop struct {
+	addr   string
+	config *ssh.ClientConfig
+}
+
+// OpenTunnel connects to the bastion (through any jump hosts) and starts
+// forwarding a local port to remote, which is resolved on the bastion side.
+func OpenTunnel(ctx context.Context, cfg config.SSHConfig, remote string) (*Tunnel, error) {
+	auth, closers, err := sshAuth(cfg)
+	if err != nil {
+		return nil, &TunnelError{Err: err}
+	}
+
+	hostKeys, err := sshHostKeys(cfg.KnownHosts)
+	if err != nil {
+		closeAll(closers)
+		return nil, &TunnelError{Err: err}
+	}
+
+	var hops []sshHop
+	for _, h := range append(append([]string{}, cfg.JumpHosts...), cfg.Host) {
+		user, addr := splitSSHHost(h, cfg.User)
+		hops = append(hops, sshHop{
+			addr: addr,
+			config: &ssh.ClientConfig{
+				User:            user,
+				Auth:            auth,
+				HostKeyCallback: hostKeys,
+				Timeout:         sshDialTimeout,
+			},
+		})
+	}
+
+	t, err := openTunnel(ctx, hops, remote)
+	if err != nil {
+		closeAll(closers)
+		return nil, err
+	}
+	t.closers = closers
+	return t, nil
+}
+
+// openTunnel dials hops in order, each through the previous one, and listens
+// on a random local port.
+func openTunnel(ctx context.Context, hops []sshHop, remote string) (*Tunnel, error) {
+	if len(hops) == 0 {
+		return nil, &TunnelError{Err: errors.New("no ssh host")}
+	}
+
+	t := &Tunnel{remote: remote}
+	for i, hop := range hops {
+		var conn net.Conn
+		var err error
+		if i == 0 {
+			d := net.Dialer{Timeout: hop.config.Timeout}
+			conn, err = d.DialContext(ctx, "tcp", hop.addr)
+		} else {
+			conn, err = t.clients[i-1].DialContext(ctx, "tcp", hop.addr)
+		}
+		if err != nil {
+			t.Close()
+			return nil, &TunnelError{Err: fmt.Errorf("dialing %s: %w", hop.addr, err)}
+		}
+
+		c, chans, reqs, err := sshHandshake(ctx, conn, hop)
+		if err != nil {
+			conn.Close()
+			t.Close()
+			return nil, &TunnelError{Err: fmt.Errorf("handshake with %s: %w", hop.addr, err)}
+		}
+		t.clients = append(t.clients, ssh.NewClient(c, chans, reqs))
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Close()
+		return nil, &TunnelError{Err: fmt.Errorf("listening locally: %w", err)}
+	}
+	t.listener = l
+
+	t.wg.Add(1)
+	go t.serve()
+
+	return t, nil
+}
+
+// sshHandshake runs the SSH handshake on conn, giving up at ctx's deadline or
+// after the hop's timeout, so a host that accepts TCP and then says nothing
+// can't hang the caller. A channel through a jump host has no deadlines of
+// its own, so conn is also closed when the time is up.
+func sshHandshake(ctx context.Context, conn net.Conn, hop sshHop) (ssh.Conn, <-chan ssh.NewChannel, <-chan *ssh.Request, error) {
+	timeout := hop.config.Timeout
+	if timeout <= 0 {
+		timeout = sshDialTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+
+	c, chans, reqs, err := ssh.NewClientConn(conn, hop.addr, hop.config)
+	if !stop() {
+		// conn was closed, however far the handshake got
+		if err == nil {
+			c.Close()
+		}
+		return nil, nil, nil, ctx.Err()
+	}
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	conn.SetDeadline(time.Time{})
+	return c, chans, reqs, nil
+}
+
+// Addr is the local end of the forward.
+func (t *Tunnel) Addr() string {
+	return t.listener.Addr().String()
+}
+
+// Err returns the last forwarding error, e.g. the bastion refusing to reach the database.
+func (t *Tunnel) Err() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.lastErr
+}
+
+func (t *Tunnel) serve() {
+	defer t.wg.Done()
+
+	for {
+		local, err := t.listener.Accept()
+		if err != nil {
+			return // listener closed
+		}
+
+		t.wg.Add(1)
+		go func() {
+			defer t.wg.Done()
+			t.forward(local)
+		}()
+	}
+}
+
+func (t *Tunnel) forward(local net.Conn) {
+	defer local.Close()
+
+	remote, err := t.clients[len(t.clients)-1].Dial("tcp", t.remote)
+	if err != nil {
+		t.mu.Lock()
+		t.lastErr = &TunnelError{Err: fmt.Errorf("forwarding to %s: %w", t.remote, err)}
+		t.mu.Unlock()
+		return
+	}
+	defer remote.Close()
+
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(remote, local)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(local, remote)
+		done <- struct{}{}
+	}()
+	<-done
+}
+
+// Close stops the listener and closes the SSH connections, innermost first.
+func (t *Tunnel) Close() error {
+	if t.listener != nil {
+		t.listener.Close()
+	}
+	for i := len(t.clients) - 1; i >= 0; i-- {
+		t.clients[i].Close()
+	}
+	closeAll(t.closers)
+	t.wg.Wait()
+	return nil
+}
+
+// sshAuth picks the key file and/or agent. With neither configured it uses
+// the agent when one is running, else the default key files.
+func sshAuth(cfg config.SSHConfig) ([]ssh.AuthMethod, []io.Closer, error) {
+	var methods []ssh.AuthMethod
+	var closers []io.Closer
+
+	useAgent := cfg.Agent || (cfg.KeyPath == "" && os.Getenv("SSH_AUTH_SOCK") != "")
+	if useAgent {
+		sock := os.Getenv("SSH_AUTH_SOCK")
+		if sock == "" {
+			return nil, nil, errors.New("agent requested but SSH_AUTH_SOCK is not set")
+		}
+		conn, err := net.Dial("unix", sock)
+		if err != nil {
+			return nil, nil, fmt.Errorf("connecting to ssh agent: %w", err)
+		}
+		closers = append(closers, conn)
+		methods = append(methods, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+	}
+
+	keyPaths := []string{cfg.KeyPath}
+	if cfg.KeyPath == "" {
+		keyPaths = nil
+		if !useAgent {
+			keyPaths = []string{"~/.ssh/id_ed25519", "~/.ssh/id_ecdsa", "~/.ssh/id_rsa"}
+		}
+	}
+
+	var signers []ssh.Signer
+	for _, p := range keyPaths {
+		data, err := os.ReadFile(config.ExpandHome(p))
+		if err != nil {
+			if cfg.KeyPath == "" && os.IsNotExist(err) {
+				continue // default key that isn't there
+			}
+			closeAll(closers)
+			return nil, nil, fmt.Errorf("reading key: %w", err)
+		}
+
+		signer, err := ssh.ParsePrivateKey(data)
+		if err != nil {
+			closeAll(closers)
+			var missing *ssh.PassphraseMissingError
+			if errors.As(err, &missing) {
+				return nil, nil, fmt.Errorf("key %s is passphrase-protected; add it to the ssh agent instead", p)
+			}
+			return nil, nil, fmt.Errorf("parsing key %s: %w", p, err)
+		}
+		signers = append(signers, signer)
+	}
+	if len(signers) > 0 {
+		methods = append(methods, ssh.PublicKeys(signers...))
+	}
+
+	if len(methods) == 0 {
+		return nil, nil, errors.New("no ssh key or agent available")
+	}
+	return methods, closers, nil
+}
+
+func sshHostKeys(path string) (ssh.HostKeyCallback, error) {
+	if path == "" {
+		path = "~/.ssh/known_hosts"
+	}
+
+	cb, err := knownhosts.New(config.ExpandHome(path))
+	if err != nil {
+		return nil, fmt.Errorf("loading known_hosts: %w", err)
+	}
+	return cb, nil
+}
+
+// splitSSHHost parses [user@]host[:port], defaulting to defaultUser and port 22.
+func splitSSHHost(s, defaultUser string) (user, addr string) {
+	user = defaultUser
+	if u, h, ok := strings.Cut(s, "@"); ok {
+		user, s = u, h
+	}
+	if _, _, err := net.SplitHostPort(s); err != nil {
+		s = net.JoinHostPort(s, "22")
+	}
+	return user, s
+}
+
+func closeAll(closers []io.Closer) {
+	for _, c := range closers {
+		c.Close()
+	}
+}
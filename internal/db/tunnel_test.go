@@ -0,0 +1,272 @@
+package db
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// testSSHServer is an SSH server that lets one key in and forwards
+// direct-tcpip channels, the way a bastion does.
+type testSSHServer struct {
+	addr    string
+	hostKey ssh.Signer
+}
+
+func newSigner(t *testing.T) ssh.Signer {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+func startSSHServer(t *testing.T, clientKey ssh.PublicKey) *testSSHServer {
+	t.Helper()
+	hostKey := newSigner(t)
+	cfg := &ssh.ServerConfig{
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) == string(clientKey.Marshal()) {
+				return nil, nil
+			}
+			return nil, errors.New("unknown key")
+		},
+	}
+	cfg.AddHostKey(hostKey)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go serveSSH(conn, cfg)
+		}
+	}()
+
+	return &testSSHServer{addr: l.Addr().String(), hostKey: hostKey}
+}
+
+func serveSSH(conn net.Conn, cfg *ssh.ServerConfig) {
+	sc, chans, reqs, err := ssh.NewServerConn(conn, cfg)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	defer sc.Close()
+	go ssh.DiscardRequests(reqs)
+
+	for nc := range chans {
+		if nc.ChannelType() != "direct-tcpip" {
+			nc.Reject(ssh.UnknownChannelType, "only direct-tcpip")
+			continue
+		}
+		var target struct {
+			Host     string
+			Port     uint32
+			OrigHost string
+			OrigPort uint32
+		}
+		if err := ssh.Unmarshal(nc.ExtraData(), &target); err != nil {
+			nc.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+		remote, err := net.Dial("tcp", net.JoinHostPort(target.Host, strconv.Itoa(int(target.Port))))
+		if err != nil {
+			nc.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+		ch, chReqs, err := nc.Accept()
+		if err != nil {
+			remote.Close()
+			continue
+		}
+		go ssh.DiscardRequests(chReqs)
+		go func() {
+			defer ch.Close()
+			defer remote.Close()
+			done := make(chan struct{}, 2)
+			go func() { io.Copy(remote, ch); done <- struct{}{} }()
+			go func() { io.Copy(ch, remote); done <- struct{}{} }()
+			<-done
+		}()
+	}
+}
+
+// startEchoServer stands in for the database behind the bastion.
+func startEchoServer(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return l.Addr().String()
+}
+
+// knownHostsFor writes a known_hosts file listing key for each of addrs and
+// returns the callback ezpg would build from it.
+func knownHostsFor(t *testing.T, key ssh.PublicKey, addrs ...string) ssh.HostKeyCallback {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "known_hosts")
+	var data []byte
+	for _, addr := range addrs {
+		data = append(data, knownhosts.Line([]string{knownhosts.Normalize(addr)}, key)+"\n"...)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cb, err := sshHostKeys(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cb
+}
+
+func testHop(addr string, key ssh.Signer, hostKeys ssh.HostKeyCallback) sshHop {
+	return sshHop{
+		addr: addr,
+		config: &ssh.ClientConfig{
+			User:            "ezpg",
+			Auth:            []ssh.AuthMethod{ssh.PublicKeys(key)},
+			HostKeyCallback: hostKeys,
+			Timeout:         5 * time.Second,
+		},
+	}
+}
+
+func TestTunnelRoundTrip(t *testing.T) {
+	clientKey := newSigner(t)
+	srv := startSSHServer(t, clientKey.PublicKey())
+	echo := startEchoServer(t)
+	hostKeys := knownHostsFor(t, srv.hostKey.PublicKey(), srv.addr)
+
+	// The same server twice: once as a jump host, once as the bastion
+	hops := []sshHop{testHop(srv.addr, clientKey, hostKeys), testHop(srv.addr, clientKey, hostKeys)}
+	tunnel, err := openTunnel(context.Background(), hops, echo)
+	if err != nil {
+		t.Fatalf("openTunnel: %v", err)
+	}
+	defer tunnel.Close()
+
+	conn, err := net.Dial("tcp", tunnel.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	want := "SELECT 1"
+	if _, err := conn.Write([]byte(want)); err != nil {
+		t.Fatal(err)
+	}
+	got := make([]byte, len(want))
+	if _, err := io.ReadFull(conn, got); err != nil {
+		t.Fatalf("reading through the tunnel: %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("got %q through the tunnel, want %q", got, want)
+	}
+}
+
+func TestTunnelRejectsUnknownKey(t *testing.T) {
+	srv := startSSHServer(t, newSigner(t).PublicKey())
+	hostKeys := knownHostsFor(t, srv.hostKey.PublicKey(), srv.addr)
+
+	_, err := openTunnel(context.Background(), []sshHop{testHop(srv.addr, newSigner(t), hostKeys)}, "127.0.0.1:5432")
+	var terr *TunnelError
+	if !errors.As(err, &terr) {
+		t.Fatalf("got %v, want a TunnelError for the refused key", err)
+	}
+}
+
+func TestTunnelRejectsChangedHostKey(t *testing.T) {
+	clientKey := newSigner(t)
+	srv := startSSHServer(t, clientKey.PublicKey())
+	// known_hosts has another key for the server's address
+	hostKeys := knownHostsFor(t, newSigner(t).PublicKey(), srv.addr)
+
+	_, err := openTunnel(context.Background(), []sshHop{testHop(srv.addr, clientKey, hostKeys)}, "127.0.0.1:5432")
+	var keyErr *knownhosts.KeyError
+	if !errors.As(err, &keyErr) || len(keyErr.Want) == 0 {
+		t.Fatalf("got %v, want a known_hosts mismatch", err)
+	}
+}
+
+func TestTunnelRejectsUnknownHost(t *testing.T) {
+	clientKey := newSigner(t)
+	srv := startSSHServer(t, clientKey.PublicKey())
+	hostKeys := knownHostsFor(t, srv.hostKey.PublicKey(), "127.0.0.1:1")
+
+	_, err := openTunnel(context.Background(), []sshHop{testHop(srv.addr, clientKey, hostKeys)}, "127.0.0.1:5432")
+	var keyErr *knownhosts.KeyError
+	if !errors.As(err, &keyErr) || len(keyErr.Want) != 0 {
+		t.Fatalf("got %v, want the host to be unknown", err)
+	}
+}
+
+func TestTunnelHandshakeTimeout(t *testing.T) {
+	// Accepts TCP, then never says anything
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		<-done
+		conn.Close()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	hop := testHop(l.Addr().String(), newSigner(t), ssh.InsecureIgnoreHostKey())
+	_, err = openTunnel(ctx, []sshHop{hop}, "127.0.0.1:5432")
+	if err == nil {
+		t.Fatal("handshake with a silent server succeeded")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("handshake gave up after %v, want about 200ms", elapsed)
+	}
+}
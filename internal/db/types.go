@@ -1,6 +1,7 @@
 package db
 
 import (
+	"strings"
 	"sync"
 	"time"
 
@@ -14,9 +15,25 @@ import (
 * =============================================================================
  */
 type Manager struct {
-	mu    sync.RWMutex
-	pools map[string]*pgxpool.Pool
-	conns map[string]*config.Connection
+	mu        sync.RWMutex
+	pools     map[poolKey]*pgxpool.Pool
+	conns     map[string]*config.Connection
+	passwords map[string]string // entered at the password prompt, kept in memory only
+	tunnels   map[poolKey]*Tunnel
+	current   map[string]string // connection -> database in use, "" for the configured one
+	defaultDB map[string]string // connection -> name of the configured database, as the server reported it
+
+	// Role and search_path chosen at run time. Separate lock since it is
+	// read from AfterConnect while mu is held by Connect.
+	sessMu   sync.Mutex
+	sessions map[string]config.SessionSettings
+}
+
+// poolKey identifies a pool: one per database opened on a connection's
+// server. An empty database is the one the connection is configured with.
+type poolKey struct {
+	conn     string
+	database string
 }
 
 /**
@@ -25,27 +42,62 @@ type Manager struct {
 * =============================================================================
  */
 type QueryResult struct {
-	Columns  []string
-	Rows     [][]string
-	RowCount int
-	ExecTime time.Duration
-	Message  string // for non-SELECT (e.g. INSERT 0 1)
+	Columns     []string
+	ColumnTypes []uint32 // type OIDs, parallel to Columns
+	Rows        [][]string
+	Values      [][]any // raw decoded values behind Rows, used to address rows in mutations
+	RowCount    int
+	ExecTime    time.Duration
+	Message     string // for non-SELECT (e.g. INSERT 0 1)
 }
 
 type TableInfo struct {
-	Schema string
-	Name   string
+	Schema  string
+	Name    string
+	Visible bool // reachable without the schema through search_path
+	MatView bool // a materialized view rather than a table
 }
 
 type ColumnInfo struct {
-	Name       string
-	DataType   string
-	IsNullable bool
-	IsPrimary  bool
+	Name        string
+	DataType    string
+	IsNullable  bool
+	IsPrimary   bool
+	Default     string // default expression, empty if none
+	Identity    string // "ALWAYS", "BY DEFAULT" or empty
+	IsGenerated bool
+}
+
+type CellValueKind int
+
+const (
+	CellText CellValueKind = iota
+	CellNull
+	CellDefault
+)
+
+// CellValue is a value written by a mutation. NULL and DEFAULT are explicit
+// kinds so that typing the NullDisplay text stores that text, not NULL.
+type CellValue struct {
+	Kind CellValueKind
+	Text string
+}
+
+func (c ColumnInfo) IsSerial() bool {
+	return strings.HasPrefix(c.Default, "nextval(")
+}
+
+func (c ColumnInfo) HasDefault() bool {
+	return c.Default != "" || c.Identity != "" || c.IsGenerated
+}
+
+// IsRequired reports whether an INSERT must provide a value for the column.
+func (c ColumnInfo) IsRequired() bool {
+	return !c.IsNullable && !c.HasDefault()
 }
 
 func (t TableInfo) FullName() string {
-	if t.Schema == "public" {
+	if t.Visible {
 		return t.Name
 	}
 
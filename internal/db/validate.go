@@ -0,0 +1,114 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var uuidPattern = regexp.MustCompile(`^\{?[0-9a-fA-F]{8}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{12}\}?$`)
+
+// numericPattern is the decimal syntax numeric accepts: no hex, no
+// underscores, digits on at least one side of the point.
+var numericPattern = regexp.MustCompile(`^[+-]?(\d+\.?\d*|\.\d+)([eE][+-]?\d+)?$`)
+
+// TypeName returns the Postgres name of a builtin type OID, or "" if unknown.
+func TypeName(oid uint32) string {
+	if t, ok := typeMap.TypeForOID(oid); ok {
+		return t.Name
+	}
+
+	return ""
+}
+
+// IsMultilineType reports whether values of oid are usually long enough to edit over several lines.
+func IsMultilineType(oid uint32) bool {
+	switch oid {
+	case pgtype.TextOID, pgtype.JSONOID, pgtype.JSONBOID, pgtype.XMLOID:
+		return true
+	}
+
+	return elementOID(oid) != 0
+}
+
+// ValidateValue checks that s is acceptable input for a column of type oid.
+// Types it does not know about are left for the server to reject.
+func ValidateValue(oid uint32, s string) error {
+	s = strings.TrimSpace(s)
+
+	switch oid {
+	case pgtype.Int2OID:
+		return validateInt(s, 16)
+	case pgtype.Int4OID, pgtype.OIDOID:
+		return validateInt(s, 32)
+	case pgtype.Int8OID:
+		return validateInt(s, 64)
+
+	case pgtype.Float4OID, pgtype.Float8OID:
+		switch strings.ToLower(s) {
+		case "nan", "infinity", "-infinity", "+infinity":
+			return nil
+		}
+		if _, err := strconv.ParseFloat(s, 64); err != nil {
+			return fmt.Errorf("%q is not a number", s)
+		}
+
+	case pgtype.NumericOID:
+		switch strings.ToLower(s) {
+		case "nan", "infinity", "-infinity", "+infinity":
+			return nil
+		}
+		if !numericPattern.MatchString(s) {
+			return fmt.Errorf("%q is not a number", s)
+		}
+
+	case pgtype.BoolOID:
+		switch strings.ToLower(s) {
+		case "t", "f", "true", "false", "y", "n", "yes", "no", "on", "off", "1", "0":
+			return nil
+		}
+		return fmt.Errorf("%q is not a boolean (use true/false)", s)
+
+	case pgtype.DateOID:
+		if isInfinity(s) {
+			return nil
+		}
+		if _, err := time.Parse("2006-01-02", s); err != nil {
+			return fmt.Errorf("%q is not a date (use YYYY-MM-DD)", s)
+		}
+
+	case pgtype.UUIDOID:
+		if !uuidPattern.MatchString(s) {
+			return fmt.Errorf("%q is not a uuid", s)
+		}
+
+	case pgtype.JSONOID, pgtype.JSONBOID:
+		if !json.Valid([]byte(s)) {
+			return fmt.Errorf("invalid json")
+		}
+	}
+
+	return nil
+}
+
+func validateInt(s string, bits int) error {
+	if _, err := strconv.ParseInt(s, 10, bits); err != nil {
+		return fmt.Errorf("%q is not a %d-bit integer", s, bits)
+	}
+
+	return nil
+}
+
+func isInfinity(s string) bool {
+	switch strings.ToLower(s) {
+	case "infinity", "-infinity", "+infinity":
+		return true
+	}
+
+	return false
+}
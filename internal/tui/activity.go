@@ -0,0 +1,152 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/zaffron/ezpg/internal/db"
+	"github.com/zaffron/ezpg/internal/tui/components/adminview"
+	"github.com/zaffron/ezpg/internal/tui/components/keyhints"
+)
+
+var activityColumns = []adminview.Column{
+	{Title: "pid", Width: 7, Numeric: true},
+	{Title: "user", Width: 12},
+	{Title: "database", Width: 12},
+	{Title: "state", Width: 14},
+	{Title: "wait", Width: 18},
+	{Title: "duration", Width: 9, Numeric: true},
+	{Title: "client", Width: 15},
+	{Title: "application", Width: 16},
+	{Title: "query"},
+}
+
+var activityHints = []keyhints.Hint{
+	{Key: "enter", Desc: "query"},
+	{Key: "x", Desc: "plan"},
+	{Key: "c", Desc: "cancel"},
+	{Key: "T", Desc: "terminate"},
+}
+
+func (a *App) setActivity(list []db.Activity) {
+	a.activity = list
+
+	rows := make([]adminview.Row, len(list))
+	for i, act := range list {
+		state := act.State
+		if state == "" {
+			state = act.BackendType // background workers have no state
+		}
+		rows[i] = adminview.Row{
+			Key: strconv.Itoa(int(act.PID)),
+			Cells: []string{
+				strconv.Itoa(int(act.PID)),
+				act.User,
+				act.Database,
+				state,
+				act.WaitEvent,
+				adminview.FormatDuration(act.Duration),
+				act.Client,
+				act.Application,
+				act.Query,
+			},
+			Sort:  []float64{0: float64(act.PID), 5: act.Duration.Seconds()},
+			Color: activityColor(act),
+		}
+	}
+	a.admin.SetRows(rows)
+}
+
+// activityColor draws attention to sessions waiting on a lock or holding a
+// transaction open, and fades out idle ones.
+func activityColor(act db.Activity) lipgloss.Color {
+	switch {
+	case strings.HasPrefix(act.WaitEvent, "Lock:"):
+		return ColorDanger
+	case strings.HasPrefix(act.State, "idle in transaction"):
+		return ColorWarning
+	case act.State != "active" || act.Self:
+		return ColorMuted
+	}
+	return ""
+}
+
+func (a App) selectedActivity() (db.Activity, bool) {
+	row, ok := a.admin.Selected()
+	if !ok {
+		return db.Activity{}, false
+	}
+	for _, act := range a.activity {
+		if strconv.Itoa(int(act.PID)) == row.Key {
+			return act, true
+		}
+	}
+	return db.Activity{}, false
+}
+
+// handleActivityKey handles the keys of the activity monitor, reporting
+// false for the ones it leaves to the list.
+func (a App) handleActivityKey(msg tea.KeyMsg) (tea.Model, tea.Cmd, bool) {
+	act, ok := a.selectedActivity()
+
+	switch {
+	case key.Matches(msg, Keys.Enter):
+		if ok {
+			a.admin.ShowDetail(activityTitle(act), activityDetail(act))
+			a.updateHints()
+		}
+		return a, nil, true
+
+	case key.Matches(msg, Keys.Explain):
+		if !ok {
+			return a, nil, true
+		}
+		m, cmd := a.explainAdmin("Plan of "+activityTitle(act), act.Database, act.Query)
+		return m, cmd, true
+
+	case key.Matches(msg, Keys.Cancel), key.Matches(msg, Keys.Terminate):
+		if !ok {
+			return a, nil, true
+		}
+		if act.Self {
+			a.statusbar.SetMessage("That is this session", true)
+			return a, statusTimeoutCmd(3 * time.Second), true
+		}
+		m, cmd := a.signalBackend(act.PID, act.User+" on "+act.Database, key.Matches(msg, Keys.Terminate))
+		return m, cmd, true
+	}
+
+	return a, nil, false
+}
+
+func activityTitle(act db.Activity) string {
+	return fmt.Sprintf("pid %d (%s on %s)", act.PID, act.User, act.Database)
+}
+
+// activityDetail is the full query of act, under what else is known about it.
+func activityDetail(act db.Activity) string {
+	var b strings.Builder
+	field := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "%-12s %s\n", name, value)
+		}
+	}
+	field("state", act.State)
+	field("duration", adminview.FormatDuration(act.Duration))
+	field("wait", act.WaitEvent)
+	field("client", act.Client)
+	field("application", act.Application)
+	field("backend", act.BackendType)
+
+	query := act.Query
+	if query == "" {
+		query = "(no query)"
+	}
+	b.WriteString("\n" + query)
+	return b.String()
+}
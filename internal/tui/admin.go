@@ -0,0 +1,417 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/zaffron/ezpg/internal/db"
+	"github.com/zaffron/ezpg/internal/tui/components/adminview"
+	"github.com/zaffron/ezpg/internal/tui/components/keyhints"
+)
+
+// adminPage is one of the admin screens, switched between with tab.
+type adminPage int
+
+const (
+	adminActivity adminPage = iota
+	adminLocks
+	adminStatements
+	adminSizes
+	adminReplication
+	adminServer
+	adminSettings
+	adminMaintenance
+	adminNotify
+)
+
+var adminPages = []string{
+	adminActivity:    "Activity",
+	adminLocks:       "Locks",
+	adminStatements:  "Statements",
+	adminSizes:       "Sizes",
+	adminReplication: "Replication",
+	adminServer:      "Server",
+	adminSettings:    "Settings",
+	adminMaintenance: "Maintenance",
+	adminNotify:      "Notify",
+}
+
+// adminStatic lists the pages that are too costly to refresh on a timer,
+// so they only reload with r.
+var adminStatic = map[adminPage]bool{
+	adminSizes:    true,
+	adminServer:   true,
+	adminSettings: true,
+	adminNotify:   true,
+}
+
+// adminEmptyText is what a page says when it has nothing to list.
+var adminEmptyText = map[adminPage]string{
+	adminLocks:       "no session is waiting on a lock",
+	adminSizes:       "no tables in this database",
+	adminReplication: "no standbys, slots, publications or subscriptions",
+	adminServer:      "no extensions installed in this database",
+	adminMaintenance: "no maintenance command run yet, press m on a table in the sidebar",
+	adminNotify:      "nothing received yet, press l to LISTEN on a channel",
+}
+
+// Bounds of the refresh interval when changed with + and -.
+const (
+	minAdminRefresh = time.Second
+	maxAdminRefresh = time.Minute
+)
+
+// openAdmin shows page for the active connection and starts refreshing it.
+func (a App) openAdmin(page adminPage) (tea.Model, tea.Cmd) {
+	if a.activeConn == "" || !a.mgr.IsConnected(a.activeConn) {
+		a.statusbar.SetMessage("Connect to a server first", true)
+		return a, statusTimeoutCmd(3 * time.Second)
+	}
+
+	a.screen = ScreenAdmin
+	a.inputFocused = false
+	a.adminPage = page
+	a.adminConn = a.activeConn
+	a.adminSeq++ // stops the ticks of the page shown before
+	a.adminLoading = false
+	a.admin.SetColumns(adminColumns(page))
+	a.admin.SetEmptyText(adminEmptyText[page])
+	a.admin.SetTitle(adminPages[page] + " on " + a.adminConn)
+	a.admin.SetInfo("loading...")
+	a.layoutResize()
+	a.updateHints()
+
+	if adminStatic[page] {
+		return a, a.refreshAdmin()
+	}
+	return a, tea.Batch(a.refreshAdmin(), adminTickCmd(a.adminSeq, a.adminEvery))
+}
+
+func adminColumns(page adminPage) []adminview.Column {
+	switch page {
+	case adminActivity:
+		return activityColumns
+	case adminLocks:
+		return lockColumns
+	case adminStatements:
+		return statementColumns
+	case adminSizes:
+		return sizeColumns
+	case adminReplication:
+		return replicationColumns
+	case adminServer:
+		return extensionColumns
+	case adminSettings:
+		return settingColumns
+	case adminMaintenance:
+		return maintenanceColumns
+	case adminNotify:
+		return notifyColumns
+	}
+	return nil
+}
+
+// refreshAdmin reloads the page shown, unless a reload is still running.
+func (a *App) refreshAdmin() tea.Cmd {
+	if a.screen != ScreenAdmin || a.adminLoading {
+		return nil
+	}
+	a.adminLoading = true
+
+	switch a.adminPage {
+	case adminActivity:
+		return loadActivityCmd(a.mgr, a.adminConn)
+	case adminLocks:
+		return loadBlockingCmd(a.mgr, a.adminConn)
+	case adminStatements:
+		return loadStatementsCmd(a.mgr, a.adminConn, topStatements)
+	case adminSizes:
+		return loadSizesCmd(a.mgr, a.adminConn)
+	case adminReplication:
+		return loadReplicationCmd(a.mgr, a.adminConn)
+	case adminServer:
+		return loadServerInfoCmd(a.mgr, a.adminConn)
+	case adminSettings:
+		return loadSettingsCmd(a.mgr, a.adminConn)
+	case adminMaintenance:
+		return a.refreshMaintenance()
+	case adminNotify:
+		a.adminLoading = false
+		a.showNotify()
+		return nil
+	}
+	return nil
+}
+
+// adminLoaded finishes a reload of the admin page, reporting err if it failed.
+func (a *App) adminLoaded(err error) tea.Cmd {
+	a.adminLoading = false
+	if db.IsConnectionLost(err) {
+		a.admin.SetInfo("connection lost")
+		return a.connectionLost(a.adminConn, "Refresh failed", "", err)
+	}
+	if err != nil {
+		a.admin.SetInfo("refresh failed")
+		a.statusbar.SetMessage(err.Error(), true)
+		return statusTimeoutCmd(5 * time.Second)
+	}
+	updated := "updated " + time.Now().Format("15:04:05")
+	if !adminStatic[a.adminPage] {
+		updated = fmt.Sprintf("every %s, %s", a.adminEvery, updated)
+	}
+	a.admin.SetInfo(updated)
+	return nil
+}
+
+func (a App) handleAdminKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if a.admin.IsFiltering() {
+		return a.handleAdminFilterKey(msg)
+	}
+
+	if a.admin.HasDetail() {
+		if key.Matches(msg, Keys.Escape) || key.Matches(msg, Keys.Quit) || key.Matches(msg, Keys.Enter) {
+			a.admin.CloseDetail()
+			a.updateHints()
+			return a, nil
+		}
+		var cmd tea.Cmd
+		a.admin, cmd = a.admin.Update(msg)
+		return a, cmd
+	}
+
+	switch {
+	case key.Matches(msg, Keys.Escape) && a.admin.IsFiltered():
+		a.admin.ClearFilter()
+		a.updateHints()
+		return a, nil
+
+	case key.Matches(msg, Keys.Quit), key.Matches(msg, Keys.Escape):
+		a.screen = ScreenBrowse
+		a.adminSeq++
+		a.layoutResize()
+		a.updateHints()
+		return a, nil
+
+	case key.Matches(msg, Keys.Tab), key.Matches(msg, Keys.ShiftTab):
+		if len(adminPages) < 2 {
+			return a, nil
+		}
+		step := 1
+		if key.Matches(msg, Keys.ShiftTab) {
+			step = len(adminPages) - 1
+		}
+		return a.openAdmin((a.adminPage + adminPage(step)) % adminPage(len(adminPages)))
+
+	case key.Matches(msg, Keys.Search):
+		a.admin.StartFilter()
+		a.updateHints()
+		return a, nil
+
+	case key.Matches(msg, Keys.Sort):
+		a.admin.CycleSort()
+		return a, nil
+
+	case key.Matches(msg, Keys.SortReverse):
+		a.admin.ReverseSort()
+		return a, nil
+
+	case key.Matches(msg, Keys.Reload):
+		return a, a.refreshAdmin()
+
+	case (key.Matches(msg, Keys.Faster) || key.Matches(msg, Keys.Slower)) && !adminStatic[a.adminPage]:
+		if key.Matches(msg, Keys.Faster) {
+			a.adminEvery = max(a.adminEvery-time.Second, minAdminRefresh)
+		} else {
+			a.adminEvery = min(a.adminEvery+time.Second, maxAdminRefresh)
+		}
+		a.statusbar.SetMessage(fmt.Sprintf("Refreshing every %s", a.adminEvery), false)
+		return a, statusTimeoutCmd(2 * time.Second)
+	}
+
+	switch a.adminPage {
+	case adminActivity:
+		if m, cmd, ok := a.handleActivityKey(msg); ok {
+			return m, cmd
+		}
+	case adminLocks:
+		if m, cmd, ok := a.handleLocksKey(msg); ok {
+			return m, cmd
+		}
+	case adminStatements:
+		if m, cmd, ok := a.handleStatementsKey(msg); ok {
+			return m, cmd
+		}
+	case adminSizes:
+		if m, cmd, ok := a.handleSizesKey(msg); ok {
+			return m, cmd
+		}
+	case adminReplication:
+		if m, cmd, ok := a.handleReplicationKey(msg); ok {
+			return m, cmd
+		}
+	case adminServer:
+		if m, cmd, ok := a.handleServerKey(msg); ok {
+			return m, cmd
+		}
+	case adminSettings:
+		if m, cmd, ok := a.handleSettingsKey(msg); ok {
+			return m, cmd
+		}
+	case adminMaintenance:
+		if m, cmd, ok := a.handleMaintenanceKey(msg); ok {
+			return m, cmd
+		}
+	case adminNotify:
+		if m, cmd, ok := a.handleNotifyKey(msg); ok {
+			return m, cmd
+		}
+	}
+
+	var cmd tea.Cmd
+	a.admin, cmd = a.admin.Update(msg)
+	return a, cmd
+}
+
+func (a App) handleAdminFilterKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, Keys.Enter):
+		a.admin.AcceptFilter()
+		a.updateHints()
+		return a, nil
+	case key.Matches(msg, Keys.Escape):
+		a.admin.ClearFilter()
+		a.updateHints()
+		return a, nil
+	}
+	return a, a.admin.UpdateFilter(msg)
+}
+
+func (a App) adminHints() []keyhints.Hint {
+	if a.admin.IsFiltering() {
+		return []keyhints.Hint{
+			{Key: "enter", Desc: "apply filter"},
+			{Key: "esc", Desc: "clear"},
+		}
+	}
+	if a.admin.HasDetail() {
+		return []keyhints.Hint{
+			{Key: "j/k", Desc: "scroll"},
+			{Key: "esc", Desc: "back"},
+		}
+	}
+
+	hints := []keyhints.Hint{{Key: "j/k", Desc: "navigate"}}
+	switch a.adminPage {
+	case adminActivity:
+		hints = append(hints, activityHints...)
+	case adminLocks:
+		hints = append(hints, lockHints...)
+	case adminStatements:
+		hints = append(hints, statementHints...)
+	case adminSizes:
+		hints = append(hints, sizeHints...)
+	case adminReplication:
+		hints = append(hints, replicationHints...)
+	case adminServer:
+		hints = append(hints, serverHints...)
+	case adminSettings:
+		hints = append(hints, settingHints...)
+	case adminMaintenance:
+		hints = append(hints, maintenanceHints...)
+	case adminNotify:
+		hints = append(hints, notifyHints...)
+	}
+	hints = append(hints,
+		keyhints.Hint{Key: "/", Desc: "filter"},
+		keyhints.Hint{Key: "s/S", Desc: "sort"},
+		keyhints.Hint{Key: "r", Desc: "refresh"},
+	)
+	if !adminStatic[a.adminPage] {
+		hints = append(hints, keyhints.Hint{Key: "+/-", Desc: "interval"})
+	}
+	if len(adminPages) > 1 {
+		hints = append(hints, keyhints.Hint{Key: "tab", Desc: "next screen"})
+	}
+	return append(hints, keyhints.Hint{Key: "q", Desc: "back"})
+}
+
+// signalBackend cancels the query of pid, or ends its session, after
+// confirming when destructive actions need it. who describes the session,
+// e.g. "alice on app".
+func (a App) signalBackend(pid int32, who string, terminate bool) (tea.Model, tea.Cmd) {
+	if c, ok := a.mgr.ConnectionConfig(a.adminConn); ok && c.IsReadOnly() {
+		a.statusbar.SetMessage("Connection is read-only", true)
+		return a, statusTimeoutCmd(3 * time.Second)
+	}
+
+	connName := a.adminConn
+	run := func() tea.Cmd {
+		return signalBackendCmd(a.mgr, connName, pid, terminate)
+	}
+	if !a.cfg.Settings.ConfirmDestructive {
+		return a, run()
+	}
+
+	what := "Cancel the query of"
+	if terminate {
+		what = "Terminate the session of"
+	}
+	a.confirming = true
+	a.confirmText = fmt.Sprintf("%s pid %d (%s)? (y/n)", what, pid, who)
+	a.statusbar.SetMessage(a.confirmText, true)
+	a.onConfirm = run
+	a.updateHints()
+	return a, nil
+}
+
+// explainAdmin shows the plan of query, which ran on database. Plans come
+// from the database the connection is using, so others are refused.
+func (a App) explainAdmin(title, database, query string) (tea.Model, tea.Cmd) {
+	if database != "" && database != a.mgr.Database(a.adminConn) {
+		a.statusbar.SetMessage(fmt.Sprintf("The query ran on database %s, switch to it to see the plan", database), true)
+		return a, statusTimeoutCmd(4 * time.Second)
+	}
+	a.loading = true
+	a.statusbar.SetLoading(true, "Explaining...")
+	return a, explainCmd(a.mgr, a.adminConn, title, query)
+}
+
+// adminTabs names the admin pages, the one shown highlighted.
+func (a App) adminTabs() string {
+	tabs := make([]string, len(adminPages))
+	for i, name := range adminPages {
+		style := lipgloss.NewStyle().Foreground(ColorMuted).Padding(0, 1)
+		if adminPage(i) == a.adminPage {
+			style = style.Foreground(ColorBg).Background(ColorPrimary).Bold(true)
+		}
+		tabs[i] = style.Render(name)
+	}
+	return strings.Join(tabs, " ")
+}
+
+func (a App) adminScreenView() string {
+	borderH := StyleMainActive.GetHorizontalBorderSize()
+	borderV := StyleMainActive.GetVerticalBorderSize()
+	statusHeight := 2
+
+	// Width and Height include the padding but not the border
+	w := max(a.width-borderH, 0)
+	h := max(a.height-statusHeight-borderV, 0)
+
+	body := a.adminTabs() + "\n\n" + a.admin.View()
+
+	main := a.panelStyle(StyleMainActive).Width(w).Height(h).MaxHeight(h + borderV).Render(body)
+	return lipgloss.JoinVertical(lipgloss.Left, main, a.statusbar.View())
+}
+
+// adminSize is the room the admin view gets inside the panel, under the tabs.
+func (a App) adminSize() (int, int) {
+	frameH := StyleMainActive.GetHorizontalFrameSize()
+	frameV := StyleMainActive.GetVerticalFrameSize()
+	statusHeight := 2
+	return max(a.width-frameH, 0), max(a.height-statusHeight-frameV-2, 0)
+}
@@ -1,21 +1,27 @@
 package tui
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/zaffron/ezpg/internal/config"
 	"github.com/zaffron/ezpg/internal/db"
+	"github.com/zaffron/ezpg/internal/tui/components/adminview"
 	"github.com/zaffron/ezpg/internal/tui/components/editor"
 	"github.com/zaffron/ezpg/internal/tui/components/homescreen"
 	"github.com/zaffron/ezpg/internal/tui/components/keyhints"
+	"github.com/zaffron/ezpg/internal/tui/components/picker"
+	"github.com/zaffron/ezpg/internal/tui/components/privmatrix"
 	"github.com/zaffron/ezpg/internal/tui/components/sidebar"
 	"github.com/zaffron/ezpg/internal/tui/components/statusbar"
 	"github.com/zaffron/ezpg/internal/tui/components/tableview"
+	"github.com/zaffron/ezpg/internal/tui/shared"
 )
 
 const sidebarWidth = 30
@@ -41,25 +47,87 @@ type App struct {
 	loading    bool
 
 	// For confirming destructive actions
-	confirming  bool
-	confirmText string
-	onConfirm   func() tea.Cmd
+	confirming     bool
+	confirmText    string
+	confirmPreview string // generated SQL shown in place of the table while confirming
+	onConfirm      func() tea.Cmd
+
+	// Single-line input in the status bar, e.g. a password
+	prompting   bool
+	promptInput textinput.Model
+	onPrompt    func(a App, value string) (tea.Model, tea.Cmd)
+
+	// Rows parsed from the clipboard, staged until the paste is confirmed
+	pasteRecords [][]string
+	pasteMapping pasteMapping
 
 	// Primary key cache
-	pkCache map[string][]string // "schema.table" -> pk column names
+	pkCache  map[tableKey][]string        // pk column names
+	colCache map[tableKey][]db.ColumnInfo // column info
 
 	// Active connection context
 	activeConn string
+	connTLS    map[string]string // connection name -> negotiated TLS, for the status bar
+	health     map[string]db.Health
+	unsure     map[string]string // a write that may have been applied before its connection was lost
+
+	// Remembered between runs, e.g. recently used connections
+	state *config.State
+
+	// Picker shown in place of the table, e.g. for SET ROLE
+	picking bool
+	picker  picker.Picker
+	onPick  func(a App, value string) (tea.Model, tea.Cmd)
+
+	// Admin screens, such as the activity monitor
+	admin        adminview.AdminView
+	adminPage    adminPage
+	adminConn    string
+	adminSeq     int // bumped whenever the page changes, so old refresh ticks stop
+	adminLoading bool
+	adminEvery   time.Duration
+	activity     []db.Activity
+	blocking     []db.BlockingProcess
+	statements   []db.StatementStats
+	sizeTables   map[string]db.TableSize // row key -> table
+	sizeIndexes  map[string]db.IndexSize // row key -> index
+	replDetails  map[string]string       // row key -> drill-down text
+	extensions   []db.Extension
+	settings     []db.Setting
+	onlyChanged  bool // the settings list hides the ones at their default
+
+	// Roles listed under the sidebar's security folders, and the privilege
+	// matrix shown in place of the table while editing grants
+	roleInfo    map[string][]db.RoleInfo // connection -> roles
+	granting    bool
+	grants      privmatrix.Matrix
+	grantSchema string
+	grantTable  string
+
+	// Maintenance commands run from the sidebar, logged on the admin screen
+	maint        maintenanceJob
+	maintLog     []maintenanceLine
+	maintLogBase int // lines dropped from the front of maintLog, so row keys stay put
+
+	// The LISTEN/NOTIFY console and what it received
+	notify notifyConsole
 }
 
+// recentConnections is how many recently used connections the home screen lists.
+const recentConnections = 5
+
 func NewApp(cfg *config.Config) App {
 	mgr := db.NewManager(cfg.Connections)
 
-	sb := sidebar.New(cfg.Connections)
+	sb := sidebar.New(cfg.Connections, cfg.Settings.EnvironmentColors)
 	tv := tableview.New()
 	ed := editor.New()
 	st := statusbar.New()
-	hs := homescreen.New(cfg.Connections)
+	hs := homescreen.New(cfg.Connections, cfg.Settings.EnvironmentColors)
+
+	// A missing or unreadable state file just means no history
+	state, _ := config.LoadState(config.StatePath(cfg.Path))
+	hs.SetRecent(state.Recent(recentConnections))
 
 	return App{
 		cfg:        cfg,
@@ -71,7 +139,15 @@ func NewApp(cfg *config.Config) App {
 		editor:     ed,
 		statusbar:  st,
 		homescreen: hs,
-		pkCache:    make(map[string][]string),
+		pkCache:    make(map[tableKey][]string),
+		colCache:   make(map[tableKey][]db.ColumnInfo),
+		roleInfo:   make(map[string][]db.RoleInfo),
+		connTLS:    make(map[string]string),
+		health:     make(map[string]db.Health),
+		unsure:     make(map[string]string),
+		state:      state,
+		admin:      adminview.New(),
+		adminEvery: time.Duration(cfg.Settings.RefreshInterval) * time.Second,
 	}
 }
 
@@ -81,11 +157,13 @@ func (a App) Init() tea.Cmd {
 	if develop {
 		return tea.Batch(
 			tea.SetWindowTitle("lazygres"),
+			healthTickCmd(),
 		)
 	}
 	return tea.Batch(
 		tea.EnterAltScreen,
 		tea.SetWindowTitle("lazygres"),
+		healthTickCmd(),
 	)
 }
 
@@ -101,6 +179,16 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case ConnectMsg:
 		a.loading = false
 		a.statusbar.SetLoading(false, "")
+		if errors.Is(msg.Err, db.ErrPasswordRequired) {
+			a.promptPassword(msg.Name)
+			return a, nil
+		}
+		var tunnelErr *db.TunnelError
+		if errors.As(msg.Err, &tunnelErr) {
+			a.statusbar.SetMessage("SSH tunnel failed: "+tunnelErr.Err.Error(), true)
+			a.updateHints()
+			return a, statusTimeoutCmd(5 * time.Second)
+		}
 		if msg.Err != nil {
 			a.statusbar.SetMessage("Connect failed: "+msg.Err.Error(), true)
 			a.updateHints()
@@ -108,41 +196,212 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		a.sidebar.SetConnected(msg.Name, true)
 		a.activeConn = msg.Name
-		a.statusbar.SetMessage("Connected to "+msg.Name, false)
-		a.statusbar.SetContext(msg.Name, "")
+		a.connTLS[msg.Name] = msg.TLS
+		a.setHealth(msg.Name, db.HealthConnected)
+		remember := a.rememberConnect(msg.Name)
+		connected := "Connected to " + msg.Name
+		if msg.TLS != "" {
+			connected += " (" + msg.TLS + ")"
+		}
+		a.statusbar.SetMessage(connected, false)
+		a.setStatusContext(msg.Name, "")
 		// Switch to browse screen
 		a.screen = ScreenBrowse
 		a.panel = PanelSidebar
 		a.inputFocused = false
 		a.layoutResize()
 		a.updateHints()
+		load := loadTablesCmd(a.mgr, msg.Name)
+		if c, ok := a.mgr.ConnectionConfig(msg.Name); ok && c.ShowDatabases {
+			load = loadDatabasesCmd(a.mgr, msg.Name)
+		}
 		return a, tea.Batch(
-			loadTablesCmd(a.mgr, msg.Name),
+			load,
+			remember,
 			statusTimeoutCmd(3*time.Second),
 		)
 
+	case ConnectionTestedMsg:
+		a.loading = false
+		a.statusbar.SetLoading(false, "")
+		a.homescreen.SetTestResult(msg.Name, msg.Diag, msg.Err)
+		a.updateHints()
+		return a, nil
+
 	case DisconnectMsg:
+		delete(a.health, msg.Name)
+		a.listenerConnClosed(msg.Name)
+		delete(a.unsure, msg.Name)
 		a.sidebar.RemoveTables(msg.Name)
 		if a.activeConn == msg.Name {
 			a.activeConn = ""
-			a.statusbar.SetContext("", "")
+			a.setStatusContext("", "")
 		}
 		a.statusbar.SetMessage("Disconnected from "+msg.Name, false)
 		a.updateHints()
 		return a, statusTimeoutCmd(3 * time.Second)
 
+	case HealthTickMsg:
+		cmds := []tea.Cmd{healthTickCmd()}
+		for _, name := range a.mgr.Connected() {
+			if a.health[name] != db.HealthLost { // already reconnecting
+				cmds = append(cmds, checkHealthCmd(a.mgr, name))
+			}
+		}
+		return a, tea.Batch(cmds...)
+
+	case HealthMsg:
+		if !a.mgr.IsConnected(msg.Name) {
+			return a, nil // disconnected meanwhile
+		}
+		if msg.Health == db.HealthLost {
+			return a, a.lost(msg.Name)
+		}
+		a.setHealth(msg.Name, msg.Health)
+		return a, nil
+
+	case ReconnectMsg:
+		if !a.mgr.IsConnected(msg.Name) {
+			delete(a.health, msg.Name)
+			return a, nil
+		}
+		if msg.Err != nil {
+			return a, reconnectCmd(a.mgr, msg.Name, msg.Attempt+1)
+		}
+		a.setHealth(msg.Name, db.HealthConnected)
+		relisten := a.relisten(msg.Name)
+		if a.confirming {
+			return a, relisten // leave the question on screen
+		}
+		if what, ok := a.unsure[msg.Name]; ok {
+			delete(a.unsure, msg.Name)
+			a.statusbar.SetMessage("Reconnected to "+msg.Name+"; "+what+" may have been applied before the connection was lost, reload to check", true)
+			a.updateHints()
+			return a, tea.Batch(relisten, statusTimeoutCmd(10*time.Second))
+		}
+		a.statusbar.SetMessage("Reconnected to "+msg.Name, false)
+		a.updateHints()
+		return a, tea.Batch(relisten, statusTimeoutCmd(3*time.Second))
+
 	case TablesLoadedMsg:
+		a.loading = false
+		a.statusbar.SetLoading(false, "")
+		if db.IsConnectionLost(msg.Err) {
+			return a, a.connectionLost(msg.ConnName, "Load tables failed", "", msg.Err)
+		}
 		if msg.Err != nil {
 			a.statusbar.SetMessage("Load tables failed: "+msg.Err.Error(), true)
 			a.updateHints()
 			return a, statusTimeoutCmd(5 * time.Second)
 		}
-		a.sidebar.LoadTables(msg.ConnName, msg.Tables)
+		a.sidebar.LoadTables(msg.ConnName, msg.Database, msg.Tables)
+		a.sidebar.SetCurrentDatabase(msg.ConnName, msg.Database)
 		a.updateHints()
 		return a, nil
 
-	case ColumnsLoadedMsg:
+	case DatabasesLoadedMsg:
+		a.loading = false
+		a.statusbar.SetLoading(false, "")
+		if db.IsConnectionLost(msg.Err) {
+			return a, a.connectionLost(msg.ConnName, "Load databases failed", "", msg.Err)
+		}
 		if msg.Err != nil {
+			a.statusbar.SetMessage("Load databases failed: "+msg.Err.Error(), true)
+			a.updateHints()
+			return a, statusTimeoutCmd(5 * time.Second)
+		}
+		a.sidebar.LoadDatabases(msg.ConnName, msg.Databases)
+		a.updateHints()
+		return a, loadTablesCmd(a.mgr, msg.ConnName)
+
+	case RolesLoadedMsg:
+		a.loading = false
+		a.statusbar.SetLoading(false, "")
+		if msg.Err != nil {
+			a.statusbar.SetMessage("Load roles failed: "+msg.Err.Error(), true)
+			a.updateHints()
+			return a, statusTimeoutCmd(5 * time.Second)
+		}
+		a.pickRole(msg)
+		return a, nil
+
+	case RoleInfoLoadedMsg:
+		a.loading = false
+		a.statusbar.SetLoading(false, "")
+		if msg.Err != nil {
+			a.statusbar.SetMessage("Load roles failed: "+msg.Err.Error(), true)
+			a.updateHints()
+			return a, statusTimeoutCmd(5 * time.Second)
+		}
+		a.roleInfo[msg.ConnName] = msg.Roles
+		a.sidebar.LoadRoles(msg.ConnName, msg.Roles)
+		a.updateHints()
+		return a, nil
+
+	case AccessLoadedMsg:
+		a.loading = false
+		a.statusbar.SetLoading(false, "")
+		if msg.Err != nil {
+			a.statusbar.SetMessage("Load privileges failed: "+msg.Err.Error(), true)
+			a.updateHints()
+			return a, statusTimeoutCmd(5 * time.Second)
+		}
+		a.showAccess(msg)
+		a.updateHints()
+		return a, statusTimeoutCmd(8 * time.Second)
+
+	case RowSecurityLoadedMsg:
+		a.loading = false
+		a.statusbar.SetLoading(false, "")
+		if msg.Err != nil {
+			a.statusbar.SetMessage("Load policies failed: "+msg.Err.Error(), true)
+			a.updateHints()
+			return a, statusTimeoutCmd(5 * time.Second)
+		}
+		a.showPolicies(msg)
+		a.updateHints()
+		return a, statusTimeoutCmd(8 * time.Second)
+
+	case GrantsLoadedMsg:
+		a.loading = false
+		a.statusbar.SetLoading(false, "")
+		if msg.Err != nil {
+			a.statusbar.SetMessage("Load grants failed: "+msg.Err.Error(), true)
+			a.updateHints()
+			return a, statusTimeoutCmd(5 * time.Second)
+		}
+		a.startGrants(msg)
+		return a, nil
+
+	case SchemasLoadedMsg:
+		a.loading = false
+		a.statusbar.SetLoading(false, "")
+		if msg.Err != nil {
+			a.statusbar.SetMessage("Load schemas failed: "+msg.Err.Error(), true)
+			a.updateHints()
+			return a, statusTimeoutCmd(5 * time.Second)
+		}
+		a.pickSearchPath(msg)
+		return a, nil
+
+	case DatabaseSwitchedMsg:
+		a.loading = false
+		a.statusbar.SetLoading(false, "")
+		if msg.Err != nil {
+			a.statusbar.SetMessage("Switch database failed: "+msg.Err.Error(), true)
+			a.updateHints()
+			return a, statusTimeoutCmd(5 * time.Second)
+		}
+		a.switchedDatabase(msg.ConnName)
+		a.statusbar.SetMessage("Using database "+msg.Database, false)
+		a.updateHints()
+		return a, tea.Batch(
+			loadTablesCmd(a.mgr, msg.ConnName),
+			statusTimeoutCmd(3*time.Second),
+		)
+
+	case ColumnsLoadedMsg:
+		if msg.Err != nil || msg.Database != a.mgr.Database(msg.ConnName) {
 			return a, nil
 		}
 		var pks []string
@@ -151,26 +410,36 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				pks = append(pks, c.Name)
 			}
 		}
-		cacheKey := msg.Schema + "." + msg.Table
-		a.pkCache[cacheKey] = pks
+		key := tableKey{conn: msg.ConnName, database: msg.Database, schema: msg.Schema, table: msg.Table}
+		a.pkCache[key] = pks
+		a.colCache[key] = msg.Columns
 		return a, nil
 
 	case TableDataMsg:
 		a.loading = false
 		a.statusbar.SetLoading(false, "")
+		if msg.Database != a.mgr.Database(msg.ConnName) && !db.IsConnectionLost(msg.Err) {
+			return a, nil // from a database we have since switched away from
+		}
+		if db.IsConnectionLost(msg.Err) {
+			return a, a.connectionLost(msg.ConnName, "Load data failed", "", msg.Err)
+		}
 		if msg.Err != nil {
 			a.statusbar.SetMessage("Load data failed: "+msg.Err.Error(), true)
 			a.updateHints()
 			return a, statusTimeoutCmd(5 * time.Second)
 		}
 		a.tableview.SetData(msg.ConnName, msg.Schema, msg.Table, msg.Result)
-		a.statusbar.SetContext(msg.ConnName, msg.Table)
+		a.setStatusContext(msg.ConnName, msg.Table)
 		a.updateHints()
 		return a, nil
 
 	case QueryResultMsg:
 		a.loading = false
 		a.statusbar.SetLoading(false, "")
+		if db.IsConnectionLost(msg.Err) {
+			return a.offerRetry(msg)
+		}
 		if msg.Err != nil {
 			a.statusbar.SetMessage("Query error: "+msg.Err.Error(), true)
 			a.updateHints()
@@ -188,6 +457,9 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return a, statusTimeoutCmd(5 * time.Second)
 
 	case RowDeletedMsg:
+		if db.IsConnectionLost(msg.Err) {
+			return a, a.connectionLost(msg.ConnName, "Delete failed", "the delete", msg.Err)
+		}
 		if msg.Err != nil {
 			a.statusbar.SetMessage("Delete failed: "+msg.Err.Error(), true)
 			a.updateHints()
@@ -201,22 +473,28 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		)
 
 	case RowInsertedMsg:
-		a.tableview.CancelInsert()
+		if db.IsConnectionLost(msg.Err) {
+			return a, a.connectionLost(msg.ConnName, "Insert failed", "the insert", msg.Err)
+		}
 		if msg.Err != nil {
+			// Keep the form open so the values can be fixed
 			a.statusbar.SetMessage("Insert failed: "+msg.Err.Error(), true)
 			a.updateHints()
 			return a, statusTimeoutCmd(5 * time.Second)
 		}
-		a.statusbar.SetMessage("Row inserted", false)
+		a.tableview.CancelInsert()
+		// Show the returned row, including generated keys, until the next reload
+		a.tableview.SetData(a.tableview.ConnName(), a.tableview.Schema(), a.tableview.TableName(), msg.Result)
+		a.statusbar.SetMessage("Row inserted (r to reload table)", false)
 		a.inputFocused = false
 		a.updateHints()
-		return a, tea.Batch(
-			a.reloadTableData(),
-			statusTimeoutCmd(3*time.Second),
-		)
+		return a, statusTimeoutCmd(5 * time.Second)
 
 	case RowUpdatedMsg:
 		a.tableview.CancelEdit()
+		if db.IsConnectionLost(msg.Err) {
+			return a, a.connectionLost(msg.ConnName, "Update failed", "the update", msg.Err)
+		}
 		if msg.Err != nil {
 			a.statusbar.SetMessage("Update failed: "+msg.Err.Error(), true)
 			a.updateHints()
@@ -230,6 +508,25 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			statusTimeoutCmd(3*time.Second),
 		)
 
+	case BulkDoneMsg:
+		if db.IsConnectionLost(msg.Err) {
+			return a, a.connectionLost(msg.ConnName, "Bulk action failed", "the bulk action", msg.Err)
+		}
+		if msg.Err != nil {
+			a.statusbar.SetMessage("Bulk action failed: "+msg.Err.Error(), true)
+			a.updateHints()
+			return a, statusTimeoutCmd(5 * time.Second)
+		}
+		a.tableview.CancelEdit()
+		a.tableview.ClearSelection()
+		a.inputFocused = false
+		a.statusbar.SetMessage(fmt.Sprintf("%s %d rows", msg.Action, msg.Affected), false)
+		a.updateHints()
+		return a, tea.Batch(
+			a.reloadTableData(),
+			statusTimeoutCmd(3*time.Second),
+		)
+
 	case ConnectionSavedMsg:
 		if msg.Err != nil {
 			a.statusbar.SetMessage("Save failed: "+msg.Err.Error(), true)
@@ -238,7 +535,7 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		a.statusbar.SetMessage("Connection saved", false)
 		a.homescreen.SetConnections(a.cfg.Connections)
-		a.sidebar = sidebar.New(a.cfg.Connections)
+		a.sidebar.SetConnections(a.cfg.Connections)
 		a.updateHints()
 		return a, statusTimeoutCmd(3 * time.Second)
 
@@ -250,15 +547,159 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		a.statusbar.SetMessage("Connection deleted", false)
 		a.homescreen.SetConnections(a.cfg.Connections)
-		a.sidebar = sidebar.New(a.cfg.Connections)
+		a.sidebar.SetConnections(a.cfg.Connections)
 		a.updateHints()
 		return a, statusTimeoutCmd(3 * time.Second)
 
+	case OpenInEditorMsg:
+		return a.loadEditor(msg.Query, msg.Note)
+
+	case ClipboardMsg:
+		return a.handleClipboard(msg)
+
 	case StatusMsg:
 		a.statusbar.SetMessage(msg.Text, msg.IsErr)
 		a.updateHints()
 		return a, statusTimeoutCmd(5 * time.Second)
 
+	case AdminTickMsg:
+		if msg.Seq != a.adminSeq || a.screen != ScreenAdmin {
+			return a, nil // left the page, so stop refreshing it
+		}
+		return a, tea.Batch(a.refreshAdmin(), adminTickCmd(a.adminSeq, a.adminEvery))
+
+	case ActivityLoadedMsg:
+		if msg.ConnName != a.adminConn || a.adminPage != adminActivity {
+			return a, nil
+		}
+		if msg.Err == nil {
+			a.setActivity(msg.Activity)
+		}
+		return a, a.adminLoaded(msg.Err)
+
+	case BlockingLoadedMsg:
+		if msg.ConnName != a.adminConn || a.adminPage != adminLocks {
+			return a, nil
+		}
+		if msg.Err == nil {
+			a.setBlocking(msg.Processes)
+		}
+		return a, a.adminLoaded(msg.Err)
+
+	case StatementsLoadedMsg:
+		if msg.ConnName != a.adminConn || a.adminPage != adminStatements {
+			return a, nil
+		}
+		return a, a.statementsLoaded(msg)
+
+	case StatementsResetMsg:
+		if msg.Err != nil {
+			a.statusbar.SetMessage(msg.Err.Error(), true)
+			return a, statusTimeoutCmd(5 * time.Second)
+		}
+		a.statusbar.SetMessage("Statement statistics reset", false)
+		return a, tea.Batch(a.refreshAdmin(), statusTimeoutCmd(3*time.Second))
+
+	case SizesLoadedMsg:
+		if msg.ConnName != a.adminConn || a.adminPage != adminSizes {
+			return a, nil
+		}
+		if msg.Err == nil {
+			a.setSizes(msg.Tables, msg.Indexes)
+		}
+		return a, a.adminLoaded(msg.Err)
+
+	case ReplicationLoadedMsg:
+		if msg.ConnName != a.adminConn || a.adminPage != adminReplication {
+			return a, nil
+		}
+		if msg.Err == nil {
+			a.setReplication(msg.Status)
+		}
+		return a, a.adminLoaded(msg.Err)
+
+	case ServerInfoLoadedMsg:
+		if msg.ConnName != a.adminConn || a.adminPage != adminServer {
+			return a, nil
+		}
+		if msg.Err == nil {
+			a.setServerInfo(msg.Info, msg.Extensions)
+		}
+		return a, a.adminLoaded(msg.Err)
+
+	case SettingsLoadedMsg:
+		if msg.ConnName != a.adminConn || a.adminPage != adminSettings {
+			return a, nil
+		}
+		if msg.Err == nil {
+			a.setSettings(msg.Settings)
+		}
+		return a, a.adminLoaded(msg.Err)
+
+	case ProgressLoadedMsg:
+		if a.adminPage != adminMaintenance {
+			return a, nil
+		}
+		return a, a.progressLoaded(msg)
+
+	case MaintenanceRequestedMsg:
+		return a.startMaintenance(msg.ConnName, msg.Maintenance)
+
+	case MaintenanceStartedMsg, MaintenanceNoticeMsg, MaintenanceDoneMsg:
+		return a.maintenanceEvent(msg)
+
+	case MaintenanceCancelSentMsg:
+		return a, a.maintenanceCancelSent(msg)
+
+	case ListenerOpenedMsg:
+		return a.listenerOpened(msg)
+
+	case ListenedMsg:
+		return a.listened(msg)
+
+	case NotificationMsg, ListenerDoneMsg:
+		return a.notifyEvent(msg)
+
+	case NotifySentMsg:
+		if msg.Err != nil {
+			a.logNotify("ERROR", "", msg.Err.Error())
+			a.statusbar.SetMessage(msg.Err.Error(), true)
+		} else {
+			a.statusbar.SetMessage("Notified "+msg.Channel, false)
+		}
+		a.redrawNotify()
+		return a, statusTimeoutCmd(3 * time.Second)
+
+	case BackendSignaledMsg:
+		a.loading = false
+		a.statusbar.SetLoading(false, "")
+		if msg.Err != nil {
+			a.statusbar.SetMessage(msg.Err.Error(), true)
+			return a, statusTimeoutCmd(5 * time.Second)
+		}
+		switch {
+		case !msg.OK:
+			a.statusbar.SetMessage(fmt.Sprintf("Could not signal pid %d, it may have ended already", msg.PID), true)
+		case msg.Terminate:
+			a.statusbar.SetMessage(fmt.Sprintf("Terminated pid %d", msg.PID), false)
+		default:
+			a.statusbar.SetMessage(fmt.Sprintf("Cancelled the query of pid %d", msg.PID), false)
+		}
+		return a, tea.Batch(a.refreshAdmin(), statusTimeoutCmd(3*time.Second))
+
+	case PlanLoadedMsg:
+		a.loading = false
+		a.statusbar.SetLoading(false, "")
+		if msg.Err != nil {
+			a.statusbar.SetMessage(msg.Err.Error(), true)
+			return a, statusTimeoutCmd(5 * time.Second)
+		}
+		if a.screen == ScreenAdmin {
+			a.admin.ShowDetail(msg.Title, msg.Plan)
+			a.updateHints()
+		}
+		return a, nil
+
 	case ClearStatusMsg:
 		a.statusbar.ClearMessage()
 		return a, nil
@@ -278,10 +719,23 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (a App) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	// Global quit on ctrl+c
 	if key.Matches(msg, key.NewBinding(key.WithKeys("ctrl+c"))) {
+		a.stopListener()
 		a.mgr.CloseAll()
 		return a, tea.Quit
 	}
 
+	if a.prompting {
+		return a.handlePromptKey(msg)
+	}
+
+	if a.picking {
+		return a.handlePickKey(msg)
+	}
+
+	if a.granting {
+		return a.handleGrantKey(msg)
+	}
+
 	// Confirmation mode
 	if a.confirming {
 		return a.handleConfirmKey(msg)
@@ -295,6 +749,8 @@ func (a App) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			return a.handleInputKey(msg)
 		}
 		return a.handleBrowseKey(msg)
+	case ScreenAdmin:
+		return a.handleAdminKey(msg)
 	}
 
 	return a, nil
@@ -302,8 +758,14 @@ func (a App) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 func (a App) handleConfirmKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
+	case "m":
+		if a.pasteRecords != nil {
+			return a.cyclePasteMapping()
+		}
 	case "y", "Y":
 		a.confirming = false
+		a.confirmPreview = ""
+		a.pasteRecords = nil
 		a.statusbar.ClearMessage()
 		if a.onConfirm != nil {
 			cmd := a.onConfirm()
@@ -313,6 +775,8 @@ func (a App) handleConfirmKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 	case "n", "N", "esc":
 		a.confirming = false
+		a.confirmPreview = ""
+		a.pasteRecords = nil
 		a.onConfirm = nil
 		a.statusbar.SetMessage("Cancelled", false)
 		a.updateHints()
@@ -321,15 +785,106 @@ func (a App) handleConfirmKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return a, nil
 }
 
+// setHealth records the health of a connection and shows it in the sidebar
+// and, for the active connection, the status bar.
+func (a *App) setHealth(name string, h db.Health) {
+	a.health[name] = h
+	a.sidebar.SetHealth(name, h)
+	if name == a.activeConn {
+		a.statusbar.SetHealth(shared.HealthBadge(h))
+	}
+}
+
+// offerRetry asks whether to rerun a query that failed because the
+// connection went away. A write may have been committed before the
+// connection dropped, so the question says so.
+func (a App) offerRetry(msg QueryResultMsg) (tea.Model, tea.Cmd) {
+	cmd := a.lost(msg.ConnName)
+	a.confirming = true
+	a.confirmText = fmt.Sprintf("Connection to %s lost: %v. Retry query? (y/n)", msg.ConnName, msg.Err)
+	if db.IsWriteQuery(msg.Query) {
+		a.confirmText = fmt.Sprintf("Connection to %s lost: %v. The statement may already have been applied. Run it again? (y/n)", msg.ConnName, msg.Err)
+	}
+	a.statusbar.SetMessage(a.confirmText, true)
+	a.onConfirm = func() tea.Cmd {
+		return retryQueryCmd(a.mgr, msg.ConnName, msg.Query, a.formatter())
+	}
+	a.updateHints()
+	return a, cmd
+}
+
+// connectionLost reports that what failed because connName went away, and
+// starts reconnecting. unsure names a write whose outcome is unknown, as it
+// may have committed before the connection dropped; it is brought up again
+// once reconnected.
+func (a *App) connectionLost(connName, what, unsure string, err error) tea.Cmd {
+	cmd := a.lost(connName)
+	text := fmt.Sprintf("%s: connection to %s lost (%v), reconnecting...", what, connName, err)
+	if unsure != "" {
+		a.unsure[connName] = unsure
+		text += " " + strings.ToUpper(unsure[:1]) + unsure[1:] + " may have been applied"
+	}
+	a.statusbar.SetMessage(text, true)
+	a.updateHints()
+	return tea.Batch(cmd, statusTimeoutCmd(5*time.Second))
+}
+
+// lost marks connName as lost and starts reconnecting it, unless that is
+// already under way.
+func (a *App) lost(connName string) tea.Cmd {
+	if !a.mgr.IsConnected(connName) || a.health[connName] == db.HealthLost {
+		return nil
+	}
+	a.setHealth(connName, db.HealthLost)
+	if !a.confirming {
+		a.statusbar.SetMessage("Connection to "+connName+" lost, reconnecting...", true)
+		a.updateHints()
+	}
+	return reconnectCmd(a.mgr, connName, 0)
+}
+
 // --- Home Screen Key Handling ---
 
 func (a App) handleHomeKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	if a.homescreen.IsFormOpen() {
 		return a.handleHomeFormKey(msg)
 	}
+	if a.homescreen.IsFiltering() {
+		return a.handleHomeFilterKey(msg)
+	}
 
 	switch {
+	case key.Matches(msg, Keys.Escape) && a.homescreen.IsFiltered():
+		a.homescreen.ClearFilter()
+		a.updateHints()
+		return a, nil
+
+	case key.Matches(msg, Keys.Search):
+		a.homescreen.StartFilter()
+		a.updateHints()
+		return a, nil
+
+	case msg.String() == "h", msg.String() == "left":
+		a.homescreen.SetFolderCollapsed(true)
+		a.updateHints()
+		return a, nil
+
+	case msg.String() == "l", msg.String() == "right":
+		a.homescreen.SetFolderCollapsed(false)
+		a.updateHints()
+		return a, nil
+
+	case msg.String() == "f":
+		return a.toggleFavorite()
+
+	case msg.String() == "K":
+		return a.moveConnection(-1)
+
+	case msg.String() == "J":
+		return a.moveConnection(1)
+
 	case key.Matches(msg, Keys.Quit):
+		a.stopListener()
 		a.mgr.CloseAll()
 		return a, tea.Quit
 
@@ -357,7 +912,18 @@ func (a App) handleHomeKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case key.Matches(msg, Keys.Delete):
 		return a.handleDeleteConnection()
 
+	case msg.String() == "t":
+		conn, ok := a.homescreen.SelectedConnection()
+		if !ok {
+			return a, nil
+		}
+		return a.testConnection(conn)
+
 	case key.Matches(msg, Keys.Enter):
+		if a.homescreen.ToggleFolder() {
+			a.updateHints()
+			return a, nil
+		}
 		conn, ok := a.homescreen.SelectedConnection()
 		if !ok {
 			return a, nil
@@ -372,6 +938,61 @@ func (a App) handleHomeKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return a, nil
 }
 
+func (a App) handleHomeFilterKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		a.homescreen.ClearFilter()
+	case "enter":
+		a.homescreen.AcceptFilter()
+	case "up", "ctrl+p":
+		a.homescreen.MoveUp()
+	case "down", "ctrl+n":
+		a.homescreen.MoveDown()
+	default:
+		return a, a.homescreen.UpdateFilter(msg)
+	}
+	a.updateHints()
+	return a, nil
+}
+
+func (a App) toggleFavorite() (tea.Model, tea.Cmd) {
+	idx := a.homescreen.SelectedIndex()
+	if idx < 0 {
+		return a, nil
+	}
+
+	a.cfg.Connections[idx].Favorite = !a.cfg.Connections[idx].Favorite
+	a.mgr.AddConnection(a.cfg.Connections[idx])
+	a.homescreen.SetConnections(a.cfg.Connections)
+	a.updateHints()
+	return a, saveConfigCmd(a.cfg, a.cfg.Connections[idx].Name)
+}
+
+// moveConnection swaps the selected connection with its neighbour in the same
+// folder, which is the order the config file lists them in.
+func (a App) moveConnection(delta int) (tea.Model, tea.Cmd) {
+	from, to, ok := a.homescreen.SiblingIndex(delta)
+	if !ok {
+		return a, nil
+	}
+
+	conns := a.cfg.Connections
+	conns[from], conns[to] = conns[to], conns[from]
+	a.homescreen.SetConnections(conns)
+	a.homescreen.SelectConnection(to)
+	a.sidebar.SetConnections(conns)
+	a.updateHints()
+	return a, saveConfigCmd(a.cfg, conns[to].Name)
+}
+
+// rememberConnect records name as recently used and returns the command
+// that saves the history.
+func (a *App) rememberConnect(name string) tea.Cmd {
+	a.state.Touch(name)
+	a.homescreen.SetRecent(a.state.Recent(recentConnections))
+	return saveStateCmd(a.state)
+}
+
 func (a App) handleHomeFormKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch {
 	case key.Matches(msg, Keys.Escape):
@@ -382,6 +1003,14 @@ func (a App) handleHomeFormKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case key.Matches(msg, Keys.Enter):
 		return a.saveConnection()
 
+	case key.Matches(msg, Keys.TestConn):
+		conn, err := a.homescreen.FormConnection()
+		if err != nil {
+			a.statusbar.SetMessage(err.Error(), true)
+			return a, statusTimeoutCmd(3 * time.Second)
+		}
+		return a.testConnection(conn)
+
 	case key.Matches(msg, Keys.Tab):
 		a.homescreen.NextField()
 		return a, nil
@@ -396,18 +1025,24 @@ func (a App) handleHomeFormKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 }
 
 func (a App) saveConnection() (tea.Model, tea.Cmd) {
-	conn := a.homescreen.FormConnection()
+	conn, err := a.homescreen.FormConnection()
+	if err != nil {
+		a.statusbar.SetMessage(err.Error(), true)
+		a.updateHints()
+		return a, statusTimeoutCmd(3 * time.Second)
+	}
 	if conn.Name == "" {
 		a.statusbar.SetMessage("Connection name is required", true)
 		a.updateHints()
 		return a, statusTimeoutCmd(3 * time.Second)
 	}
-	if conn.URL == "" && conn.Host == "" {
-		a.statusbar.SetMessage("Either URL or host is required", true)
+	if !conn.HasTarget() {
+		a.statusbar.SetMessage("A URL, host or service is required", true)
 		a.updateHints()
 		return a, statusTimeoutCmd(3 * time.Second)
 	}
 
+	var saveState tea.Cmd
 	if a.homescreen.IsCreating() {
 		a.cfg.Connections = append(a.cfg.Connections, conn)
 		a.mgr.AddConnection(conn)
@@ -416,14 +1051,29 @@ func (a App) saveConnection() (tea.Model, tea.Cmd) {
 		oldName := a.cfg.Connections[idx].Name
 		a.cfg.Connections[idx] = conn
 		a.mgr.UpdateConnection(oldName, conn)
+		a.listenerConnClosed(oldName)
+		a.sidebar.RemoveTables(oldName)
+		if oldName != conn.Name {
+			a.state.Rename(oldName, conn.Name)
+			saveState = saveStateCmd(a.state)
+		}
 	}
 
 	a.homescreen.CancelForm()
 
-	return a, func() tea.Msg {
-		err := a.cfg.Save()
-		return ConnectionSavedMsg{Name: conn.Name, Err: err}
+	return a, tea.Batch(saveConfigCmd(a.cfg, conn.Name), saveState)
+}
+
+func (a App) testConnection(conn config.Connection) (tea.Model, tea.Cmd) {
+	if !conn.HasTarget() {
+		a.statusbar.SetMessage("A URL, host or service is required", true)
+		return a, statusTimeoutCmd(3 * time.Second)
 	}
+
+	a.loading = true
+	a.statusbar.SetLoading(true, "Testing "+conn.Name+"...")
+	a.homescreen.SetTesting(conn.Name)
+	return a, testConnectionCmd(a.mgr, conn)
 }
 
 func (a App) handleDeleteConnection() (tea.Model, tea.Cmd) {
@@ -444,11 +1094,10 @@ func (a App) handleDeleteConnection() (tea.Model, tea.Cmd) {
 			}
 			name := a.cfg.Connections[idx].Name
 			a.mgr.RemoveConnection(name)
+			a.listenerConnClosed(name)
 			a.cfg.Connections = append(a.cfg.Connections[:idx], a.cfg.Connections[idx+1:]...)
-			return func() tea.Msg {
-				err := a.cfg.Save()
-				return ConnectionDeletedMsg{Name: name, Err: err}
-			}
+			a.state.Rename(name, "")
+			return tea.Batch(saveStateCmd(a.state), deleteConfigCmd(a.cfg, name))
 		}
 		return a, nil
 	}
@@ -456,17 +1105,21 @@ func (a App) handleDeleteConnection() (tea.Model, tea.Cmd) {
 	idx := a.homescreen.SelectedIndex()
 	name := a.cfg.Connections[idx].Name
 	a.mgr.RemoveConnection(name)
+	a.listenerConnClosed(name)
 	a.cfg.Connections = append(a.cfg.Connections[:idx], a.cfg.Connections[idx+1:]...)
-	return a, func() tea.Msg {
-		err := a.cfg.Save()
-		return ConnectionDeletedMsg{Name: name, Err: err}
-	}
+	a.state.Rename(name, "")
+	return a, tea.Batch(saveStateCmd(a.state), deleteConfigCmd(a.cfg, name))
 }
 
 // --- Browse Screen Key Handling ---
 
 func (a App) handleBrowseKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch {
+	case key.Matches(msg, Keys.Escape) && a.panel == PanelTable && a.tableview.HasSelection():
+		a.tableview.ClearSelection()
+		a.updateHints()
+		return a, nil
+
 	case key.Matches(msg, Keys.Quit), key.Matches(msg, Keys.Escape):
 		// Back to home screen
 		a.screen = ScreenHome
@@ -524,6 +1177,80 @@ func (a App) handleBrowseKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			return a, nil
 		}
 
+	case key.Matches(msg, Keys.Databases):
+		if a.panel == PanelSidebar {
+			return a.toggleDatabases()
+		}
+
+	case key.Matches(msg, Keys.Role), key.Matches(msg, Keys.SearchPath):
+		if a.activeConn == "" || !a.mgr.IsConnected(a.activeConn) {
+			return a, nil
+		}
+		a.loading = true
+		if key.Matches(msg, Keys.Role) {
+			a.statusbar.SetLoading(true, "Loading roles...")
+			return a, loadRolesCmd(a.mgr, a.activeConn)
+		}
+		a.statusbar.SetLoading(true, "Loading schemas...")
+		return a, loadSchemasCmd(a.mgr, a.activeConn)
+
+	case key.Matches(msg, Keys.Admin):
+		return a.openAdmin(adminActivity)
+
+	case key.Matches(msg, Keys.Maintenance):
+		if a.panel == PanelSidebar {
+			return a.maintenanceMenu()
+		}
+
+	case key.Matches(msg, Keys.Access):
+		if a.panel == PanelSidebar {
+			return a.tableAccess()
+		}
+
+	case key.Matches(msg, Keys.Policies):
+		if a.panel == PanelSidebar {
+			return a.tablePolicies()
+		}
+
+	case key.Matches(msg, Keys.Grants):
+		if a.panel == PanelSidebar {
+			return a.grantMatrix()
+		}
+
+	case key.Matches(msg, Keys.Mark):
+		if a.panel == PanelTable && a.tableview.HasData() {
+			a.tableview.ToggleMark()
+			a.updateHints()
+			return a, nil
+		}
+
+	case key.Matches(msg, Keys.Visual):
+		if a.panel == PanelTable && a.tableview.HasData() {
+			a.tableview.ToggleVisual()
+			a.updateHints()
+			return a, nil
+		}
+
+	case key.Matches(msg, Keys.Duplicate):
+		if a.panel == PanelTable && a.tableview.HasSelection() {
+			return a.handleDuplicateRows()
+		}
+
+	case key.Matches(msg, Keys.BulkUpdate):
+		if a.panel == PanelTable && a.tableview.HasSelection() {
+			return a.handleBulkUpdate()
+		}
+
+	case key.Matches(msg, Keys.PasteRows):
+		if a.panel == PanelTable && a.tableview.HasData() {
+			return a.handlePasteRows()
+		}
+
+	case key.Matches(msg, Keys.Reload):
+		if a.panel == PanelTable && a.tableview.HasData() {
+			return a, a.reloadTableData()
+		}
+
 	case key.Matches(msg, Keys.NextPage):
 		if a.panel == PanelTable && a.tableview.HasData() {
 			a.tableview.NextPage()
@@ -602,22 +1329,19 @@ func (a App) handleInputKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 func (a App) handleCellEditKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "enter":
-		return a.saveCellEdit()
+		return a.saveCellEdit(db.CellValue{Kind: db.CellText, Text: a.tableview.EditValue()})
+	case "ctrl+n":
+		return a.saveCellEdit(db.CellValue{Kind: db.CellNull})
+	case "ctrl+d":
+		return a.saveCellEdit(db.CellValue{Kind: db.CellDefault})
 	case "tab":
 		a.tableview.NextEditCol()
+		return a, nil
 	case "shift+tab":
 		a.tableview.PrevEditCol()
-	case "backspace":
-		v := a.tableview.EditValue()
-		if len(v) > 0 {
-			a.tableview.SetEditValue(v[:len(v)-1])
-		}
-	default:
-		if len(msg.String()) == 1 {
-			a.tableview.SetEditValue(a.tableview.EditValue() + msg.String())
-		}
+		return a, nil
 	}
-	return a, nil
+	return a, a.tableview.UpdateEdit(msg)
 }
 
 func (a App) handleRowInsertKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
@@ -627,24 +1351,18 @@ func (a App) handleRowInsertKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if done {
 			return a.submitInsertRow()
 		}
+	case "ctrl+s":
+		return a.submitInsertRow()
 	case "tab":
 		a.tableview.NextInsertCol()
 	case "shift+tab":
 		a.tableview.PrevInsertCol()
-	case "backspace":
-		vals := a.tableview.InsertValues()
-		col := a.tableview.InsertCol()
-		if col < len(vals) && len(vals[col]) > 0 {
-			a.tableview.SetInsertValue(vals[col][:len(vals[col])-1])
-		}
+	case "ctrl+n":
+		a.tableview.SetInsertKind(db.CellNull)
+	case "ctrl+d":
+		a.tableview.SetInsertKind(db.CellDefault)
 	default:
-		if len(msg.String()) == 1 {
-			vals := a.tableview.InsertValues()
-			col := a.tableview.InsertCol()
-			if col < len(vals) {
-				a.tableview.SetInsertValue(vals[col] + msg.String())
-			}
-		}
+		return a, a.tableview.UpdateInsert(msg)
 	}
 	return a, nil
 }
@@ -707,11 +1425,33 @@ func (a App) handleEnter() (tea.Model, tea.Cmd) {
 		if connName == "" {
 			return a, nil
 		}
+		database, isDB := a.sidebar.SelectedDatabase()
+		if isDB {
+			return a.selectDatabase(connName, database)
+		}
+		if a.sidebar.IsSecurity() {
+			return a.toggleSecurity(connName)
+		}
+		if role := a.sidebar.SelectedRole(); role != "" {
+			return a.roleAccess(connName, role)
+		}
+		if database != "" && database != a.mgr.Database(connName) {
+			if !a.mgr.SwitchDatabase(connName, database) {
+				a.statusbar.SetMessage("Database "+database+" is not open", true)
+				return a, statusTimeoutCmd(3 * time.Second)
+			}
+			a.switchedDatabase(connName)
+		}
 		if isConn {
 			if a.mgr.IsConnected(connName) {
+				byDB := a.sidebar.HasDatabases(connName)
 				a.sidebar.CollapseConnection(connName)
 				a.sidebar.SetConnected(connName, true)
 				a.loading = true
+				if byDB {
+					a.statusbar.SetLoading(true, "Loading databases...")
+					return a, loadDatabasesCmd(a.mgr, connName)
+				}
 				a.statusbar.SetLoading(true, "Loading tables...")
 				return a, loadTablesCmd(a.mgr, connName)
 			}
@@ -725,11 +1465,10 @@ func (a App) handleEnter() (tea.Model, tea.Cmd) {
 		a.activeConn = connName
 		a.loading = true
 		a.statusbar.SetLoading(true, "Loading "+table+"...")
-		cacheKey := schema + "." + table
 		var cmds []tea.Cmd
 		cmds = append(cmds, loadTableDataCmd(a.mgr, connName, schema, table,
-			a.cfg.Settings.DefaultLimit, 0, a.cfg.Settings.NullDisplay))
-		if _, ok := a.pkCache[cacheKey]; !ok {
+			a.cfg.Settings.DefaultLimit, 0, a.formatter()))
+		if _, ok := a.pkCache[a.cacheKey(connName, schema, table)]; !ok {
 			cmds = append(cmds, loadColumnsCmd(a.mgr, connName, schema, table))
 		}
 		a.updateHints()
@@ -737,7 +1476,11 @@ func (a App) handleEnter() (tea.Model, tea.Cmd) {
 	}
 
 	if a.panel == PanelTable && a.tableview.HasData() {
-		row, _, val := a.tableview.StartEdit()
+		if reason := a.readOnlyReason(); reason != "" {
+			a.statusbar.SetMessage(reason, true)
+			return a, statusTimeoutCmd(3 * time.Second)
+		}
+		row, _, val := a.tableview.StartEdit(a.formatter())
 		if row >= 0 {
 			a.inputFocused = true
 			a.updateHints()
@@ -756,11 +1499,63 @@ func (a App) handleEnter() (tea.Model, tea.Cmd) {
 	return a, nil
 }
 
+// toggleDatabases switches the selected connection between listing its
+// databases and listing the tables of the database in use.
+func (a App) toggleDatabases() (tea.Model, tea.Cmd) {
+	connName, _, _, _ := a.sidebar.SelectedItem()
+	if connName == "" || !a.mgr.IsConnected(connName) {
+		return a, nil
+	}
+
+	a.loading = true
+	if a.sidebar.HasDatabases(connName) {
+		a.sidebar.CollapseConnection(connName)
+		a.statusbar.SetLoading(true, "Loading tables...")
+		return a, loadTablesCmd(a.mgr, connName)
+	}
+	a.statusbar.SetLoading(true, "Loading databases...")
+	return a, loadDatabasesCmd(a.mgr, connName)
+}
+
+// selectDatabase opens a database node, switching to it, or closes it when
+// it is the open one.
+func (a App) selectDatabase(connName, database string) (tea.Model, tea.Cmd) {
+	if database == a.mgr.Database(connName) && a.sidebar.IsExpanded() {
+		a.sidebar.CollapseDatabase(connName, database)
+		a.updateHints()
+		return a, nil
+	}
+
+	a.loading = true
+	a.statusbar.SetLoading(true, "Opening "+database+"...")
+	return a, useDatabaseCmd(a.mgr, connName, database)
+}
+
+// switchedDatabase updates the UI after connName moved to another database.
+// Rows of connName on screen are dropped: edits to them would otherwise go
+// to the table of the same name in the new database.
+func (a *App) switchedDatabase(connName string) {
+	if a.tableview.ConnName() == connName {
+		a.tableview.Clear()
+		a.pasteRecords = nil
+		if a.panel == PanelTable {
+			a.panel = PanelSidebar
+		}
+	}
+	a.activeConn = connName
+	a.sidebar.SetCurrentDatabase(connName, a.mgr.Database(connName))
+	a.setStatusContext(connName, "")
+}
+
 func (a App) handleDeleteRow() (tea.Model, tea.Cmd) {
 	if a.panel != PanelTable || !a.tableview.HasData() {
 		return a, nil
 	}
 
+	if a.tableview.HasSelection() {
+		return a.handleBulkDelete()
+	}
+
 	connName := a.tableview.ConnName()
 	schema := a.tableview.Schema()
 	tableName := a.tableview.TableName()
@@ -769,19 +1564,18 @@ func (a App) handleDeleteRow() (tea.Model, tea.Cmd) {
 		return a, statusTimeoutCmd(3 * time.Second)
 	}
 
-	if connCfg, ok := a.mgr.ConnectionConfig(connName); ok && connCfg.ReadOnly {
+	if connCfg, ok := a.mgr.ConnectionConfig(connName); ok && connCfg.IsReadOnly() {
 		a.statusbar.SetMessage("Connection is read-only", true)
 		return a, statusTimeoutCmd(3 * time.Second)
 	}
 
-	row := a.tableview.SelectedRow()
+	row := a.tableview.SelectedValues()
 	if row == nil {
 		return a, nil
 	}
 
 	columns := a.tableview.Columns()
-	cacheKey := schema + "." + tableName
-	pkCols := a.pkCache[cacheKey]
+	pkCols := a.pkCache[a.cacheKey(connName, schema, tableName)]
 
 	if a.cfg.Settings.ConfirmDestructive {
 		a.confirming = true
@@ -789,12 +1583,12 @@ func (a App) handleDeleteRow() (tea.Model, tea.Cmd) {
 		a.statusbar.SetMessage(a.confirmText, true)
 		a.updateHints()
 		a.onConfirm = func() tea.Cmd {
-			return deleteRowCmd(a.mgr, connName, schema, tableName, columns, pkCols, row, a.cfg.Settings.NullDisplay)
+			return deleteRowCmd(a.mgr, connName, schema, tableName, columns, pkCols, row)
 		}
 		return a, nil
 	}
 
-	return a, deleteRowCmd(a.mgr, connName, schema, tableName, columns, pkCols, row, a.cfg.Settings.NullDisplay)
+	return a, deleteRowCmd(a.mgr, connName, schema, tableName, columns, pkCols, row)
 }
 
 func (a App) handleInsertRow() (tea.Model, tea.Cmd) {
@@ -810,12 +1604,12 @@ func (a App) handleInsertRow() (tea.Model, tea.Cmd) {
 	}
 
 	connName := a.tableview.ConnName()
-	if connCfg, ok := a.mgr.ConnectionConfig(connName); ok && connCfg.ReadOnly {
+	if connCfg, ok := a.mgr.ConnectionConfig(connName); ok && connCfg.IsReadOnly() {
 		a.statusbar.SetMessage("Connection is read-only", true)
 		return a, statusTimeoutCmd(3 * time.Second)
 	}
 
-	a.tableview.StartInsert()
+	a.tableview.StartInsert(a.colCache[a.cacheKey(connName, schema, tableName)])
 	a.inputFocused = true
 	a.updateHints()
 	return a, nil
@@ -825,25 +1619,46 @@ func (a App) submitInsertRow() (tea.Model, tea.Cmd) {
 	connName := a.tableview.ConnName()
 	schema := a.tableview.Schema()
 	tableName := a.tableview.TableName()
-	columns := a.tableview.Columns()
+	columns := a.tableview.InsertColumns()
 	values := a.tableview.InsertValues()
 
-	return a, insertRowCmd(a.mgr, connName, schema, tableName, columns, values, a.cfg.Settings.NullDisplay)
+	if idx, err := a.tableview.ValidateInsert(); err != nil {
+		a.tableview.FocusInsertCol(idx)
+		a.statusbar.SetMessage("Invalid row: "+err.Error(), true)
+		return a, statusTimeoutCmd(3 * time.Second)
+	}
+
+	return a, insertRowCmd(a.mgr, connName, schema, tableName, columns, values, a.formatter())
 }
 
-func (a App) saveCellEdit() (tea.Model, tea.Cmd) {
+func (a App) saveCellEdit(newValue db.CellValue) (tea.Model, tea.Cmd) {
+	// The connection may have been edited to read-only since the edit began
+	if reason := a.readOnlyReason(); reason != "" {
+		a.statusbar.SetMessage(reason, true)
+		return a, statusTimeoutCmd(3 * time.Second)
+	}
+
 	connName := a.tableview.ConnName()
 	schema := a.tableview.Schema()
 	tableName := a.tableview.TableName()
 	columns := a.tableview.Columns()
 	colIdx := a.tableview.EditingCol()
-	newValue := a.tableview.EditValue()
-	row := a.tableview.SelectedRow()
+	row := a.tableview.SelectedValues()
+
+	if newValue.Kind == db.CellText {
+		if err := db.ValidateValue(a.tableview.ColumnType(colIdx), newValue.Text); err != nil {
+			a.statusbar.SetMessage("Invalid value: "+err.Error(), true)
+			return a, statusTimeoutCmd(3 * time.Second)
+		}
+	}
+
+	if a.tableview.IsBulkEdit() {
+		return a.saveBulkEdit(newValue)
+	}
 
-	cacheKey := schema + "." + tableName
-	pkCols := a.pkCache[cacheKey]
+	pkCols := a.pkCache[a.cacheKey(connName, schema, tableName)]
 
-	return a, updateCellCmd(a.mgr, connName, schema, tableName, columns, pkCols, row, colIdx, newValue, a.cfg.Settings.NullDisplay)
+	return a, updateCellCmd(a.mgr, connName, schema, tableName, columns, pkCols, row, colIdx, newValue)
 }
 
 func (a App) executeQuery() (tea.Model, tea.Cmd) {
@@ -857,9 +1672,48 @@ func (a App) executeQuery() (tea.Model, tea.Cmd) {
 	}
 
 	a.editor.AddToHistory(query)
+
+	if conn, ok := a.mgr.ConnectionConfig(a.activeConn); ok && conn.IsProduction() && db.IsWriteQuery(query) {
+		return a.confirmProdQuery(conn.Name, query)
+	}
+
+	return a.runQuery(query)
+}
+
+func (a App) runQuery(query string) (tea.Model, tea.Cmd) {
 	a.loading = true
 	a.statusbar.SetLoading(true, "Executing query...")
-	return a, execQueryCmd(a.mgr, a.activeConn, query, a.cfg.Settings.NullDisplay)
+	return a, execQueryCmd(a.mgr, a.activeConn, query, a.formatter())
+}
+
+// confirmProdQuery makes the user type the connection name, or the table the
+// statement targets, before running DML/DDL on production.
+func (a App) confirmProdQuery(connName, query string) (tea.Model, tea.Cmd) {
+	target := db.StatementTarget(query)
+	label := fmt.Sprintf("PROD write: type %q to run", connName)
+	if target != "" {
+		label = fmt.Sprintf("PROD write: type %q or %q to run", target, connName)
+	}
+
+	a.startPrompt(label, false, func(a App, typed string) (tea.Model, tea.Cmd) {
+		typed = strings.TrimSpace(typed)
+		if typed != connName && (target == "" || typed != target) {
+			a.statusbar.SetMessage("Name did not match, query not run", true)
+			return a, statusTimeoutCmd(3 * time.Second)
+		}
+		return a.runQuery(query)
+	})
+	return a, nil
+}
+
+// tableKey identifies a table in pkCache and colCache.
+type tableKey struct {
+	conn, database, schema, table string
+}
+
+// cacheKey is the tableKey of schema.table in the database connName is working against.
+func (a App) cacheKey(connName, schema, table string) tableKey {
+	return tableKey{conn: connName, database: a.mgr.Database(connName), schema: schema, table: table}
 }
 
 func (a *App) reloadTableData() tea.Cmd {
@@ -871,17 +1725,82 @@ func (a *App) reloadTableData() tea.Cmd {
 	}
 	offset := a.tableview.Page() * a.tableview.PageSize()
 	return loadTableDataCmd(a.mgr, connName, schema, tableName,
-		a.cfg.Settings.DefaultLimit, offset, a.cfg.Settings.NullDisplay)
+		a.cfg.Settings.DefaultLimit, offset, a.formatter())
+}
+
+// setStatusContext shows conn and table in the status bar, with the
+// connection's TLS state and environment.
+func (a *App) setStatusContext(conn, table string) {
+	label := conn
+	if database := a.mgr.Database(conn); database != "" {
+		label += "/" + database
+	}
+	a.statusbar.SetContext(label, table)
+	a.statusbar.SetTLS(a.connTLS[conn])
+	a.statusbar.SetHealth(shared.HealthBadge(a.health[conn]))
+
+	var env string
+	if c, ok := a.mgr.ConnectionConfig(conn); ok {
+		env = c.Environment
+	}
+	a.statusbar.SetEnvBadge(shared.EnvBadge(env, a.cfg.Settings.EnvironmentColors))
+}
+
+// panelStyle tints a panel border with the active connection's environment color.
+func (a App) panelStyle(s lipgloss.Style) lipgloss.Style {
+	c, ok := a.mgr.ConnectionConfig(a.activeConn)
+	if !ok {
+		return s
+	}
+	if color, ok := shared.EnvColor(c.Environment, a.cfg.Settings.EnvironmentColors); ok {
+		return s.BorderForeground(color)
+	}
+	return s
+}
+
+func (a App) formatter() db.Formatter {
+	return db.NewFormatter(a.cfg.Settings)
 }
 
 func (a *App) updateHints() {
 	var hints []keyhints.Hint
 
+	if a.prompting {
+		a.statusbar.SetHints([]keyhints.Hint{
+			{Key: "enter", Desc: "submit"},
+			{Key: "esc", Desc: "cancel"},
+		})
+		return
+	}
+
+	if a.picking {
+		a.statusbar.SetHints([]keyhints.Hint{
+			{Key: "up/down", Desc: "navigate"},
+			{Key: "enter", Desc: "choose"},
+			{Key: "esc", Desc: "cancel"},
+		})
+		return
+	}
+
+	if a.granting {
+		a.statusbar.SetHints([]keyhints.Hint{
+			{Key: "hjkl", Desc: "move"},
+			{Key: "space", Desc: "toggle"},
+			{Key: "a", Desc: "toggle row"},
+			{Key: "enter", Desc: "write SQL"},
+			{Key: "esc", Desc: "cancel"},
+		})
+		return
+	}
+
 	if a.confirming {
 		hints = []keyhints.Hint{
 			{Key: "y", Desc: "confirm"},
 			{Key: "n", Desc: "cancel"},
 		}
+		if a.pasteRecords != nil {
+			hints = append(hints, keyhints.Hint{Key: "m", Desc: "column mapping"})
+		}
 		a.statusbar.SetHints(hints)
 		return
 	}
@@ -891,6 +1810,8 @@ func (a *App) updateHints() {
 		hints = a.homescreen.Hints()
 	case ScreenBrowse:
 		hints = a.browseHints()
+	case ScreenAdmin:
+		hints = a.adminHints()
 	}
 
 	a.statusbar.SetHints(hints)
@@ -907,7 +1828,10 @@ func (a App) browseHints() []keyhints.Hint {
 		if a.tableview.IsEditing() {
 			return []keyhints.Hint{
 				{Key: "enter", Desc: "save"},
+				{Key: "alt+enter", Desc: "newline"},
 				{Key: "tab", Desc: "next col"},
+				{Key: "ctrl+n", Desc: "set NULL"},
+				{Key: "ctrl+d", Desc: "set DEFAULT"},
 				{Key: "esc", Desc: "cancel"},
 			}
 		}
@@ -915,6 +1839,9 @@ func (a App) browseHints() []keyhints.Hint {
 			return []keyhints.Hint{
 				{Key: "enter", Desc: "next/save"},
 				{Key: "tab", Desc: "next col"},
+				{Key: "ctrl+d", Desc: "DEFAULT"},
+				{Key: "ctrl+n", Desc: "NULL"},
+				{Key: "ctrl+s", Desc: "save"},
 				{Key: "esc", Desc: "cancel"},
 			}
 		}
@@ -935,14 +1862,31 @@ func (a App) browseHints() []keyhints.Hint {
 		hints = append(hints,
 			keyhints.Hint{Key: "enter", Desc: "select"},
 			keyhints.Hint{Key: "/", Desc: "filter"},
+			keyhints.Hint{Key: "D", Desc: "databases"},
+			keyhints.Hint{Key: "R", Desc: "role"},
+			keyhints.Hint{Key: "S", Desc: "search_path"},
+			keyhints.Hint{Key: "m", Desc: "maintenance"},
+			keyhints.Hint{Key: "a", Desc: "access"},
+			keyhints.Hint{Key: "L", Desc: "policies"},
+			keyhints.Hint{Key: "M", Desc: "grants"},
 		)
 	case PanelTable:
-		if a.tableview.HasData() {
+		if a.tableview.HasSelection() {
+			hints = append(hints,
+				keyhints.Hint{Key: "d", Desc: "delete selected"},
+				keyhints.Hint{Key: "c", Desc: "duplicate"},
+				keyhints.Hint{Key: "u", Desc: "set column"},
+				keyhints.Hint{Key: "esc", Desc: "clear selection"},
+			)
+		} else if a.tableview.HasData() {
 			hints = append(hints,
 				keyhints.Hint{Key: "enter", Desc: "edit cell"},
-				keyhints.Hint{Key: "h/l", Desc: "scroll cols"},
+				keyhints.Hint{Key: "h/l", Desc: "column"},
 				keyhints.Hint{Key: "d", Desc: "delete"},
 				keyhints.Hint{Key: "o", Desc: "insert"},
+				keyhints.Hint{Key: "r", Desc: "reload"},
+				keyhints.Hint{Key: "space/v", Desc: "select"},
+				keyhints.Hint{Key: "P", Desc: "paste rows"},
 				keyhints.Hint{Key: "n/p", Desc: "page"},
 			)
 		}
@@ -954,6 +1898,7 @@ func (a App) browseHints() []keyhints.Hint {
 
 	hints = append(hints,
 		keyhints.Hint{Key: "e", Desc: "editor"},
+		keyhints.Hint{Key: "A", Desc: "admin"},
 		keyhints.Hint{Key: "q", Desc: "home"},
 	)
 
@@ -1002,6 +1947,10 @@ func (a *App) layoutResize() {
 		}
 
 		a.statusbar.SetSize(a.width)
+
+	case ScreenAdmin:
+		a.admin.SetSize(a.adminSize())
+		a.statusbar.SetSize(a.width)
 	}
 }
 
@@ -1015,6 +1964,8 @@ func (a App) View() string {
 		return a.homeView()
 	case ScreenBrowse:
 		return a.browseView()
+	case ScreenAdmin:
+		return a.adminScreenView()
 	}
 
 	return ""
@@ -1026,6 +1977,21 @@ func (a App) homeView() string {
 	return lipgloss.JoinVertical(lipgloss.Left, content, status)
 }
 
+// tableContent renders the table view, or the SQL awaiting confirmation.
+func (a App) tableContent() string {
+	if a.picking {
+		return a.picker.View()
+	}
+	if a.granting {
+		return a.grants.View()
+	}
+	if a.confirming && a.confirmPreview != "" {
+		title := lipgloss.NewStyle().Bold(true).Foreground(ColorWarning).Render(a.confirmText)
+		return title + "\n\n" + lipgloss.NewStyle().Foreground(ColorFg).Render(a.confirmPreview)
+	}
+	return a.tableview.View(a.panel == PanelTable)
+}
+
 func (a App) browseView() string {
 	frameH := StyleSidebarActive.GetHorizontalFrameSize()
 	frameV := StyleSidebarActive.GetVerticalFrameSize()
@@ -1038,9 +2004,9 @@ func (a App) browseView() string {
 
 	var sideStyle lipgloss.Style
 	if a.panel == PanelSidebar {
-		sideStyle = StyleSidebarActive.Width(sideW).MaxWidth(sideW).Height(sideContentH).MaxHeight(sideContentH)
+		sideStyle = a.panelStyle(StyleSidebarActive).Width(sideW).MaxWidth(sideW).Height(sideContentH).MaxHeight(sideContentH)
 	} else {
-		sideStyle = StyleSidebarInactive.Width(sideW).MaxWidth(sideW).Height(sideContentH).MaxHeight(sideContentH)
+		sideStyle = a.panelStyle(StyleSidebarInactive).Width(sideW).MaxWidth(sideW).Height(sideContentH).MaxHeight(sideContentH)
 	}
 	sideView := sideStyle.Render(a.sidebar.View(a.panel == PanelSidebar))
 
@@ -1056,19 +2022,19 @@ func (a App) browseView() string {
 
 		var tableStyle lipgloss.Style
 		if a.panel == PanelTable {
-			tableStyle = StyleMainActive.Width(mainW).MaxWidth(mainW).Height(tableContentH).MaxHeight(tableContentH)
+			tableStyle = a.panelStyle(StyleMainActive).Width(mainW).MaxWidth(mainW).Height(tableContentH).MaxHeight(tableContentH)
 		} else {
-			tableStyle = StyleMainInactive.Width(mainW).MaxWidth(mainW).Height(tableContentH).MaxHeight(tableContentH)
+			tableStyle = a.panelStyle(StyleMainInactive).Width(mainW).MaxWidth(mainW).Height(tableContentH).MaxHeight(tableContentH)
 		}
 
 		var edStyle lipgloss.Style
 		if a.panel == PanelEditor {
-			edStyle = StyleEditorActive.Width(mainW).MaxWidth(mainW).Height(editorContentH).MaxHeight(editorContentH)
+			edStyle = a.panelStyle(StyleEditorActive).Width(mainW).MaxWidth(mainW).Height(editorContentH).MaxHeight(editorContentH)
 		} else {
-			edStyle = StyleEditorInactive.Width(mainW).MaxWidth(mainW).Height(editorContentH).MaxHeight(editorContentH)
+			edStyle = a.panelStyle(StyleEditorInactive).Width(mainW).MaxWidth(mainW).Height(editorContentH).MaxHeight(editorContentH)
 		}
 
-		tableSection := tableStyle.Render(a.tableview.View(a.panel == PanelTable))
+		tableSection := tableStyle.Render(a.tableContent())
 		editorSection := edStyle.Render(a.editor.View())
 		mainView = lipgloss.JoinVertical(lipgloss.Left, tableSection, editorSection)
 	} else {
@@ -1076,11 +2042,11 @@ func (a App) browseView() string {
 
 		var tableStyle lipgloss.Style
 		if a.panel == PanelTable {
-			tableStyle = StyleMainActive.Width(mainW).MaxWidth(mainW).Height(tableContentH).MaxHeight(tableContentH)
+			tableStyle = a.panelStyle(StyleMainActive).Width(mainW).MaxWidth(mainW).Height(tableContentH).MaxHeight(tableContentH)
 		} else {
-			tableStyle = StyleMainInactive.Width(mainW).MaxWidth(mainW).Height(tableContentH).MaxHeight(tableContentH)
+			tableStyle = a.panelStyle(StyleMainInactive).Width(mainW).MaxWidth(mainW).Height(tableContentH).MaxHeight(tableContentH)
 		}
-		mainView = tableStyle.Render(a.tableview.View(a.panel == PanelTable))
+		mainView = tableStyle.Render(a.tableContent())
 	}
 
 	content := lipgloss.JoinHorizontal(lipgloss.Top, sideView, mainView)
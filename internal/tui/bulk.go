@@ -0,0 +1,145 @@
+package tui
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/zaffron/ezpg/internal/db"
+)
+
+// maxPreviewStatements caps how much generated SQL a bulk confirmation shows.
+const maxPreviewStatements = 50
+
+// readOnlyReason explains why the table in the table view can't be modified, or "" if it can.
+func (a App) readOnlyReason() string {
+	schema := a.tableview.Schema()
+	tableName := a.tableview.TableName()
+	if schema == "" || tableName == "" || tableName == "query result" {
+		return "Cannot modify query results"
+	}
+
+	if connCfg, ok := a.mgr.ConnectionConfig(a.tableview.ConnName()); ok && connCfg.IsReadOnly() {
+		return "Connection is read-only"
+	}
+
+	return ""
+}
+
+// confirmBulk always asks before running a bulk action, showing the generated SQL.
+func (a App) confirmBulk(verb, done string, stmts []db.Statement) (tea.Model, tea.Cmd) {
+	connName := a.tableview.ConnName()
+
+	a.confirming = true
+	a.confirmText = fmt.Sprintf("%s %d rows in one transaction? (y/n)", verb, len(stmts))
+	a.confirmPreview = db.PreviewStatements(stmts, maxPreviewStatements)
+	a.statusbar.SetMessage(a.confirmText, true)
+	a.updateHints()
+	a.onConfirm = func() tea.Cmd {
+		return bulkCmd(a.mgr, connName, done, stmts)
+	}
+	return a, nil
+}
+
+func (a App) handleBulkDelete() (tea.Model, tea.Cmd) {
+	if reason := a.readOnlyReason(); reason != "" {
+		a.statusbar.SetMessage(reason, true)
+		return a, statusTimeoutCmd(3 * time.Second)
+	}
+
+	schema := a.tableview.Schema()
+	tableName := a.tableview.TableName()
+	columns := a.tableview.Columns()
+	pkCols := a.pkCache[a.cacheKey(a.tableview.ConnName(), schema, tableName)]
+
+	var stmts []db.Statement
+	for _, i := range a.tableview.SelectedIndices() {
+		stmts = append(stmts, db.DeleteRowStatement(schema, tableName, columns, pkCols, a.tableview.ValuesAt(i)))
+	}
+
+	return a.confirmBulk("Delete", "Deleted", stmts)
+}
+
+// handleDuplicateRows re-inserts the selected rows, leaving serial, identity,
+// generated and primary key columns to their defaults so new keys are assigned.
+func (a App) handleDuplicateRows() (tea.Model, tea.Cmd) {
+	if reason := a.readOnlyReason(); reason != "" {
+		a.statusbar.SetMessage(reason, true)
+		return a, statusTimeoutCmd(3 * time.Second)
+	}
+
+	connName := a.tableview.ConnName()
+	schema := a.tableview.Schema()
+	tableName := a.tableview.TableName()
+	columns := a.tableview.Columns()
+	f := a.formatter()
+
+	// Without column info we can't tell which values must be regenerated
+	cols, ok := a.colCache[a.cacheKey(connName, schema, tableName)]
+	if !ok {
+		a.statusbar.SetMessage("Loading column info, try again in a moment", true)
+		return a, tea.Batch(
+			loadColumnsCmd(a.mgr, connName, schema, tableName),
+			statusTimeoutCmd(3*time.Second),
+		)
+	}
+
+	// Primary keys must be new, so every key column needs a default
+	generated := make(map[string]bool)
+	for _, c := range cols {
+		if c.IsSerial() || c.Identity != "" || c.IsGenerated || (c.IsPrimary && c.HasDefault()) {
+			generated[c.Name] = true
+		} else if c.IsPrimary {
+			a.statusbar.SetMessage("Cannot duplicate: primary key column "+c.Name+" has no default", true)
+			return a, statusTimeoutCmd(3 * time.Second)
+		}
+	}
+
+	var stmts []db.Statement
+	for _, i := range a.tableview.SelectedIndices() {
+		row := a.tableview.ValuesAt(i)
+		values := make([]db.CellValue, len(columns))
+		for j, col := range columns {
+			switch {
+			case generated[col]:
+				values[j] = db.CellValue{Kind: db.CellDefault}
+			case row[j] == nil:
+				values[j] = db.CellValue{Kind: db.CellNull}
+			default:
+				values[j] = db.CellValue{Kind: db.CellText, Text: f.EditText(row[j], a.tableview.ColumnType(j))}
+			}
+		}
+		stmts = append(stmts, db.InsertRowStatement(schema, tableName, columns, values))
+	}
+
+	return a.confirmBulk("Duplicate", "Duplicated", stmts)
+}
+
+func (a App) handleBulkUpdate() (tea.Model, tea.Cmd) {
+	if reason := a.readOnlyReason(); reason != "" {
+		a.statusbar.SetMessage(reason, true)
+		return a, statusTimeoutCmd(3 * time.Second)
+	}
+
+	if a.tableview.StartBulkEdit(a.formatter()) {
+		a.inputFocused = true
+		a.updateHints()
+	}
+	return a, nil
+}
+
+// saveBulkEdit sets the edited column to newValue on every selected row.
+func (a App) saveBulkEdit(newValue db.CellValue) (tea.Model, tea.Cmd) {
+	schema := a.tableview.Schema()
+	tableName := a.tableview.TableName()
+	columns := a.tableview.Columns()
+	colIdx := a.tableview.EditingCol()
+	pkCols := a.pkCache[a.cacheKey(a.tableview.ConnName(), schema, tableName)]
+
+	var stmts []db.Statement
+	for _, i := range a.tableview.SelectedIndices() {
+		stmts = append(stmts, db.UpdateCellStatement(schema, tableName, columns, pkCols, a.tableview.ValuesAt(i), colIdx, newValue))
+	}
+
+	return a.confirmBulk("Update", "Updated", stmts)
+}
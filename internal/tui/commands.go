@@ -2,10 +2,10 @@ package tui
 
 import (
 	"context"
-	"fmt"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/zaffron/ezpg/internal/config"
 	"github.com/zaffron/ezpg/internal/db"
 )
 
@@ -13,8 +13,21 @@ func connectCmd(mgr *db.Manager, name string) tea.Cmd {
 	return func() tea.Msg {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
-		err := mgr.Connect(ctx, name)
-		return ConnectMsg{Name: name, Err: err}
+		if err := mgr.Connect(ctx, name); err != nil {
+			return ConnectMsg{Name: name, Err: err}
+		}
+		tlsInfo, _ := mgr.TLSSummary(ctx, name)
+		return ConnectMsg{Name: name, TLS: tlsInfo}
+	}
+}
+
+// testConnectionCmd tries conn with a short timeout, without keeping the connection.
+func testConnectionCmd(mgr *db.Manager, conn config.Connection) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		diag, err := mgr.TestConnection(ctx, conn)
+		return ConnectionTestedMsg{Name: conn.Name, Diag: diag, Err: err}
 	}
 }
 
@@ -25,180 +38,474 @@ func connectCmd(mgr *db.Manager, name string) tea.Cmd {
 // 	}
 // }
 
+// saveStateCmd writes a copy of st, taken now. The history is only a
+// convenience, so failing to write it is not reported.
+func saveStateCmd(st *config.State) tea.Cmd {
+	snapshot := st.Clone()
+	return func() tea.Msg {
+		_ = snapshot.Save()
+		return nil
+	}
+}
+
+// saveConfigCmd writes a copy of cfg, taken now, after a change to the
+// named connection.
+func saveConfigCmd(cfg *config.Config, name string) tea.Cmd {
+	snapshot := cfg.Clone()
+	return func() tea.Msg {
+		err := snapshot.Save()
+		return ConnectionSavedMsg{Name: name, Err: err}
+	}
+}
+
+// deleteConfigCmd is saveConfigCmd after the named connection was deleted.
+func deleteConfigCmd(cfg *config.Config, name string) tea.Cmd {
+	snapshot := cfg.Clone()
+	return func() tea.Msg {
+		err := snapshot.Save()
+		return ConnectionDeletedMsg{Name: name, Err: err}
+	}
+}
+
 func loadTablesCmd(mgr *db.Manager, connName string) tea.Cmd {
+	database := mgr.Database(connName)
 	return func() tea.Msg {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 		tables, err := mgr.ListTables(ctx, connName)
-		return TablesLoadedMsg{ConnName: connName, Tables: tables, Err: err}
+		return TablesLoadedMsg{ConnName: connName, Database: database, Tables: tables, Err: err}
+	}
+}
+
+func loadDatabasesCmd(mgr *db.Manager, connName string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		databases, err := mgr.ListDatabases(ctx, connName)
+		return DatabasesLoadedMsg{ConnName: connName, Databases: databases, Err: err}
+	}
+}
+
+func loadRolesCmd(mgr *db.Manager, connName string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		roles, current, err := mgr.ListRoles(ctx, connName)
+		return RolesLoadedMsg{ConnName: connName, Roles: roles, Current: current, Err: err}
+	}
+}
+
+func loadRoleInfoCmd(mgr *db.Manager, connName string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		roles, err := mgr.ListRoleInfo(ctx, connName)
+		return RoleInfoLoadedMsg{ConnName: connName, Roles: roles, Err: err}
+	}
+}
+
+// loadRoleAccessCmd loads the tables role can do anything with.
+func loadRoleAccessCmd(mgr *db.Manager, connName, role string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		access, err := mgr.RoleAccess(ctx, connName, role)
+		return AccessLoadedMsg{ConnName: connName, Role: role, Access: access, Err: err}
+	}
+}
+
+// loadTableAccessCmd loads the roles that can do anything with a table.
+func loadTableAccessCmd(mgr *db.Manager, connName, schema, table string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		access, err := mgr.TableAccess(ctx, connName, schema, table)
+		return AccessLoadedMsg{ConnName: connName, Schema: schema, Table: table, Access: access, Err: err}
+	}
+}
+
+func loadRowSecurityCmd(mgr *db.Manager, connName, schema, table string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		rs, err := mgr.RowSecurity(ctx, connName, schema, table)
+		return RowSecurityLoadedMsg{ConnName: connName, Schema: schema, Table: table, RowSecurity: rs, Err: err}
+	}
+}
+
+func loadGrantsCmd(mgr *db.Manager, connName, schema, table string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		grants, err := mgr.TableGrants(ctx, connName, schema, table)
+		return GrantsLoadedMsg{ConnName: connName, Schema: schema, Table: table, Grants: grants, Err: err}
+	}
+}
+
+func loadSchemasCmd(mgr *db.Manager, connName string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		schemas, searchPath, err := mgr.ListSchemas(ctx, connName)
+		return SchemasLoadedMsg{ConnName: connName, Schemas: schemas, SearchPath: searchPath, Err: err}
+	}
+}
+
+// useDatabaseCmd switches connName to database, connecting to it if needed.
+func useDatabaseCmd(mgr *db.Manager, connName, database string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		err := mgr.UseDatabase(ctx, connName, database)
+		return DatabaseSwitchedMsg{ConnName: connName, Database: database, Err: err}
 	}
 }
 
 func loadColumnsCmd(mgr *db.Manager, connName, schema, table string) tea.Cmd {
+	database := mgr.Database(connName)
 	return func() tea.Msg {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 		cols, err := mgr.ListColumns(ctx, connName, schema, table)
-		return ColumnsLoadedMsg{ConnName: connName, Schema: schema, Table: table, Columns: cols, Err: err}
+		return ColumnsLoadedMsg{ConnName: connName, Database: database, Schema: schema, Table: table, Columns: cols, Err: err}
 	}
 }
 
-func loadTableDataCmd(mgr *db.Manager, connName, schema, table string, limit, offset int, nullDisplay string) tea.Cmd {
+func loadTableDataCmd(mgr *db.Manager, connName, schema, table string, limit, offset int, f db.Formatter) tea.Cmd {
+	database := mgr.Database(connName)
 	return func() tea.Msg {
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
-		result, err := mgr.QueryTableData(ctx, connName, schema, table, limit, offset, nullDisplay)
-		return TableDataMsg{ConnName: connName, Schema: schema, Table: table, Result: result, Err: err}
+		result, err := mgr.QueryTableData(ctx, connName, schema, table, limit, offset, f)
+		return TableDataMsg{ConnName: connName, Database: database, Schema: schema, Table: table, Result: result, Err: err}
 	}
 }
 
-func execQueryCmd(mgr *db.Manager, connName, query, nullDisplay string) tea.Cmd {
+func execQueryCmd(mgr *db.Manager, connName, query string, f db.Formatter) tea.Cmd {
 	return func() tea.Msg {
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
-		result, err := mgr.ExecQuery(ctx, connName, query, nullDisplay)
-		return QueryResultMsg{Result: result, Err: err}
+		result, err := mgr.ExecQuery(ctx, connName, query, f)
+		return QueryResultMsg{ConnName: connName, Query: query, Result: result, Err: err}
+	}
+}
+
+// retryQueryCmd reconnects if the connection is still down, then runs query again.
+func retryQueryCmd(mgr *db.Manager, connName, query string, f db.Formatter) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if health, _, _ := mgr.CheckHealth(ctx, connName); health == db.HealthLost {
+			if err := mgr.Reconnect(ctx, connName); err != nil {
+				return QueryResultMsg{ConnName: connName, Query: query, Err: err}
+			}
+		}
+		result, err := mgr.ExecQuery(ctx, connName, query, f)
+		return QueryResultMsg{ConnName: connName, Query: query, Result: result, Err: err}
+	}
+}
+
+const (
+	healthCheckInterval = 15 * time.Second
+	maxReconnectDelay   = 30 * time.Second
+)
+
+func healthTickCmd() tea.Cmd {
+	return tea.Tick(healthCheckInterval, func(time.Time) tea.Msg {
+		return HealthTickMsg{}
+	})
+}
+
+func checkHealthCmd(mgr *db.Manager, name string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		health, latency, err := mgr.CheckHealth(ctx, name)
+		return HealthMsg{Name: name, Health: health, Latency: latency, Err: err}
 	}
 }
 
-func deleteRowCmd(mgr *db.Manager, connName, schema, table string, columns []string, pkCols []string, rowValues []string, nullDisplay string) tea.Cmd {
+// reconnectCmd waits out an exponential backoff, then tries to reconnect.
+func reconnectCmd(mgr *db.Manager, name string, attempt int) tea.Cmd {
+	delay := min(time.Second<<min(attempt, 5), maxReconnectDelay)
+	return tea.Tick(delay, func(time.Time) tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		err := mgr.Reconnect(ctx, name)
+		return ReconnectMsg{Name: name, Attempt: attempt, Err: err}
+	})
+}
+
+// adminTickCmd asks for the next refresh of the admin screen opened as seq.
+func adminTickCmd(seq int, every time.Duration) tea.Cmd {
+	return tea.Tick(every, func(time.Time) tea.Msg {
+		return AdminTickMsg{Seq: seq}
+	})
+}
+
+func loadActivityCmd(mgr *db.Manager, connName string) tea.Cmd {
 	return func() tea.Msg {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
+		activity, err := mgr.ListActivity(ctx, connName)
+		return ActivityLoadedMsg{ConnName: connName, Activity: activity, Err: err}
+	}
+}
 
-		// Build WHERE clause from primary keys or all columns
-		whereCols := pkCols
-		if len(whereCols) == 0 {
-			whereCols = columns
+func loadBlockingCmd(mgr *db.Manager, connName string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		procs, err := mgr.ListBlocking(ctx, connName)
+		return BlockingLoadedMsg{ConnName: connName, Processes: procs, Err: err}
+	}
+}
+
+func loadStatementsCmd(mgr *db.Manager, connName string, limit int) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		stmts, err := mgr.ListStatements(ctx, connName, limit)
+		return StatementsLoadedMsg{ConnName: connName, Statements: stmts, Err: err}
+	}
+}
+
+func resetStatementsCmd(mgr *db.Manager, connName string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		return StatementsResetMsg{Err: mgr.ResetStatements(ctx, connName)}
+	}
+}
+
+func loadSizesCmd(mgr *db.Manager, connName string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		tables, err := mgr.ListTableSizes(ctx, connName)
+		if err != nil {
+			return SizesLoadedMsg{ConnName: connName, Err: err}
 		}
+		indexes, err := mgr.ListIndexSizes(ctx, connName)
+		return SizesLoadedMsg{ConnName: connName, Tables: tables, Indexes: indexes, Err: err}
+	}
+}
 
-		where := ""
-		args := []any{}
-		argIdx := 1
-		for _, col := range whereCols {
-			for i, c := range columns {
-				if c == col {
-					if where != "" {
-						where += " AND "
-					}
-					if rowValues[i] == nullDisplay {
-						where += fmt.Sprintf("%q IS NULL", col)
-					} else {
-						where += fmt.Sprintf("%q = $%d", col, argIdx)
-						args = append(args, rowValues[i])
-						argIdx++
-					}
-					break
-				}
-			}
+func loadServerInfoCmd(mgr *db.Manager, connName string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		info, err := mgr.ServerInfo(ctx, connName)
+		if err != nil {
+			return ServerInfoLoadedMsg{ConnName: connName, Err: err}
 		}
+		extensions, err := mgr.ListExtensions(ctx, connName)
+		return ServerInfoLoadedMsg{ConnName: connName, Info: info, Extensions: extensions, Err: err}
+	}
+}
+
+func loadSettingsCmd(mgr *db.Manager, connName string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		settings, err := mgr.ListSettings(ctx, connName)
+		return SettingsLoadedMsg{ConnName: connName, Settings: settings, Err: err}
+	}
+}
+
+func loadReplicationCmd(mgr *db.Manager, connName string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		status, err := mgr.ReplicationStatus(ctx, connName)
+		return ReplicationLoadedMsg{ConnName: connName, Status: status, Err: err}
+	}
+}
 
-		query := fmt.Sprintf(
-			`DELETE FROM %q.%q WHERE ctid = (SELECT ctid FROM %q.%q WHERE %s LIMIT 1)`,
-			schema, table, schema, table, where,
+// runMaintenanceCmd runs mt until done or ctx is cancelled. Its start, notices
+// and end go to events, which waitMaintenanceCmd hands on one at a time, so
+// they arrive in the order the server sent them.
+func runMaintenanceCmd(ctx context.Context, mgr *db.Manager, connName string, mt db.Maintenance, events chan<- tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		err := mgr.RunMaintenance(ctx, connName, mt,
+			func(pid int32) { events <- MaintenanceStartedMsg{PID: pid} },
+			func(n db.Notice) { events <- MaintenanceNoticeMsg{Notice: n} },
 		)
+		events <- MaintenanceDoneMsg{Err: err}
+		close(events)
+		return nil
+	}
+}
 
-		pool, err := mgr.Pool(connName)
-		if err != nil {
-			return RowDeletedMsg{Err: err}
-		}
+// cancelMaintenanceCmd has the server cancel the command running as pid.
+func cancelMaintenanceCmd(mgr *db.Manager, connName string, pid int32) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		ok, err := mgr.CancelBackend(ctx, connName, pid)
+		return MaintenanceCancelSentMsg{PID: pid, OK: ok, Err: err}
+	}
+}
 
-		_, err = pool.Exec(ctx, query, args...)
-		return RowDeletedMsg{Err: err}
+// waitMaintenanceCmd waits for the next event of a running command.
+func waitMaintenanceCmd(events <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return <-events // nil once closed
 	}
 }
 
-func insertRowCmd(mgr *db.Manager, connName, schema, table string, columns []string, values []string, nullDisplay string) tea.Cmd {
+// closeListenerCmd closes a listener that won't be run.
+func closeListenerCmd(l *db.Listener) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		l.Close(ctx)
+		return nil
+	}
+}
+
+// openListenerCmd opens a connection of its own to LISTEN on channels.
+func openListenerCmd(mgr *db.Manager, connName string, channels []string) tea.Cmd {
+	database := mgr.Database(connName)
 	return func() tea.Msg {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
+		l, err := mgr.NewListener(ctx, connName)
+		return ListenerOpenedMsg{ConnName: connName, Database: database, Listener: l, Channels: channels, Err: err}
+	}
+}
+
+// runListenerCmd passes the notifications l receives to events until ctx is
+// cancelled or the connection fails, then closes events. waitNotifyCmd
+// hands them on one at a time.
+func runListenerCmd(ctx context.Context, l *db.Listener, events chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		err := l.Run(ctx, func(n db.Notification) {
+			events <- NotificationMsg{Notification: n, Events: events}
+		})
+		events <- ListenerDoneMsg{Events: events, Err: err}
+		close(events)
+		return nil
+	}
+}
+
+// waitNotifyCmd waits for the next notification of a listener.
+func waitNotifyCmd(events <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return <-events // nil once closed
+	}
+}
 
-		cols := ""
-		placeholders := ""
-		args := []any{}
-		argIdx := 1
-		for i, col := range columns {
-			if values[i] == "" || values[i] == nullDisplay {
-				continue
+// listenCmd LISTENs on channels, or UNLISTENs from them, stopping at the
+// first failure.
+func listenCmd(l *db.Listener, channels []string, unlisten bool) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		for i, ch := range channels {
+			run := l.Listen
+			if unlisten {
+				run = l.Unlisten
 			}
-			if cols != "" {
-				cols += ", "
-				placeholders = placeholders + ", "
+			if err := run(ctx, ch); err != nil {
+				return ListenedMsg{Listener: l, Channels: channels[:i], Unlisten: unlisten, Err: err}
 			}
-			cols += fmt.Sprintf("%q", col)
-			placeholders += fmt.Sprintf("$%d", argIdx)
-			args = append(args, values[i])
-			argIdx++
 		}
+		return ListenedMsg{Listener: l, Channels: channels, Unlisten: unlisten}
+	}
+}
 
-		if cols == "" {
-			return RowInsertedMsg{Err: fmt.Errorf("no values provided")}
-		}
+func notifyCmd(mgr *db.Manager, connName, channel, payload string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		err := mgr.Notify(ctx, connName, channel, payload)
+		return NotifySentMsg{Channel: channel, Err: err}
+	}
+}
 
-		query := fmt.Sprintf(`INSERT INTO %q.%q (%s) VALUES (%s)`, schema, table, cols, placeholders)
+func loadProgressCmd(mgr *db.Manager, connName string, mt db.Maintenance, pid int32) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		progress, ok, err := mgr.MaintenanceProgress(ctx, connName, mt, pid)
+		return ProgressLoadedMsg{Progress: progress, OK: ok, Err: err}
+	}
+}
 
-		pool, err := mgr.Pool(connName)
-		if err != nil {
-			return RowInsertedMsg{Err: err}
+// signalBackendCmd cancels the query of pid, or ends its session when terminate is set.
+func signalBackendCmd(mgr *db.Manager, connName string, pid int32, terminate bool) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		signal := mgr.CancelBackend
+		if terminate {
+			signal = mgr.TerminateBackend
 		}
+		ok, err := signal(ctx, connName, pid)
+		return BackendSignaledMsg{PID: pid, Terminate: terminate, OK: ok, Err: err}
+	}
+}
 
-		_, err = pool.Exec(ctx, query, args...)
-		return RowInsertedMsg{Err: err}
+func explainCmd(mgr *db.Manager, connName, title, query string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		plan, err := mgr.ExplainQuery(ctx, connName, query)
+		return PlanLoadedMsg{Title: title, Plan: plan, Err: err}
 	}
 }
 
-func updateCellCmd(mgr *db.Manager, connName, schema, table string, columns []string, pkCols []string, rowValues []string, colIdx int, newValue, nullDisplay string) tea.Cmd {
+func deleteRowCmd(mgr *db.Manager, connName, schema, table string, columns []string, pkCols []string, rowValues []any) tea.Cmd {
 	return func() tea.Msg {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
-		// Build WHERE clause
-		whereCols := pkCols
-		if len(whereCols) == 0 {
-			whereCols = columns
+		pool, err := mgr.Pool(connName)
+		if err != nil {
+			return RowDeletedMsg{ConnName: connName, Err: err}
 		}
 
-		where := ""
-		args := []any{}
-		argIdx := 1
-
-		// First arg is the new value
-		var setClause string
-		if newValue == nullDisplay {
-			setClause = fmt.Sprintf("%q = NULL", columns[colIdx])
-		} else {
-			setClause = fmt.Sprintf("%q = $%d", columns[colIdx], argIdx)
-			args = append(args, newValue)
-			argIdx++
-		}
+		st := db.DeleteRowStatement(schema, table, columns, pkCols, rowValues)
+		_, err = pool.Exec(ctx, st.SQL, st.Args...)
+		return RowDeletedMsg{ConnName: connName, Err: err}
+	}
+}
 
-		for _, col := range whereCols {
-			for i, c := range columns {
-				if c == col {
-					if where != "" {
-						where += " AND "
-					}
-					if rowValues[i] == nullDisplay {
-						where += fmt.Sprintf("%q IS NULL", col)
-					} else {
-						where += fmt.Sprintf("%q = $%d", col, argIdx)
-						args = append(args, rowValues[i])
-						argIdx++
-					}
-					break
-				}
-			}
-		}
+func insertRowCmd(mgr *db.Manager, connName, schema, table string, columns []string, values []db.CellValue, f db.Formatter) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
 
-		query := fmt.Sprintf(`UPDATE %q.%q SET %s WHERE %s`, schema, table, setClause, where)
+		st := db.InsertRowStatement(schema, table, columns, values)
+		result, err := mgr.ExecQuery(ctx, connName, st.SQL, f, st.Args...)
+		return RowInsertedMsg{ConnName: connName, Result: result, Err: err}
+	}
+}
+
+func updateCellCmd(mgr *db.Manager, connName, schema, table string, columns []string, pkCols []string, rowValues []any, colIdx int, newValue db.CellValue) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
 
 		pool, err := mgr.Pool(connName)
 		if err != nil {
-			return RowUpdatedMsg{Err: err}
+			return RowUpdatedMsg{ConnName: connName, Err: err}
 		}
 
-		_, err = pool.Exec(ctx, query, args...)
-		return RowUpdatedMsg{Err: err}
+		st := db.UpdateCellStatement(schema, table, columns, pkCols, rowValues, colIdx, newValue)
+		_, err = pool.Exec(ctx, st.SQL, st.Args...)
+		return RowUpdatedMsg{ConnName: connName, Err: err}
+	}
+}
+
+// bulkCmd runs stmts in one transaction on behalf of a bulk table action.
+func bulkCmd(mgr *db.Manager, connName, action string, stmts []db.Statement) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+		defer cancel()
+		affected, err := mgr.ExecTx(ctx, connName, stmts)
+		return BulkDoneMsg{ConnName: connName, Action: action, Affected: affected, Err: err}
 	}
 }
 
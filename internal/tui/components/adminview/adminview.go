@@ -0,0 +1,508 @@
+package adminview
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/zaffron/ezpg/internal/tui/shared"
+)
+
+// Column describes one column of the list.
+type Column struct {
+	Title   string
+	Width   int  // 0 takes the space the fixed columns leave
+	Numeric bool // right aligned and sorted by Row.Sort
+}
+
+// Row is one line of the list.
+type Row struct {
+	Key   string    // identifies the row across refreshes, e.g. a pid
+	Cells []string  // parallel to the columns
+	Sort  []float64 // sort keys of the numeric columns, parallel to Cells
+	Color lipgloss.Color
+}
+
+// AdminView is a sortable, filterable list for the admin screens, such as
+// pg_stat_activity, with a scrollable text view for drilling into a row.
+type AdminView struct {
+	title   string
+	info    string
+	summary string // lines shown above the list, e.g. server facts
+	empty   string // shown when there are no rows
+	columns []Column
+	rows    []Row // as given
+	visible []Row // filtered and sorted
+	cursor  int
+	offset  int
+	width   int
+	height  int
+
+	sortCol  int // -1 keeps the given order
+	sortDesc bool
+
+	filter    textinput.Model
+	filtering bool
+
+	// Drill-down into a row, e.g. the full query text
+	detail       bool
+	detailTitle  string
+	detailText   string
+	detailOffset int
+}
+
+func New() AdminView {
+	filter := textinput.New()
+	filter.Prompt = "/"
+	filter.PromptStyle = lipgloss.NewStyle().Foreground(shared.ColorSecondary).Bold(true)
+	filter.Placeholder = "filter"
+	filter.CharLimit = 64
+	filter.Width = 30
+
+	return AdminView{filter: filter, sortCol: -1}
+}
+
+func (v *AdminView) SetSize(w, h int) {
+	v.width = w
+	v.height = h
+	v.scrollToCursor()
+}
+
+func (v *AdminView) SetTitle(title string) { v.title = title }
+
+// SetEmptyText sets what the list says when there is nothing in it.
+func (v *AdminView) SetEmptyText(text string) { v.empty = text }
+
+// SetInfo sets the text shown next to the title, e.g. when it last refreshed.
+func (v *AdminView) SetInfo(info string) { v.info = info }
+
+// SetSummary sets text to show between the title and the list.
+func (v *AdminView) SetSummary(summary string) {
+	v.summary = summary
+	v.scrollToCursor()
+}
+
+// SetColumns replaces the columns, clearing the rows, the summary, the sort
+// and the filter.
+func (v *AdminView) SetColumns(cols []Column) {
+	v.columns = cols
+	v.summary = ""
+	v.rows = nil
+	v.visible = nil
+	v.cursor = 0
+	v.offset = 0
+	v.sortCol = -1
+	v.sortDesc = false
+	v.detail = false
+	v.ClearFilter()
+}
+
+// SetRows replaces the rows, keeping the cursor on the same row when it is
+// still there.
+func (v *AdminView) SetRows(rows []Row) {
+	selected, ok := v.Selected()
+	v.rows = rows
+	v.rebuild()
+	if ok {
+		v.selectKey(selected.Key)
+	}
+}
+
+// SortBy sorts on col, or restores the given order for -1.
+func (v *AdminView) SortBy(col int, desc bool) {
+	v.sortCol = col
+	v.sortDesc = desc
+	v.resort()
+}
+
+// CycleSort sorts on the next column, numeric ones largest first.
+func (v *AdminView) CycleSort() {
+	if len(v.columns) == 0 {
+		return
+	}
+	col := v.sortCol + 1
+	if col >= len(v.columns) {
+		col = -1
+	}
+	v.SortBy(col, col >= 0 && v.columns[col].Numeric)
+}
+
+func (v *AdminView) ReverseSort() {
+	if v.sortCol >= 0 {
+		v.SortBy(v.sortCol, !v.sortDesc)
+	}
+}
+
+// resort rebuilds the list, keeping the cursor on its row.
+func (v *AdminView) resort() {
+	selected, ok := v.Selected()
+	v.rebuild()
+	if ok {
+		v.selectKey(selected.Key)
+	}
+}
+
+func (v *AdminView) rebuild() {
+	q := strings.ToLower(v.filter.Value())
+	v.visible = v.visible[:0]
+	for _, r := range v.rows {
+		if q == "" || r.matches(q) {
+			v.visible = append(v.visible, r)
+		}
+	}
+
+	if c := v.sortCol; c >= 0 && c < len(v.columns) {
+		numeric := v.columns[c].Numeric
+		slices.SortStableFunc(v.visible, func(a, b Row) int {
+			var n int
+			if numeric {
+				n = cmp.Compare(a.sortKey(c), b.sortKey(c))
+			} else {
+				n = cmp.Compare(strings.ToLower(a.cell(c)), strings.ToLower(b.cell(c)))
+			}
+			if v.sortDesc {
+				return -n
+			}
+			return n
+		})
+	}
+
+	v.cursor = min(v.cursor, max(len(v.visible)-1, 0))
+	v.scrollToCursor()
+}
+
+func (r Row) cell(i int) string {
+	if i < len(r.Cells) {
+		return r.Cells[i]
+	}
+	return ""
+}
+
+func (r Row) sortKey(i int) float64 {
+	if i < len(r.Sort) {
+		return r.Sort[i]
+	}
+	return 0
+}
+
+func (r Row) matches(q string) bool {
+	for _, c := range r.Cells {
+		if strings.Contains(strings.ToLower(c), q) {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *AdminView) selectKey(k string) {
+	for i, r := range v.visible {
+		if r.Key == k {
+			v.cursor = i
+			v.scrollToCursor()
+			return
+		}
+	}
+}
+
+// Selected returns the row under the cursor.
+func (v *AdminView) Selected() (Row, bool) {
+	if v.cursor < 0 || v.cursor >= len(v.visible) {
+		return Row{}, false
+	}
+	return v.visible[v.cursor], true
+}
+
+// AtEnd reports whether the cursor is on the last row, or there are none,
+// so a list that grows at the bottom can keep following it.
+func (v *AdminView) AtEnd() bool { return v.cursor >= len(v.visible)-1 }
+
+// SelectLast moves the cursor to the last row.
+func (v *AdminView) SelectLast() { v.move(len(v.visible)) }
+
+// Len is the number of rows shown, after filtering.
+func (v *AdminView) Len() int { return len(v.visible) }
+
+func (v *AdminView) IsFiltering() bool {
+	return v.filtering
+}
+
+// IsFiltered reports whether a filter narrows the list, typed or accepted.
+func (v *AdminView) IsFiltered() bool {
+	return v.filter.Value() != ""
+}
+
+func (v *AdminView) StartFilter() {
+	v.filtering = true
+	v.filter.Focus()
+}
+
+// AcceptFilter stops typing but keeps the list narrowed.
+func (v *AdminView) AcceptFilter() {
+	v.filtering = false
+	v.filter.Blur()
+}
+
+func (v *AdminView) ClearFilter() {
+	v.filtering = false
+	v.filter.Blur()
+	v.filter.SetValue("")
+	v.resort()
+}
+
+func (v *AdminView) UpdateFilter(msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+	v.filter, cmd = v.filter.Update(msg)
+	v.cursor = 0
+	v.rebuild()
+	return cmd
+}
+
+// ShowDetail shows text in place of the list until CloseDetail.
+func (v *AdminView) ShowDetail(title, text string) {
+	v.detail = true
+	v.detailTitle = title
+	v.detailText = text
+	v.detailOffset = 0
+}
+
+func (v *AdminView) CloseDetail() {
+	v.detail = false
+	v.detailText = ""
+}
+
+func (v *AdminView) HasDetail() bool { return v.detail }
+
+// listHeight is the number of rows that fit under the title and header.
+func (v *AdminView) listHeight() int {
+	h := v.height - 3 // title, header, footer
+	if v.filtering || v.IsFiltered() {
+		h--
+	}
+	if v.summary != "" {
+		h -= strings.Count(v.summary, "\n") + 2 // and a blank line under it
+	}
+	return max(h, 1)
+}
+
+func (v *AdminView) scrollToCursor() {
+	h := v.listHeight()
+	if v.cursor < v.offset {
+		v.offset = v.cursor
+	}
+	if v.cursor >= v.offset+h {
+		v.offset = v.cursor - h + 1
+	}
+	v.offset = max(min(v.offset, len(v.visible)-h), 0)
+}
+
+func (v *AdminView) move(delta int) {
+	if len(v.visible) == 0 {
+		return
+	}
+	v.cursor = max(min(v.cursor+delta, len(v.visible)-1), 0)
+	v.scrollToCursor()
+}
+
+func (v AdminView) Update(msg tea.KeyMsg) (AdminView, tea.Cmd) {
+	if v.detail {
+		lines := len(v.detailLines())
+		page := max(v.height-2, 1)
+		switch {
+		case key.Matches(msg, key.NewBinding(key.WithKeys("j", "down"))):
+			v.detailOffset++
+		case key.Matches(msg, key.NewBinding(key.WithKeys("k", "up"))):
+			v.detailOffset--
+		case key.Matches(msg, key.NewBinding(key.WithKeys("ctrl+d", "pgdown"))):
+			v.detailOffset += page / 2
+		case key.Matches(msg, key.NewBinding(key.WithKeys("ctrl+u", "pgup"))):
+			v.detailOffset -= page / 2
+		case key.Matches(msg, key.NewBinding(key.WithKeys("g"))):
+			v.detailOffset = 0
+		case key.Matches(msg, key.NewBinding(key.WithKeys("G"))):
+			v.detailOffset = lines
+		}
+		v.detailOffset = max(min(v.detailOffset, lines-page), 0)
+		return v, nil
+	}
+
+	switch {
+	case key.Matches(msg, key.NewBinding(key.WithKeys("j", "down"))):
+		v.move(1)
+	case key.Matches(msg, key.NewBinding(key.WithKeys("k", "up"))):
+		v.move(-1)
+	case key.Matches(msg, key.NewBinding(key.WithKeys("g"))):
+		v.move(-len(v.visible))
+	case key.Matches(msg, key.NewBinding(key.WithKeys("G"))):
+		v.move(len(v.visible))
+	case key.Matches(msg, key.NewBinding(key.WithKeys("ctrl+d"))):
+		v.move(v.listHeight() / 2)
+	case key.Matches(msg, key.NewBinding(key.WithKeys("ctrl+u"))):
+		v.move(-v.listHeight() / 2)
+	}
+	return v, nil
+}
+
+// colWidths fits the columns into the width, the flexible ones sharing
+// whatever the fixed ones leave.
+func (v AdminView) colWidths() []int {
+	widths := make([]int, len(v.columns))
+	used, flex := 0, 0
+	for i, c := range v.columns {
+		widths[i] = c.Width
+		if c.Width == 0 {
+			flex++
+		}
+		used += c.Width + 1 // one space between columns
+	}
+	if flex > 0 {
+		each := max((v.width-used)/flex, 8)
+		for i, c := range v.columns {
+			if c.Width == 0 {
+				widths[i] = each
+			}
+		}
+	}
+	return widths
+}
+
+func (v AdminView) View() string {
+	if v.detail {
+		return v.detailView()
+	}
+
+	var b strings.Builder
+
+	title := lipgloss.NewStyle().Bold(true).Foreground(shared.ColorPrimary).Render(v.title)
+	info := lipgloss.NewStyle().Foreground(shared.ColorMuted).Render("  " + v.info)
+	b.WriteString(title + info + "\n")
+
+	if v.summary != "" {
+		b.WriteString(lipgloss.NewStyle().Foreground(shared.ColorFg).Render(v.summary) + "\n\n")
+	}
+
+	if v.filtering || v.IsFiltered() {
+		b.WriteString(v.filter.View() + "\n")
+	}
+
+	widths := v.colWidths()
+	header := make([]string, len(v.columns))
+	for i, c := range v.columns {
+		t := c.Title
+		if i == v.sortCol {
+			if v.sortDesc {
+				t += "↓"
+			} else {
+				t += "↑"
+			}
+		}
+		header[i] = align(t, widths[i], c.Numeric)
+	}
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(shared.ColorSecondary)
+	b.WriteString(headerStyle.Render(fit(strings.Join(header, " "), v.width)) + "\n")
+
+	h := v.listHeight()
+	if len(v.visible) == 0 {
+		empty := v.empty
+		if empty == "" || len(v.rows) > 0 {
+			empty = "nothing to show"
+		}
+		b.WriteString(lipgloss.NewStyle().Foreground(shared.ColorMuted).PaddingLeft(2).Render(empty) + "\n")
+	}
+	for i := v.offset; i < len(v.visible) && i < v.offset+h; i++ {
+		r := v.visible[i]
+		cells := make([]string, len(v.columns))
+		for j, c := range v.columns {
+			cells[j] = align(r.cell(j), widths[j], c.Numeric)
+		}
+		line := fit(strings.Join(cells, " "), v.width)
+
+		style := lipgloss.NewStyle().Foreground(shared.ColorFg)
+		if r.Color != "" {
+			style = style.Foreground(r.Color)
+		}
+		if i == v.cursor {
+			style = style.Background(shared.ColorBgAlt).Bold(true)
+		}
+		b.WriteString(style.Render(line) + "\n")
+	}
+
+	footer := fmt.Sprintf(" %d rows", len(v.visible))
+	if len(v.visible) != len(v.rows) {
+		footer = fmt.Sprintf(" %d of %d rows", len(v.visible), len(v.rows))
+	}
+	b.WriteString(lipgloss.NewStyle().Foreground(shared.ColorMuted).Render(footer))
+
+	return b.String()
+}
+
+func (v AdminView) detailLines() []string {
+	wrapped := lipgloss.NewStyle().Width(max(v.width-2, 10)).Render(v.detailText)
+	return strings.Split(wrapped, "\n")
+}
+
+func (v AdminView) detailView() string {
+	var b strings.Builder
+
+	title := lipgloss.NewStyle().Bold(true).Foreground(shared.ColorPrimary).Render(v.detailTitle)
+	b.WriteString(title + "\n\n")
+
+	lines := v.detailLines()
+	end := min(v.detailOffset+max(v.height-2, 1), len(lines))
+	text := strings.Join(lines[min(v.detailOffset, end):end], "\n")
+	b.WriteString(lipgloss.NewStyle().Foreground(shared.ColorFg).Render(text))
+
+	return b.String()
+}
+
+// FormatDuration renders d compactly, e.g. 12.5ms, 4.2s, 3m05s or 2h10m.
+func FormatDuration(d time.Duration) string {
+	switch {
+	case d < time.Millisecond:
+		return fmt.Sprintf("%dµs", d.Microseconds())
+	case d < time.Second:
+		return fmt.Sprintf("%.1fms", float64(d)/float64(time.Millisecond))
+	case d < 10*time.Second:
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+	return fmt.Sprintf("%dd%02dh", int(d.Hours())/24, int(d.Hours())%24)
+}
+
+// align pads or cuts s to exactly w columns. Newlines and tabs become spaces,
+// so multi-line queries stay on one row.
+func align(s string, w int, right bool) string {
+	s = strings.Join(strings.Fields(s), " ")
+	s = fit(s, w)
+	pad := strings.Repeat(" ", max(w-lipgloss.Width(s), 0))
+	if right {
+		return pad + s
+	}
+	return s + pad
+}
+
+// fit cuts s to w columns, marking the cut with an ellipsis.
+func fit(s string, w int) string {
+	if lipgloss.Width(s) <= w {
+		return s
+	}
+	r := []rune(s)
+	if w <= 1 {
+		return string(r[:max(w, 0)])
+	}
+	r = r[:min(len(r), w)] // no rune is narrower than a column
+	for len(r) > 0 && lipgloss.Width(string(r))+1 > w {
+		r = r[:len(r)-1]
+	}
+	return string(r) + "…"
+}
@@ -2,6 +2,8 @@ package homescreen
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
@@ -11,37 +13,50 @@ import (
 )
 
 func (h *HomeScreen) initForm(conn config.Connection) {
+	h.base = conn
+	h.ClearTestResult()
 	h.fields = make([]textinput.Model, fieldCount)
 
 	labels := []string{
 		"Name",
+		"Environment",
+		"Group",
+		"Service",
 		"Host",
 		"Port",
 		"User",
 		"Password",
+		"Password Cmd",
 		"Database",
 		"SSL Mode",
+		"SSL Root Cert",
+		"SSL Cert",
+		"SSL Key",
+		"SSL Key Pass",
+		"Params",
 		"URL",
 	}
 
 	values := []string{
 		conn.Name,
+		conn.Environment,
+		conn.Group,
+		conn.Service,
 		conn.Host,
-		fmt.Sprintf("%d", conn.Port),
+		formatPort(conn.Port),
 		conn.User,
 		conn.Password,
+		conn.PasswordCommand,
 		conn.Database,
 		conn.SSLMode,
+		conn.SSLRootCert,
+		conn.SSLCert,
+		conn.SSLKey,
+		conn.SSLPassword,
+		config.FormatParams(conn.Params),
 		conn.URL,
 	}
 
-	if conn.Port == 0 {
-		values[fieldPort] = "5432"
-	}
-	if conn.SSLMode == "" {
-		values[fieldSSLMode] = "disable"
-	}
-
 	for i := range h.fields {
 		ti := textinput.New()
 		ti.Prompt = labels[i] + ": "
@@ -54,7 +69,7 @@ func (h *HomeScreen) initForm(conn config.Connection) {
 		ti.Width = 40
 		ti.SetValue(values[i])
 
-		if i == int(fieldPassword) {
+		if i == int(fieldPassword) || i == int(fieldSSLPassword) {
 			ti.EchoMode = textinput.EchoPassword
 		}
 
@@ -76,17 +91,19 @@ func (h *HomeScreen) IsCreating() bool {
 func (h *HomeScreen) StartCreate() {
 	h.creating = true
 	h.editing = false
-	h.initForm(config.Connection{Port: 5432, SSLMode: "disable"})
+	// Create inside the folder under the cursor
+	h.initForm(config.Connection{Port: 5432, SSLMode: "disable", Group: h.selectedGroup()})
 }
 
 func (h *HomeScreen) StartEdit() bool {
-	if h.cursor < 0 || h.cursor >= len(h.connections) {
+	idx := h.SelectedIndex()
+	if idx < 0 {
 		return false
 	}
 	h.editing = true
 	h.creating = false
-	h.editIdx = h.cursor
-	h.initForm(h.connections[h.cursor])
+	h.editIdx = idx
+	h.initForm(h.connections[idx])
 	return true
 }
 
@@ -98,9 +115,11 @@ func (h *HomeScreen) CancelForm() {
 	h.editing = false
 	h.creating = false
 	h.fields = nil
+	h.ClearTestResult()
 }
 
 func (h *HomeScreen) NextField() {
+	h.leaveField()
 	h.fields[h.activeField].Blur()
 	h.activeField++
 	if h.activeField >= fieldCount {
@@ -112,10 +131,59 @@ func (h *HomeScreen) NextField() {
 func (h *HomeScreen) UpdateField(msg tea.Msg) tea.Cmd {
 	var cmd tea.Cmd
 	h.fields[h.activeField], cmd = h.fields[h.activeField].Update(msg)
+	if k, ok := msg.(tea.KeyMsg); ok && k.Paste && h.activeField == fieldURL {
+		h.expandURL()
+	}
 	return cmd
 }
 
+func (h *HomeScreen) leaveField() {
+	if h.activeField == fieldURL {
+		h.expandURL()
+	}
+}
+
+// expandURL parses a URL or key=value DSN in the URL field into the separate
+// fields. Values with ${ENV} references are left alone since they only make
+// sense once expanded.
+func (h *HomeScreen) expandURL() {
+	dsn := h.fields[fieldURL].Value()
+	if dsn == "" || strings.Contains(dsn, "${") {
+		return
+	}
+
+	c, err := config.ParseDSN(dsn)
+	if err != nil {
+		return // keep it as a raw connection string
+	}
+
+	h.fields[fieldService].SetValue(c.Service)
+	h.fields[fieldHost].SetValue(c.Host)
+	h.fields[fieldPort].SetValue(formatPort(c.Port))
+	h.fields[fieldUser].SetValue(c.User)
+	if c.Password != "" {
+		h.fields[fieldPassword].SetValue(c.Password)
+		h.fields[fieldPasswordCmd].SetValue("")
+	}
+	h.fields[fieldDatabase].SetValue(c.Database)
+	h.fields[fieldSSLMode].SetValue(c.SSLMode)
+	h.fields[fieldSSLRootCert].SetValue(c.SSLRootCert)
+	h.fields[fieldSSLCert].SetValue(c.SSLCert)
+	h.fields[fieldSSLKey].SetValue(c.SSLKey)
+	h.fields[fieldSSLPassword].SetValue(c.SSLPassword)
+	h.fields[fieldParams].SetValue(config.FormatParams(c.Params))
+	h.fields[fieldURL].SetValue("")
+}
+
+func formatPort(port int) string {
+	if port == 0 {
+		return "" // PGPORT, the service or 5432
+	}
+	return strconv.Itoa(port)
+}
+
 func (h *HomeScreen) PrevField() {
+	h.leaveField()
 	h.fields[h.activeField].Blur()
 	h.activeField--
 	if h.activeField < 0 {
@@ -124,19 +192,42 @@ func (h *HomeScreen) PrevField() {
 	h.fields[h.activeField].Focus()
 }
 
-func (h *HomeScreen) FormConnection() config.Connection {
-	port := 5432
-	if p := h.fields[fieldPort].Value(); p != "" {
-		fmt.Sscanf(p, "%d", &port)
+func (h *HomeScreen) FormConnection() (config.Connection, error) {
+	var port int
+	if p := strings.TrimSpace(h.fields[fieldPort].Value()); p != "" {
+		n, err := strconv.Atoi(p)
+		if err != nil || n <= 0 || n > 65535 {
+			return config.Connection{}, fmt.Errorf("invalid port %q", p)
+		}
+		port = n
 	}
-	return config.Connection{
-		Name:     h.fields[fieldName].Value(),
-		Host:     h.fields[fieldHost].Value(),
-		Port:     port,
-		User:     h.fields[fieldUser].Value(),
-		Password: h.fields[fieldPassword].Value(),
-		Database: h.fields[fieldDatabase].Value(),
-		SSLMode:  h.fields[fieldSSLMode].Value(),
-		URL:      h.fields[fieldURL].Value(),
+
+	params, err := config.ParseParams(h.fields[fieldParams].Value())
+	if err != nil {
+		return config.Connection{}, fmt.Errorf("params: %w", err)
+	}
+
+	conn := h.base
+	conn.Name = h.fields[fieldName].Value()
+	conn.Environment = strings.TrimSpace(h.fields[fieldEnvironment].Value())
+	conn.Group = config.CleanGroup(h.fields[fieldGroup].Value())
+	conn.Service = h.fields[fieldService].Value()
+	conn.Host = h.fields[fieldHost].Value()
+	conn.Port = port
+	conn.User = h.fields[fieldUser].Value()
+	conn.Password = h.fields[fieldPassword].Value()
+	conn.PasswordCommand = h.fields[fieldPasswordCmd].Value()
+	conn.Database = h.fields[fieldDatabase].Value()
+	conn.SSLMode = h.fields[fieldSSLMode].Value()
+	conn.SSLRootCert = h.fields[fieldSSLRootCert].Value()
+	conn.SSLCert = h.fields[fieldSSLCert].Value()
+	conn.SSLKey = h.fields[fieldSSLKey].Value()
+	conn.SSLPassword = h.fields[fieldSSLPassword].Value()
+	conn.Params = params
+	conn.URL = h.fields[fieldURL].Value()
+
+	if err := conn.CheckTLS(); err != nil {
+		return config.Connection{}, err
 	}
+	return conn, nil
 }
@@ -0,0 +1,66 @@
+package homescreen
+
+import (
+	"strings"
+	"unicode"
+)
+
+// fuzzyScore reports whether the runes of query appear in order in target,
+// case-insensitively, and scores the best such alignment. Runs of consecutive
+// matches and matches at word starts score higher, so "pdb" ranks "prod-db"
+// above "pgadmin-backup". Spaces in the query are ignored.
+func fuzzyScore(query, target string) (int, bool) {
+	q := []rune(strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, query))
+	t := []rune(strings.ToLower(target))
+	if len(q) == 0 {
+		return 0, true
+	}
+
+	// best[j] is the top score with the current query rune matched at t[j], -1 if impossible
+	best := make([]int, len(t))
+	next := make([]int, len(t))
+	for i, qr := range q {
+		for j, tr := range t {
+			next[j] = -1
+			if tr != qr {
+				continue
+			}
+
+			gain := 1
+			if j == 0 || strings.ContainsRune(" -_./:", t[j-1]) {
+				gain += 3
+			}
+
+			if i == 0 {
+				next[j] = gain
+				continue
+			}
+			prev := -1
+			for k := 0; k < j; k++ {
+				if best[k] < 0 {
+					continue
+				}
+				s := best[k]
+				if k == j-1 {
+					s += 5
+				}
+				prev = max(prev, s)
+			}
+			if prev >= 0 {
+				next[j] = prev + gain
+			}
+		}
+		best, next = next, best
+	}
+
+	score := -1
+	for _, s := range best {
+		score = max(score, s)
+	}
+	return score, score >= 0
+}
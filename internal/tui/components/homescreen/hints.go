@@ -8,22 +8,43 @@ func (h HomeScreen) Hints() []keyhints.Hint {
 			{Key: "tab", Desc: "next field"},
 			{Key: "shift+tab", Desc: "prev field"},
 			{Key: "enter", Desc: "save"},
+			{Key: "ctrl+t", Desc: "test"},
 			{Key: "esc", Desc: "cancel"},
 		}
 	}
 
+	if h.filtering {
+		return []keyhints.Hint{
+			{Key: "enter", Desc: "accept"},
+			{Key: "up/down", Desc: "navigate"},
+			{Key: "esc", Desc: "clear"},
+		}
+	}
+
 	hints := []keyhints.Hint{
 		{Key: "j/k", Desc: "navigate"},
 		{Key: "enter", Desc: "connect"},
 		{Key: "c", Desc: "create"},
 	}
 
+	if h.cursor >= 0 && h.cursor < len(h.rows) && h.rows[h.cursor].kind == rowFolder {
+		hints[1] = keyhints.Hint{Key: "enter", Desc: "open/close"}
+	}
+
 	if len(h.connections) > 0 {
 		hints = append(hints,
 			keyhints.Hint{Key: "e", Desc: "edit"},
+			keyhints.Hint{Key: "t", Desc: "test"},
 			keyhints.Hint{Key: "d", Desc: "delete"},
+			keyhints.Hint{Key: "/", Desc: "search"},
+			keyhints.Hint{Key: "f", Desc: "favorite"},
+			keyhints.Hint{Key: "J/K", Desc: "move"},
 		)
 	}
 
+	if h.IsFiltered() {
+		hints = append(hints, keyhints.Hint{Key: "esc", Desc: "clear search"})
+	}
+
 	return append(hints, keyhints.Hint{Key: "q", Desc: "quit"})
 }
@@ -1,11 +1,28 @@
 package homescreen
 
-import "github.com/zaffron/ezpg/internal/config"
+import (
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/zaffron/ezpg/internal/config"
+	"github.com/zaffron/ezpg/internal/tui/shared"
+)
 
-func New(connections []config.Connection) HomeScreen {
-	return HomeScreen{
+func New(connections []config.Connection, envColors map[string]string) HomeScreen {
+	filter := textinput.New()
+	filter.Prompt = "/"
+	filter.PromptStyle = lipgloss.NewStyle().Foreground(shared.ColorSecondary).Bold(true)
+	filter.Placeholder = "search"
+	filter.CharLimit = 64
+	filter.Width = 30
+
+	h := HomeScreen{
 		connections: connections,
+		envColors:   envColors,
+		collapsed:   make(map[string]bool),
+		filter:      filter,
 	}
+	h.rebuild()
+	return h
 }
 
 func (h *HomeScreen) SetSize(w, ht int) {
@@ -15,12 +32,13 @@ func (h *HomeScreen) SetSize(w, ht int) {
 
 func (h *HomeScreen) SetConnections(conns []config.Connection) {
 	h.connections = conns
-	if h.cursor >= len(conns) && len(conns) > 0 {
-		h.cursor = len(conns) - 1
-	}
-	if len(conns) == 0 {
-		h.cursor = 0
-	}
+	h.rebuild()
+}
+
+// SetRecent sets the recently used connections, most recent first.
+func (h *HomeScreen) SetRecent(names []string) {
+	h.recent = names
+	h.rebuild()
 }
 
 func (h *HomeScreen) Connections() []config.Connection {
@@ -1,26 +1,146 @@
 package homescreen
 
-import "github.com/zaffron/ezpg/internal/config"
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/zaffron/ezpg/internal/config"
+)
 
+// SelectedIndex returns the index into connections under the cursor, or -1
+// when the cursor is on a folder.
 func (h *HomeScreen) SelectedIndex() int {
-	return h.cursor
+	if h.cursor < 0 || h.cursor >= len(h.rows) || h.rows[h.cursor].kind != rowConnection {
+		return -1
+	}
+	return h.rows[h.cursor].conn
 }
 
 func (h *HomeScreen) SelectedConnection() (config.Connection, bool) {
-	if h.cursor < 0 || h.cursor >= len(h.connections) {
+	idx := h.SelectedIndex()
+	if idx < 0 {
 		return config.Connection{}, false
 	}
-	return h.connections[h.cursor], true
+	return h.connections[idx], true
+}
+
+// SelectConnection moves the cursor to connections[idx] in the tree.
+func (h *HomeScreen) SelectConnection(idx int) {
+	for i, r := range h.rows {
+		if r.kind == rowConnection && !r.pinned && r.conn == idx {
+			h.cursor = i
+			return
+		}
+	}
+}
+
+// selectedGroup is the folder the cursor is in, for new connections.
+func (h *HomeScreen) selectedGroup() string {
+	if h.cursor < 0 || h.cursor >= len(h.rows) || h.rows[h.cursor].pinned {
+		return ""
+	}
+	return h.rows[h.cursor].path
 }
 
 func (h *HomeScreen) MoveUp() {
-	if h.cursor > 0 {
-		h.cursor--
+	if i := h.nextSelectable(h.cursor-1, -1); i >= 0 {
+		h.cursor = i
+		h.ClearTestResult()
 	}
 }
 
 func (h *HomeScreen) MoveDown() {
-	if h.cursor < len(h.connections)-1 {
-		h.cursor++
+	if i := h.nextSelectable(h.cursor+1, 1); i >= 0 {
+		h.cursor = i
+		h.ClearTestResult()
+	}
+}
+
+// ToggleFolder collapses or expands the folder under the cursor. It reports
+// false when the cursor is not on a folder.
+func (h *HomeScreen) ToggleFolder() bool {
+	if h.cursor < 0 || h.cursor >= len(h.rows) || h.rows[h.cursor].kind != rowFolder {
+		return false
+	}
+	path := h.rows[h.cursor].path
+	h.collapsed[path] = !h.collapsed[path]
+	h.rebuild()
+	return true
+}
+
+// SetFolderCollapsed collapses or expands the folder under the cursor. On a
+// connection, collapsing jumps to its folder instead.
+func (h *HomeScreen) SetFolderCollapsed(collapsed bool) {
+	if h.cursor < 0 || h.cursor >= len(h.rows) {
+		return
+	}
+	r := h.rows[h.cursor]
+	switch {
+	case r.kind == rowFolder:
+		h.collapsed[r.path] = collapsed
+		h.rebuild()
+	case r.kind == rowConnection && collapsed && !r.pinned && r.path != "" && !h.IsFiltered():
+		for i := h.cursor - 1; i >= 0; i-- {
+			if h.rows[i].kind == rowFolder && h.rows[i].path == r.path {
+				h.cursor = i
+				return
+			}
+		}
+	}
+}
+
+// SiblingIndex returns the connection that the selected one would swap
+// places with when moved by delta (-1 up, +1 down) within its folder.
+func (h *HomeScreen) SiblingIndex(delta int) (from, to int, ok bool) {
+	if h.cursor < 0 || h.cursor >= len(h.rows) || h.IsFiltered() {
+		return 0, 0, false
 	}
+	r := h.rows[h.cursor]
+	if r.kind != rowConnection || r.pinned {
+		return 0, 0, false
+	}
+
+	group := config.CleanGroup(h.connections[r.conn].Group)
+	for i := r.conn + delta; i >= 0 && i < len(h.connections); i += delta {
+		if config.CleanGroup(h.connections[i].Group) == group {
+			return r.conn, i, true
+		}
+	}
+	return 0, 0, false
+}
+
+// Fuzzy search
+
+func (h *HomeScreen) IsFiltering() bool {
+	return h.filtering
+}
+
+// IsFiltered reports whether a search narrows the list, typed or accepted.
+func (h *HomeScreen) IsFiltered() bool {
+	return h.filter.Value() != ""
+}
+
+func (h *HomeScreen) StartFilter() {
+	h.filtering = true
+	h.filter.Focus()
+	h.ClearTestResult()
+}
+
+// AcceptFilter stops typing but keeps the list narrowed.
+func (h *HomeScreen) AcceptFilter() {
+	h.filtering = false
+	h.filter.Blur()
+}
+
+func (h *HomeScreen) ClearFilter() {
+	h.filtering = false
+	h.filter.Blur()
+	h.filter.SetValue("")
+	h.rebuild()
+}
+
+func (h *HomeScreen) UpdateFilter(msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+	h.filter, cmd = h.filter.Update(msg)
+	h.rebuild()
+	h.cursor = h.nextSelectable(0, 1) // best match
+	return cmd
 }
@@ -0,0 +1,67 @@
+package homescreen
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/zaffron/ezpg/internal/db"
+	"github.com/zaffron/ezpg/internal/tui/shared"
+)
+
+// SetTesting shows that a test of the named connection is running.
+func (h *HomeScreen) SetTesting(name string) {
+	h.testTitle = "Testing " + name + "..."
+	h.testLines = nil
+	h.testFailed = false
+}
+
+// SetTestResult shows the outcome of testing the named connection.
+func (h *HomeScreen) SetTestResult(name string, d *db.Diagnostics, err error) {
+	if err != nil {
+		h.testTitle = "✗ " + name
+		h.testLines = []string{db.DescribeConnectError(err)}
+		h.testFailed = true
+		return
+	}
+
+	standby := "no"
+	if d.HotStandby {
+		standby = "yes (read-only replica)"
+	}
+
+	h.testTitle = "✓ " + name
+	h.testLines = []string{
+		"Server     PostgreSQL " + d.ServerVersion,
+		"User       " + d.User,
+		"Database   " + d.Database,
+		"TLS        " + d.TLS,
+		"Latency    " + d.Latency.Round(100*time.Microsecond).String(),
+		"Standby    " + standby,
+	}
+	h.testFailed = false
+}
+
+func (h *HomeScreen) ClearTestResult() {
+	h.testTitle = ""
+	h.testLines = nil
+}
+
+func (h HomeScreen) testView() string {
+	if h.testTitle == "" {
+		return ""
+	}
+
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(shared.ColorSuccess)
+	if h.testFailed {
+		titleStyle = titleStyle.Foreground(shared.ColorDanger)
+	}
+
+	var b strings.Builder
+	b.WriteString("\n" + titleStyle.Render(h.testTitle) + "\n")
+	for _, line := range h.testLines {
+		b.WriteString(lipgloss.NewStyle().Foreground(shared.ColorFg).Render(fmt.Sprintf("  %s", line)) + "\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,173 @@
+package homescreen
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"github.com/zaffron/ezpg/internal/config"
+)
+
+// folder is a node of the group tree, with children in first-seen order.
+type folder struct {
+	name    string
+	path    string
+	folders []*folder
+	conns   []int // indexes into connections, in config order
+}
+
+func (f *folder) child(name string) *folder {
+	for _, c := range f.folders {
+		if c.name == name {
+			return c
+		}
+	}
+	path := name
+	if f.path != "" {
+		path = f.path + "/" + name
+	}
+	c := &folder{name: name, path: path}
+	f.folders = append(f.folders, c)
+	return c
+}
+
+func (f *folder) size() int {
+	n := len(f.conns)
+	for _, c := range f.folders {
+		n += c.size()
+	}
+	return n
+}
+
+// rebuild recomputes the rows and keeps the cursor on the same connection or
+// folder where it still exists.
+func (h *HomeScreen) rebuild() {
+	var prev row
+	if h.cursor >= 0 && h.cursor < len(h.rows) {
+		prev = h.rows[h.cursor]
+	}
+
+	if q := strings.TrimSpace(h.filter.Value()); q != "" {
+		h.rows = h.filteredRows(q)
+	} else {
+		h.rows = h.treeRows()
+	}
+
+	h.cursor = -1
+	if prev.kind != rowSection {
+		for i, r := range h.rows {
+			if r.kind == prev.kind && r.pinned == prev.pinned && r.path == prev.path && r.conn == prev.conn {
+				h.cursor = i
+				break
+			}
+		}
+	}
+	if h.cursor < 0 {
+		h.cursor = h.nextSelectable(0, 1)
+	}
+}
+
+func (h *HomeScreen) treeRows() []row {
+	var rows []row
+
+	var favorites []row
+	for i, c := range h.connections {
+		if c.Favorite {
+			favorites = append(favorites, row{kind: rowConnection, conn: i, pinned: true})
+		}
+	}
+
+	var recent []row
+	for _, name := range h.recent {
+		if i := h.indexOf(name); i >= 0 {
+			recent = append(recent, row{kind: rowConnection, conn: i, pinned: true})
+		}
+	}
+
+	if len(favorites) > 0 {
+		rows = append(rows, row{kind: rowSection, label: "Favorites"})
+		rows = append(rows, favorites...)
+	}
+	if len(recent) > 0 {
+		rows = append(rows, row{kind: rowSection, label: "Recent"})
+		rows = append(rows, recent...)
+	}
+	if len(rows) > 0 {
+		rows = append(rows, row{kind: rowSection, label: "All"})
+	}
+
+	root := &folder{}
+	for i, c := range h.connections {
+		f := root
+		for _, name := range c.GroupPath() {
+			f = f.child(name)
+		}
+		f.conns = append(f.conns, i)
+	}
+
+	var walk func(f *folder, depth int)
+	walk = func(f *folder, depth int) {
+		for _, sub := range f.folders {
+			rows = append(rows, row{kind: rowFolder, label: sub.name, path: sub.path, depth: depth, count: sub.size()})
+			if !h.collapsed[sub.path] {
+				walk(sub, depth+1)
+			}
+		}
+		for _, i := range f.conns {
+			rows = append(rows, row{kind: rowConnection, conn: i, path: f.path, depth: depth})
+		}
+	}
+	walk(root, 0)
+
+	return rows
+}
+
+// filteredRows lists every connection matching q, best match first, ignoring folders.
+func (h *HomeScreen) filteredRows(q string) []row {
+	type match struct {
+		conn  int
+		score int
+	}
+
+	var matches []match
+	for i, c := range h.connections {
+		if score, ok := connScore(q, c); ok {
+			matches = append(matches, match{conn: i, score: score})
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b match) int {
+		return cmp.Compare(b.score, a.score)
+	})
+
+	rows := make([]row, len(matches))
+	for i, m := range matches {
+		rows[i] = row{kind: rowConnection, conn: m.conn, path: h.connections[m.conn].Group}
+	}
+	return rows
+}
+
+// connScore matches q against the name first, then the group, host and database.
+func connScore(q string, c config.Connection) (int, bool) {
+	if score, ok := fuzzyScore(q, c.Name); ok {
+		return score + 100, true
+	}
+	return fuzzyScore(q, strings.Join([]string{c.Group, c.Host, c.Service, c.Database, c.Environment}, " "))
+}
+
+func (h *HomeScreen) indexOf(name string) int {
+	for i, c := range h.connections {
+		if c.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (h *HomeScreen) nextSelectable(from, step int) int {
+	for i := from; i >= 0 && i < len(h.rows); i += step {
+		if h.rows[i].kind != rowSection {
+			return i
+		}
+	}
+	return -1
+}
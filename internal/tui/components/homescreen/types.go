@@ -10,27 +10,69 @@ type formField int
 
 const (
 	fieldName formField = iota
+	fieldEnvironment
+	fieldGroup
+	fieldService
 	fieldHost
 	fieldPort
 	fieldUser
 	fieldPassword
+	fieldPasswordCmd
 	fieldDatabase
 	fieldSSLMode
+	fieldSSLRootCert
+	fieldSSLCert
+	fieldSSLKey
+	fieldSSLPassword
+	fieldParams
 	fieldURL
 	fieldCount
 )
 
+// List rows
+type rowKind int
+
+const (
+	rowSection rowKind = iota // "Favorites", "Recent"; never selected
+	rowFolder                 // a group, collapsible
+	rowConnection
+)
+
+type row struct {
+	kind   rowKind
+	label  string // section title or folder name
+	path   string // folder path, e.g. clients/acme
+	conn   int    // index into connections
+	depth  int
+	pinned bool // a copy in Favorites or Recent rather than its place in the tree
+	count  int  // connections under a folder
+}
+
 // HomeScreen
 type HomeScreen struct {
 	connections []config.Connection
-	cursor      int
+	envColors   map[string]string
+	recent      []string        // connection names, most recent first
+	collapsed   map[string]bool // folder path -> collapsed
+	rows        []row
+	cursor      int // index into rows
 	width       int
 	height      int
 
+	// Fuzzy search
+	filter    textinput.Model
+	filtering bool // typing into the filter
+
 	// Form state
 	editing     bool
 	creating    bool
 	editIdx     int // index into connections when editing
 	fields      []textinput.Model
 	activeField formField
+	base        config.Connection // settings without a form field are carried over from here
+
+	// Result of the last connection test
+	testTitle  string
+	testLines  []string
+	testFailed bool
 }
@@ -36,6 +36,7 @@ func (h HomeScreen) formView() string {
 		}
 		b.WriteString(cursor + f.View() + "\n")
 	}
+	b.WriteString(h.testView())
 
 	content := b.String()
 	style := lipgloss.NewStyle().
@@ -61,54 +62,38 @@ func (h HomeScreen) listView() string {
 		Render("Connections:")
 	b.WriteString(connTitle + "\n\n")
 
-	if len(h.connections) == 0 {
-		empty := lipgloss.NewStyle().
-			Foreground(shared.ColorMuted).
-			Render("  No connections configured. Press 'c' to create one.")
-		b.WriteString(empty + "\n")
-	} else {
-		for i, conn := range h.connections {
-			prefix := "  "
-			if i == h.cursor {
-				prefix = "> "
-			}
-
-			name := conn.Name
-			var detail string
-			if conn.URL != "" {
-				detail = conn.URL
-				// Truncate long URLs
-				if len(detail) > 50 {
-					detail = detail[:47] + "..."
-				}
-			} else {
-				host := conn.Host
-				if conn.Port != 0 && conn.Port != 5432 {
-					host = fmt.Sprintf("%s:%d", host, conn.Port)
-				} else if conn.Port == 0 {
-					host = fmt.Sprintf("%s:5432", host)
-				} else {
-					host = fmt.Sprintf("%s:%d", host, conn.Port)
-				}
-				if conn.Database != "" {
-					host += "/" + conn.Database
-				}
-				detail = host
-			}
+	var lines []string
+	if h.filtering || h.IsFiltered() {
+		lines = append(lines, "  "+h.filter.View(), "")
+	}
 
-			nameStyle := lipgloss.NewStyle().Foreground(shared.ColorFg)
-			detailStyle := lipgloss.NewStyle().Foreground(shared.ColorMuted)
-			if i == h.cursor {
-				nameStyle = nameStyle.Foreground(shared.ColorPrimary).Bold(true)
+	mutedStyle := lipgloss.NewStyle().Foreground(shared.ColorMuted)
+
+	switch {
+	case len(h.connections) == 0:
+		lines = append(lines, mutedStyle.Render("  No connections configured. Press 'c' to create one."))
+	case len(h.rows) == 0:
+		lines = append(lines, mutedStyle.Render("  No matches"))
+	default:
+		// Keep the cursor in view when the list is taller than the screen
+		start, end := 0, len(h.rows)
+		if visible := h.height - 12; visible > 0 && len(h.rows) > visible {
+			start = min(max(h.cursor-visible/2, 0), len(h.rows)-visible)
+			end = start + visible
+		}
+		for i := start; i < end; i++ {
+			if h.rows[i].kind == rowSection && i > start {
+				lines = append(lines, "")
 			}
-
-			// Pad name to align details
-			paddedName := fmt.Sprintf("%-20s", name)
-			line := prefix + nameStyle.Render(paddedName) + detailStyle.Render(detail)
-			b.WriteString(line + "\n")
+			lines = append(lines, h.rowView(i))
 		}
 	}
 
+	// Left-align the rows as one block so folders line up when centered
+	b.WriteString(lipgloss.JoinVertical(lipgloss.Left, lines...) + "\n")
+
+	b.WriteString(h.testView())
+
 	// Center the content
 	content := b.String()
 	style := lipgloss.NewStyle().
@@ -119,6 +104,89 @@ func (h HomeScreen) listView() string {
 	return style.Render(content)
 }
 
+func (h HomeScreen) rowView(i int) string {
+	r := h.rows[i]
+
+	prefix := "  "
+	if i == h.cursor {
+		prefix = "> "
+	}
+	indent := strings.Repeat("  ", r.depth)
+
+	switch r.kind {
+	case rowSection:
+		sectionStyle := lipgloss.NewStyle().Bold(true).Foreground(shared.ColorMuted)
+		return "  " + sectionStyle.Render(r.label)
+
+	case rowFolder:
+		arrow := "▾"
+		if h.collapsed[r.path] {
+			arrow = "▸"
+		}
+		folderStyle := lipgloss.NewStyle().Foreground(shared.ColorSecondary)
+		if i == h.cursor {
+			folderStyle = folderStyle.Bold(true)
+		}
+		count := lipgloss.NewStyle().Foreground(shared.ColorMuted).Render(fmt.Sprintf(" (%d)", r.count))
+		return prefix + indent + folderStyle.Render(arrow+" "+r.label) + count
+	}
+
+	conn := h.connections[r.conn]
+	var detail string
+	if conn.URL != "" {
+		detail = conn.URL
+		// Truncate long URLs
+		if len(detail) > 50 {
+			detail = detail[:47] + "..."
+		}
+	} else if conn.Host == "" && conn.Service != "" {
+		detail = "service=" + conn.Service
+	} else {
+		host := conn.Host
+		if host == "" {
+			host = "$PGHOST"
+		}
+		if conn.Port != 0 && conn.Port != 5432 {
+			host = fmt.Sprintf("%s:%d", host, conn.Port)
+		} else if conn.Port == 0 {
+			host = fmt.Sprintf("%s:5432", host)
+		} else {
+			host = fmt.Sprintf("%s:%d", host, conn.Port)
+		}
+		if conn.Database != "" {
+			host += "/" + conn.Database
+		}
+		detail = host
+	}
+
+	if conn.SSH != nil {
+		detail += " via " + conn.SSH.Host
+	}
+
+	nameStyle := lipgloss.NewStyle().Foreground(shared.ColorFg)
+	detailStyle := lipgloss.NewStyle().Foreground(shared.ColorMuted)
+	if i == h.cursor {
+		nameStyle = nameStyle.Foreground(shared.ColorPrimary).Bold(true)
+	}
+
+	name := conn.Name
+	if conn.Favorite && !r.pinned {
+		name += " ★"
+	}
+	// Search results are flat, so show which folder each one is in
+	if h.IsFiltered() && conn.Group != "" {
+		detail = conn.Group + "  " + detail
+	}
+
+	// Pad name to align details
+	paddedName := fmt.Sprintf("%-*s", max(20-len(indent), len(name)+1), name)
+	line := prefix + indent + nameStyle.Render(paddedName) + detailStyle.Render(detail)
+	if badge := shared.EnvBadge(conn.Environment, h.envColors); badge != "" {
+		line += " " + badge
+	}
+	return line
+}
+
 func (h HomeScreen) View() string {
 	if h.editing || h.creating {
 		return h.formView()
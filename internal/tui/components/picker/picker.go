@@ -0,0 +1,131 @@
+package picker
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/zaffron/ezpg/internal/tui/shared"
+)
+
+// Picker is a filterable list to choose one item from. Typing narrows the
+// list; when nothing matches, the typed text itself can be chosen.
+type Picker struct {
+	title   string
+	items   []string
+	current string // marked in the list, e.g. the role in use
+	filter  textinput.Model
+	cursor  int
+	height  int
+}
+
+func New(title string, items []string, current string) Picker {
+	fi := textinput.New()
+	fi.Prompt = "> "
+	fi.PromptStyle = lipgloss.NewStyle().Foreground(shared.ColorWarning)
+	fi.CharLimit = 256
+	fi.Width = 40
+	fi.Focus()
+
+	p := Picker{
+		title:   title,
+		items:   items,
+		current: current,
+		filter:  fi,
+		height:  10,
+	}
+	for i, it := range items {
+		if it == current {
+			p.cursor = i
+		}
+	}
+	return p
+}
+
+func (p *Picker) SetHeight(h int) {
+	p.height = max(h, 3)
+}
+
+func (p Picker) matches() []string {
+	q := strings.ToLower(p.filter.Value())
+	if q == "" {
+		return p.items
+	}
+	var out []string
+	for _, it := range p.items {
+		if strings.Contains(strings.ToLower(it), q) {
+			out = append(out, it)
+		}
+	}
+	return out
+}
+
+// Value returns the highlighted item, or the typed text when nothing matches.
+func (p Picker) Value() string {
+	m := p.matches()
+	if len(m) == 0 {
+		return strings.TrimSpace(p.filter.Value())
+	}
+	return m[min(p.cursor, len(m)-1)]
+}
+
+func (p Picker) Update(msg tea.KeyMsg) (Picker, tea.Cmd) {
+	switch {
+	case key.Matches(msg, key.NewBinding(key.WithKeys("up", "ctrl+p", "ctrl+k"))):
+		if p.cursor > 0 {
+			p.cursor--
+		}
+		return p, nil
+	case key.Matches(msg, key.NewBinding(key.WithKeys("down", "ctrl+n", "ctrl+j"))):
+		if p.cursor < len(p.matches())-1 {
+			p.cursor++
+		}
+		return p, nil
+	}
+
+	var cmd tea.Cmd
+	p.filter, cmd = p.filter.Update(msg)
+	p.cursor = 0
+	return p, cmd
+}
+
+func (p Picker) View() string {
+	var b strings.Builder
+
+	title := lipgloss.NewStyle().Bold(true).Foreground(shared.ColorPrimary).Render(p.title)
+	b.WriteString(title + "\n\n")
+	b.WriteString(p.filter.View() + "\n\n")
+
+	m := p.matches()
+	if len(m) == 0 {
+		hint := "no match, enter uses the text as typed"
+		b.WriteString(lipgloss.NewStyle().Foreground(shared.ColorMuted).Render(hint) + "\n")
+	}
+
+	// Keep the cursor in view
+	start := 0
+	if p.cursor >= p.height {
+		start = p.cursor - p.height + 1
+	}
+	for i := start; i < len(m) && i < start+p.height; i++ {
+		style := lipgloss.NewStyle().Foreground(shared.ColorFg)
+		prefix := "  "
+		if i == p.cursor {
+			style = style.Foreground(shared.ColorPrimary).Bold(true)
+			prefix = "> "
+		}
+		line := prefix + m[i]
+		if m[i] == p.current {
+			line += " (current)"
+		}
+		b.WriteString(style.Render(line) + "\n")
+	}
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(shared.ColorPrimary).
+		Padding(1, 2).
+		Render(b.String())
+}
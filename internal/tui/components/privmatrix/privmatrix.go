@@ -0,0 +1,178 @@
+package privmatrix
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/zaffron/ezpg/internal/tui/shared"
+)
+
+// Matrix is a grid of roles by privileges, each cell toggled between granted
+// and not. It starts from what is granted now and reports the difference.
+type Matrix struct {
+	title      string
+	roles      []string
+	privileges []string
+	granted    [][]bool // as loaded
+	want       [][]bool // as edited
+	row, col   int
+	height     int
+}
+
+// Change is what to grant to or revoke from one role.
+type Change struct {
+	Role       string
+	Grant      bool
+	Privileges []string
+}
+
+func New(title string, roles, privileges []string, granted [][]bool) Matrix {
+	want := make([][]bool, len(granted))
+	for i, g := range granted {
+		want[i] = append([]bool(nil), g...)
+	}
+	return Matrix{
+		title:      title,
+		roles:      roles,
+		privileges: privileges,
+		granted:    granted,
+		want:       want,
+		height:     10,
+	}
+}
+
+func (m *Matrix) SetHeight(h int) {
+	m.height = max(h, 3)
+}
+
+// Changes lists the grants then the revokes that take the loaded privileges
+// to the edited ones, role by role.
+func (m Matrix) Changes() []Change {
+	var grants, revokes []Change
+	for i, role := range m.roles {
+		var add, drop []string
+		for j, p := range m.privileges {
+			switch {
+			case m.want[i][j] && !m.granted[i][j]:
+				add = append(add, p)
+			case !m.want[i][j] && m.granted[i][j]:
+				drop = append(drop, p)
+			}
+		}
+		if len(add) > 0 {
+			grants = append(grants, Change{Role: role, Grant: true, Privileges: add})
+		}
+		if len(drop) > 0 {
+			revokes = append(revokes, Change{Role: role, Privileges: drop})
+		}
+	}
+	return append(grants, revokes...)
+}
+
+func (m Matrix) Update(msg tea.KeyMsg) (Matrix, tea.Cmd) {
+	switch {
+	case key.Matches(msg, key.NewBinding(key.WithKeys("k", "up"))):
+		if m.row > 0 {
+			m.row--
+		}
+	case key.Matches(msg, key.NewBinding(key.WithKeys("j", "down"))):
+		if m.row < len(m.roles)-1 {
+			m.row++
+		}
+	case key.Matches(msg, key.NewBinding(key.WithKeys("h", "left"))):
+		if m.col > 0 {
+			m.col--
+		}
+	case key.Matches(msg, key.NewBinding(key.WithKeys("l", "right"))):
+		if m.col < len(m.privileges)-1 {
+			m.col++
+		}
+	case key.Matches(msg, key.NewBinding(key.WithKeys(" "))):
+		if m.row < len(m.want) {
+			m.want[m.row][m.col] = !m.want[m.row][m.col]
+		}
+	case key.Matches(msg, key.NewBinding(key.WithKeys("a"))):
+		// Everything on the row, or nothing when it all is already
+		if m.row < len(m.want) {
+			all := true
+			for _, w := range m.want[m.row] {
+				all = all && w
+			}
+			for j := range m.want[m.row] {
+				m.want[m.row][j] = !all
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m Matrix) View() string {
+	var b strings.Builder
+
+	title := lipgloss.NewStyle().Bold(true).Foreground(shared.ColorPrimary).Render(m.title)
+	b.WriteString(title + "\n\n")
+
+	nameW := 6
+	for _, r := range m.roles {
+		nameW = max(nameW, min(len(r), 24))
+	}
+	cellW := 0
+	for _, p := range m.privileges {
+		cellW = max(cellW, len(p))
+	}
+	cellW += 2
+
+	header := fmt.Sprintf("  %-*s", nameW, "role")
+	for _, p := range m.privileges {
+		header += fmt.Sprintf(" %-*s", cellW, p)
+	}
+	b.WriteString(lipgloss.NewStyle().Foreground(shared.ColorMuted).Render(header) + "\n")
+
+	// Keep the cursor in view
+	start := 0
+	if m.row >= m.height {
+		start = m.row - m.height + 1
+	}
+	for i := start; i < len(m.roles) && i < start+m.height; i++ {
+		name := m.roles[i]
+		if len(name) > nameW {
+			name = name[:nameW-3] + "..."
+		}
+		prefix := "  "
+		nameStyle := lipgloss.NewStyle().Foreground(shared.ColorFg)
+		if i == m.row {
+			prefix = "> "
+			nameStyle = nameStyle.Foreground(shared.ColorPrimary).Bold(true)
+		}
+		b.WriteString(nameStyle.Render(fmt.Sprintf("%s%-*s", prefix, nameW, name)))
+
+		for j := range m.privileges {
+			mark := "[ ]"
+			if m.want[i][j] {
+				mark = "[x]"
+			}
+			style := lipgloss.NewStyle().Foreground(shared.ColorFg)
+			if m.want[i][j] != m.granted[i][j] {
+				style = style.Foreground(shared.ColorWarning)
+			}
+			if i == m.row && j == m.col {
+				style = style.Reverse(true)
+			}
+			b.WriteString(" " + style.Render(mark) + strings.Repeat(" ", cellW-len(mark)))
+		}
+		b.WriteString("\n")
+	}
+
+	if n := len(m.Changes()); n > 0 {
+		b.WriteString("\n" + lipgloss.NewStyle().Foreground(shared.ColorWarning).Render(fmt.Sprintf("%d statements to generate", n)) + "\n")
+	}
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(shared.ColorPrimary).
+		Padding(1, 2).
+		Render(b.String())
+}
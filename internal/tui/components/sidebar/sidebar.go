@@ -1,7 +1,6 @@
 package sidebar
 
 import (
-	"fmt"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/key"
@@ -15,9 +14,16 @@ import (
 
 type item struct {
 	connName  string
+	database  string // set on database nodes and the tables under them
 	tableName string
 	schema    string
+	label     string // table name, schema-qualified unless on the search_path
 	isConn    bool
+	isDB      bool
+	matView   bool
+	security  bool   // the connection's security folder
+	role      string // set on the roles under the security folder
+	superuser bool
 	expanded  bool
 }
 
@@ -28,12 +34,15 @@ type Sidebar struct {
 	width       int
 	height      int
 	connected   map[string]bool
+	health      map[string]db.Health
+	current     map[string]string // connection -> database in use
+	envColors   map[string]string
 	filter      string
 	filtering   bool
 	filterInput textinput.Model
 }
 
-func New(connections []config.Connection) Sidebar {
+func New(connections []config.Connection, envColors map[string]string) Sidebar {
 	items := make([]item, 0, len(connections))
 	for _, c := range connections {
 		items = append(items, item{
@@ -52,10 +61,56 @@ func New(connections []config.Connection) Sidebar {
 		items:       items,
 		connections: connections,
 		connected:   make(map[string]bool),
+		health:      make(map[string]db.Health),
+		current:     make(map[string]string),
+		envColors:   envColors,
 		filterInput: fi,
 	}
 }
 
+// SetConnections replaces the connection list, e.g. after a reorder or an
+// edit. Connections still in it keep their tables, databases and state.
+func (s *Sidebar) SetConnections(connections []config.Connection) {
+	var selected item
+	if s.cursor >= 0 && s.cursor < len(s.items) {
+		selected = s.items[s.cursor]
+	}
+
+	// A connection's items follow its own, so they move as one block
+	blocks := make(map[string][]item)
+	for _, it := range s.items {
+		blocks[it.connName] = append(blocks[it.connName], it)
+	}
+
+	kept := make(map[string]bool, len(connections))
+	items := make([]item, 0, len(s.items))
+	for _, c := range connections {
+		kept[c.Name] = true
+		if block, ok := blocks[c.Name]; ok {
+			items = append(items, block...)
+		} else {
+			items = append(items, item{connName: c.Name, isConn: true})
+		}
+	}
+	for name := range blocks {
+		if !kept[name] {
+			delete(s.connected, name)
+			delete(s.health, name)
+			delete(s.current, name)
+		}
+	}
+
+	s.items = items
+	s.connections = connections
+	s.cursor = 0
+	for i, it := range items {
+		if it == selected {
+			s.cursor = i
+			break
+		}
+	}
+}
+
 func (s Sidebar) SelectedItem() (connName, schema, table string, isConn bool) {
 	if s.cursor < 0 || s.cursor >= len(s.items) {
 		return "", "", "", false
@@ -64,6 +119,16 @@ func (s Sidebar) SelectedItem() (connName, schema, table string, isConn bool) {
 	return it.connName, it.schema, it.tableName, it.isConn
 }
 
+// SelectedDatabase returns the database of the selected database node or
+// table, and whether the selection is a database node.
+func (s Sidebar) SelectedDatabase() (database string, isDB bool) {
+	if s.cursor < 0 || s.cursor >= len(s.items) {
+		return "", false
+	}
+	it := s.items[s.cursor]
+	return it.database, it.isDB
+}
+
 func (s *Sidebar) SetSize(w, h int) {
 	s.width = w
 	s.height = h
@@ -74,6 +139,11 @@ func (s *Sidebar) SetConnected(name string, connected bool) {
 	s.connected[name] = connected
 }
 
+// SetHealth sets the health a connected connection is drawn with.
+func (s *Sidebar) SetHealth(name string, h db.Health) {
+	s.health[name] = h
+}
+
 func (s *Sidebar) SetFilter(f string) {
 	s.filter = strings.ToLower(f)
 }
@@ -97,44 +167,218 @@ func (s *Sidebar) StopFilter(apply bool) {
 	}
 }
 
-func (s *Sidebar) LoadTables(connName string, tables []db.TableInfo) {
+// SetCurrentDatabase marks the database connName is working against.
+func (s *Sidebar) SetCurrentDatabase(connName, database string) {
+	s.current[connName] = database
+}
+
+// HasDatabases reports whether connName lists its databases.
+func (s Sidebar) HasDatabases(connName string) bool {
+	for _, it := range s.items {
+		if it.isDB && it.connName == connName {
+			return true
+		}
+	}
+	return false
+}
+
+// SelectTable moves the cursor to a listed table, clearing a filter that
+// hides it. It reports false when the table isn't in the tree, e.g. because
+// its database is collapsed.
+func (s *Sidebar) SelectTable(connName, database, schema, table string) bool {
+	for i, it := range s.items {
+		if it.isConn || it.isDB || it.connName != connName || it.schema != schema || it.tableName != table {
+			continue
+		}
+		if it.database != "" && it.database != database {
+			continue
+		}
+		if !strings.Contains(strings.ToLower(it.tableName), s.filter) {
+			s.StopFilter(false)
+		}
+		s.cursor = i
+		return true
+	}
+	return false
+}
+
+// LoadTables lists tables under the connection, or under the database node
+// when the connection lists its databases.
+func (s *Sidebar) LoadTables(connName, database string, tables []db.TableInfo) {
 	s.connected[connName] = true
+	byDB := s.HasDatabases(connName)
 
-	// Find the connection item
-	connIdx := -1
+	// Find the connection or database item
+	parent := -1
 	for i, it := range s.items {
-		if it.isConn && it.connName == connName {
-			connIdx = i
+		if it.connName != connName {
+			continue
+		}
+		if (!byDB && it.isConn) || (byDB && it.isDB && it.database == database) {
+			parent = i
 			break
 		}
 	}
-	if connIdx == -1 {
+	if parent == -1 {
 		return
 	}
 
-	// Remove old table entries for this connection
+	// Tables only carry a database when listed under one
+	tableDB := ""
+	if byDB {
+		tableDB = database
+	}
+
+	// Replace the old table entries under parent
 	newItems := make([]item, 0, len(s.items))
 	for i, it := range s.items {
-		if i == connIdx {
+		isTable := it.isTable() && it.connName == connName
+		switch {
+		case i == parent:
 			it.expanded = true
 			newItems = append(newItems, it)
-			// Insert new tables right after
 			for _, t := range tables {
 				newItems = append(newItems, item{
 					connName:  connName,
+					database:  tableDB,
 					tableName: t.Name,
 					schema:    t.Schema,
+					label:     t.FullName(),
 					isConn:    false,
+					matView:   t.MatView,
 				})
 			}
-		} else if !it.isConn && it.connName == connName {
-			// skip old table entries for this connection
+		case isTable && (!byDB || it.database == database):
 			continue
-		} else {
+		default:
+			newItems = append(newItems, it)
+		}
+	}
+	s.items = newItems
+	s.addSecurity(connName)
+	s.clampCursor()
+}
+
+// LoadDatabases lists databases under the connection, replacing its tables.
+func (s *Sidebar) LoadDatabases(connName string, databases []string) {
+	connIdx := -1
+	for i, it := range s.items {
+		if it.isConn && it.connName == connName {
+			connIdx = i
+			break
+		}
+	}
+	if connIdx == -1 {
+		return
+	}
+
+	newItems := make([]item, 0, len(s.items)+len(databases))
+	for i, it := range s.items {
+		switch {
+		case i == connIdx:
+			it.expanded = true
+			newItems = append(newItems, it)
+			for _, d := range databases {
+				newItems = append(newItems, item{connName: connName, database: d, isDB: true})
+			}
+		case it.isTable() && it.connName == connName, it.isDB && it.connName == connName:
+			continue
+		default:
+			newItems = append(newItems, it)
+		}
+	}
+	s.items = newItems
+	s.addSecurity(connName)
+	s.clampCursor()
+}
+
+// addSecurity puts the security folder at the end of an expanded
+// connection, unless it is there already.
+func (s *Sidebar) addSecurity(connName string) {
+	last := -1
+	for i, it := range s.items {
+		if it.connName != connName {
+			continue
+		}
+		if it.security {
+			return
+		}
+		last = i
+	}
+	if last == -1 {
+		return
+	}
+	s.items = append(s.items[:last+1], append([]item{{connName: connName, security: true}}, s.items[last+1:]...)...)
+}
+
+// LoadRoles lists roles under the connection's security folder.
+func (s *Sidebar) LoadRoles(connName string, roles []db.RoleInfo) {
+	newItems := make([]item, 0, len(s.items)+len(roles))
+	for _, it := range s.items {
+		if it.connName != connName {
+			newItems = append(newItems, it)
+			continue
+		}
+		switch {
+		case it.security:
+			it.expanded = true
+			newItems = append(newItems, it)
+			for _, r := range roles {
+				newItems = append(newItems, item{connName: connName, role: r.Name, superuser: r.Superuser})
+			}
+		case it.role != "":
+			continue
+		default:
 			newItems = append(newItems, it)
 		}
 	}
 	s.items = newItems
+	s.clampCursor()
+}
+
+// CollapseSecurity hides the roles under the connection's security folder.
+func (s *Sidebar) CollapseSecurity(connName string) {
+	newItems := make([]item, 0, len(s.items))
+	for _, it := range s.items {
+		if it.connName == connName {
+			if it.security {
+				it.expanded = false
+			} else if it.role != "" {
+				continue
+			}
+		}
+		newItems = append(newItems, it)
+	}
+	s.items = newItems
+	s.clampCursor()
+}
+
+// CollapseDatabase hides the tables under a database node.
+func (s *Sidebar) CollapseDatabase(connName, database string) {
+	newItems := make([]item, 0, len(s.items))
+	for _, it := range s.items {
+		if it.connName == connName && it.database == database {
+			if it.isDB {
+				it.expanded = false
+			} else {
+				continue
+			}
+		}
+		newItems = append(newItems, it)
+	}
+	s.items = newItems
+	s.clampCursor()
+}
+
+// isTable reports whether it is a table rather than a node or a role.
+func (it item) isTable() bool {
+	return !it.isConn && !it.isDB && !it.security && it.role == ""
+}
+
+func (s *Sidebar) clampCursor() {
+	if s.cursor >= len(s.items) {
+		s.cursor = len(s.items) - 1
+	}
 }
 
 func (s *Sidebar) CollapseConnection(connName string) {
@@ -150,13 +394,12 @@ func (s *Sidebar) CollapseConnection(connName string) {
 		}
 	}
 	s.items = newItems
-	if s.cursor >= len(s.items) {
-		s.cursor = len(s.items) - 1
-	}
+	s.clampCursor()
 }
 
 func (s *Sidebar) RemoveTables(connName string) {
 	s.connected[connName] = false
+	delete(s.health, connName)
 	s.CollapseConnection(connName)
 }
 
@@ -170,7 +413,7 @@ func (s Sidebar) filteredIndices() []int {
 	}
 	var indices []int
 	for i, it := range s.items {
-		if it.isConn || strings.Contains(strings.ToLower(it.tableName), s.filter) {
+		if it.isConn || it.isDB || it.security || strings.Contains(strings.ToLower(it.tableName+it.role), s.filter) {
 			indices = append(indices, i)
 		}
 	}
@@ -296,26 +539,106 @@ func (s Sidebar) renderItem(it item, selected bool) string {
 			prefix = "○   "
 		}
 		label = it.connName
+	} else if it.isDB {
+		prefix = "    ▸ "
+		if it.expanded {
+			prefix = "    ▾ "
+		}
+		label = it.database
+	} else if it.security {
+		prefix = "    ▸ "
+		if it.expanded {
+			prefix = "    ▾ "
+		}
+		label = "security"
+	} else if it.role != "" {
+		prefix = "        "
+		label = it.role
 	} else {
 		prefix = "    "
-		label = it.tableName
-		if it.schema != "" && it.schema != "public" {
-			label = fmt.Sprintf("%s.%s", it.schema, it.tableName)
+		if it.database != "" {
+			prefix = "        "
+		}
+		label = it.label
+		if it.matView {
+			label += " (mv)"
 		}
 	}
 
 	text := prefix + label
 
+	var env string
+	if it.isConn {
+		env = s.environment(it.connName)
+	}
+	// Leave room for the environment badge
+	limit := s.width - 4
+	if env != "" {
+		limit -= len(env) + 3
+	}
+
 	// Truncate if needed
-	if s.width > 4 && len(text) > s.width-4 {
-		text = text[:s.width-7] + "..."
+	if limit > 3 && len(text) > limit {
+		text = text[:limit-3] + "..."
 	}
 
-	if selected {
-		return lipgloss.NewStyle().Bold(true).Foreground(shared.ColorPrimary).Render(text)
+	switch {
+	case selected:
+		text = lipgloss.NewStyle().Bold(true).Foreground(shared.ColorPrimary).Render(text)
+	case it.isConn && s.connected[it.connName]:
+		text = lipgloss.NewStyle().Foreground(shared.HealthColor(s.health[it.connName])).Render(text)
+	case it.isDB && s.current[it.connName] == it.database:
+		text = lipgloss.NewStyle().Foreground(shared.ColorSuccess).Render(text)
+	case it.security:
+		text = lipgloss.NewStyle().Foreground(shared.ColorMuted).Render(text)
+	case it.superuser:
+		text = lipgloss.NewStyle().Foreground(shared.ColorWarning).Render(text)
 	}
-	if it.isConn && s.connected[it.connName] {
-		return lipgloss.NewStyle().Foreground(shared.ColorSuccess).Render(text)
+
+	if env != "" {
+		text += " " + shared.EnvBadge(env, s.envColors)
 	}
 	return text
 }
+
+func (s Sidebar) environment(connName string) string {
+	for _, c := range s.connections {
+		if c.Name == connName {
+			return c.Environment
+		}
+	}
+	return ""
+}
+
+// IsMatView reports whether the selected item is a materialized view.
+func (s Sidebar) IsMatView() bool {
+	if s.cursor < 0 || s.cursor >= len(s.items) {
+		return false
+	}
+	return s.items[s.cursor].matView
+}
+
+// IsSecurity reports whether the selected item is a security folder.
+func (s Sidebar) IsSecurity() bool {
+	if s.cursor < 0 || s.cursor >= len(s.items) {
+		return false
+	}
+	return s.items[s.cursor].security
+}
+
+// SelectedRole returns the role selected under a security folder, or "".
+func (s Sidebar) SelectedRole() string {
+	if s.cursor < 0 || s.cursor >= len(s.items) {
+		return ""
+	}
+	return s.items[s.cursor].role
+}
+
+// IsExpanded reports whether the selected connection, database node or
+// security folder is open.
+func (s Sidebar) IsExpanded() bool {
+	if s.cursor < 0 || s.cursor >= len(s.items) {
+		return false
+	}
+	return s.items[s.cursor].expanded
+}
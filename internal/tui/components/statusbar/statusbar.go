@@ -12,8 +12,12 @@ type StatusBar struct {
 	width   int
 	conn    string
 	table   string
+	tls     string
+	env     string // rendered environment badge
+	health  string // rendered health badge, empty when healthy
 	loading bool
 	loadMsg string
+	prompt  string // rendered input shown in place of the message
 	hints   []keyhints.Hint
 }
 
@@ -40,11 +44,28 @@ func (s *StatusBar) SetContext(conn, table string) {
 	s.table = table
 }
 
+// SetTLS sets the encryption shown next to the connection name.
+func (s *StatusBar) SetTLS(info string) {
+	s.tls = info
+}
+
+func (s *StatusBar) SetEnvBadge(badge string) {
+	s.env = badge
+}
+
+func (s *StatusBar) SetHealth(badge string) {
+	s.health = badge
+}
+
 func (s *StatusBar) SetLoading(loading bool, msg string) {
 	s.loading = loading
 	s.loadMsg = msg
 }
 
+func (s *StatusBar) SetPrompt(view string) {
+	s.prompt = view
+}
+
 func (s *StatusBar) SetHints(hints []keyhints.Hint) {
 	s.hints = hints
 }
@@ -61,6 +82,15 @@ func (s StatusBar) infoView() string {
 	var ctx string
 	if s.conn != "" {
 		ctx = lipgloss.NewStyle().Foreground(shared.ColorSecondary).Render(s.conn)
+		if s.env != "" {
+			ctx = s.env + " " + ctx
+		}
+		if s.health != "" {
+			ctx += " " + s.health
+		}
+		if s.tls != "" {
+			ctx += lipgloss.NewStyle().Foreground(shared.ColorMuted).Render(" [" + s.tls + "]")
+		}
 		if s.table != "" {
 			ctx += lipgloss.NewStyle().Foreground(shared.ColorMuted).Render(" > ") +
 				lipgloss.NewStyle().Foreground(shared.ColorFg).Render(s.table)
@@ -68,7 +98,9 @@ func (s StatusBar) infoView() string {
 	}
 
 	var msg string
-	if s.loading {
+	if s.prompt != "" {
+		msg = s.prompt
+	} else if s.loading {
 		msg = lipgloss.NewStyle().Foreground(shared.ColorWarning).Render("⏳ " + s.loadMsg)
 	} else if s.message != "" {
 		if s.isErr {
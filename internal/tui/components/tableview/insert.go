@@ -0,0 +1,266 @@
+package tableview
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/zaffron/ezpg/internal/db"
+	"github.com/zaffron/ezpg/internal/tui/shared"
+)
+
+type insertField struct {
+	col   db.ColumnInfo
+	oid   uint32
+	input textinput.Model
+	kind  db.CellValueKind
+}
+
+// locked fields can only take their default: generated columns and GENERATED ALWAYS identities.
+func (f insertField) locked() bool {
+	return f.col.IsGenerated || f.col.Identity == "ALWAYS"
+}
+
+// StartInsert begins inserting a new row. cols describes the table's columns;
+// when it is empty the form falls back to the result column names.
+func (tv *TableView) StartInsert(cols []db.ColumnInfo) {
+	if len(cols) == 0 {
+		for i, name := range tv.columns {
+			cols = append(cols, db.ColumnInfo{
+				Name:       name,
+				DataType:   db.TypeName(tv.ColumnType(i)),
+				IsNullable: true,
+			})
+		}
+	}
+
+	tv.insertFields = make([]insertField, len(cols))
+	for i, c := range cols {
+		ti := textinput.New()
+		ti.Prompt = ""
+		ti.CharLimit = 0
+		ti.Width = 30
+		ti.TextStyle = lipgloss.NewStyle().Foreground(shared.ColorFg)
+
+		kind := db.CellDefault
+		if c.IsRequired() {
+			kind = db.CellText
+		}
+
+		var oid uint32
+		for j, name := range tv.columns {
+			if name == c.Name {
+				oid = tv.ColumnType(j)
+				break
+			}
+		}
+
+		tv.insertFields[i] = insertField{col: c, oid: oid, input: ti, kind: kind}
+	}
+
+	tv.inserting = true
+	tv.insertCol = 0
+	tv.focusInsertField()
+}
+
+func (tv *TableView) CancelInsert() {
+	tv.inserting = false
+	tv.insertFields = nil
+}
+
+func (tv *TableView) InsertCol() int { return tv.insertCol }
+
+// InsertColumns returns the column names of the insert form, in order.
+func (tv *TableView) InsertColumns() []string {
+	names := make([]string, len(tv.insertFields))
+	for i, f := range tv.insertFields {
+		names[i] = f.col.Name
+	}
+	return names
+}
+
+func (tv *TableView) InsertValues() []db.CellValue {
+	values := make([]db.CellValue, len(tv.insertFields))
+	for i, f := range tv.insertFields {
+		values[i] = db.CellValue{Kind: f.kind, Text: f.input.Value()}
+	}
+	return values
+}
+
+// ValidateInsert checks required fields and typed values, returning the
+// index of the first offending field.
+func (tv *TableView) ValidateInsert() (int, error) {
+	for i, f := range tv.insertFields {
+		switch f.kind {
+		case db.CellDefault:
+			if f.col.IsRequired() {
+				return i, fmt.Errorf("%s is required", f.col.Name)
+			}
+		case db.CellNull:
+			if !f.col.IsNullable {
+				return i, fmt.Errorf("%s cannot be NULL", f.col.Name)
+			}
+		case db.CellText:
+			if err := db.ValidateValue(f.oid, f.input.Value()); err != nil {
+				return i, fmt.Errorf("%s: %w", f.col.Name, err)
+			}
+		}
+	}
+	return -1, nil
+}
+
+// SetInsertKind switches the active field to DEFAULT, NULL or a typed value.
+func (tv *TableView) SetInsertKind(kind db.CellValueKind) {
+	if tv.insertCol >= len(tv.insertFields) || tv.insertFields[tv.insertCol].locked() {
+		return
+	}
+	tv.insertFields[tv.insertCol].kind = kind
+}
+
+// UpdateInsert forwards a key to the active field; typing switches it to a value.
+func (tv *TableView) UpdateInsert(msg tea.Msg) tea.Cmd {
+	if tv.insertCol >= len(tv.insertFields) {
+		return nil
+	}
+	f := &tv.insertFields[tv.insertCol]
+	if f.locked() {
+		return nil
+	}
+
+	before := f.input.Value()
+	var cmd tea.Cmd
+	f.input, cmd = f.input.Update(msg)
+	if f.input.Value() != before {
+		f.kind = db.CellText
+	}
+	return cmd
+}
+
+func (tv *TableView) NextInsertCol() bool {
+	if tv.insertCol < len(tv.insertFields)-1 {
+		tv.insertCol++
+		tv.focusInsertField()
+		return false
+	}
+	return true // all columns filled
+}
+
+func (tv *TableView) PrevInsertCol() {
+	if tv.insertCol > 0 {
+		tv.insertCol--
+		tv.focusInsertField()
+	}
+}
+
+func (tv *TableView) FocusInsertCol(i int) {
+	if i >= 0 && i < len(tv.insertFields) {
+		tv.insertCol = i
+		tv.focusInsertField()
+	}
+}
+
+func (tv *TableView) focusInsertField() {
+	for i := range tv.insertFields {
+		if i == tv.insertCol {
+			tv.insertFields[i].input.Focus()
+		} else {
+			tv.insertFields[i].input.Blur()
+		}
+	}
+}
+
+func (tv TableView) insertView() string {
+	var b strings.Builder
+
+	tname := tv.tableName
+	if tv.schema != "" && tv.schema != "public" {
+		tname = tv.schema + "." + tname
+	}
+	title := lipgloss.NewStyle().Bold(true).Foreground(shared.ColorSuccess).Render("INSERT INTO " + tname)
+	b.WriteString(title + "\n\n")
+
+	nameW, typeW := 4, 4
+	for _, f := range tv.insertFields {
+		nameW = max(nameW, len(f.col.Name)+2)
+		typeW = max(typeW, len(f.col.DataType))
+	}
+	nameW = min(nameW, 30)
+	typeW = min(typeW, 24)
+
+	// Scroll window around the active field
+	maxLines := max(tv.height-4, 1)
+	start := 0
+	if tv.insertCol >= maxLines {
+		start = tv.insertCol - maxLines + 1
+	}
+	end := min(start+maxLines, len(tv.insertFields))
+
+	muted := lipgloss.NewStyle().Foreground(shared.ColorMuted)
+	for i := start; i < end; i++ {
+		f := tv.insertFields[i]
+
+		cursor := "  "
+		nameStyle := lipgloss.NewStyle().Foreground(shared.ColorFg)
+		if i == tv.insertCol {
+			cursor = "> "
+			nameStyle = nameStyle.Foreground(shared.ColorPrimary).Bold(true)
+		}
+
+		name := f.col.Name
+		if f.col.IsRequired() {
+			name += " *"
+		}
+
+		var value string
+		switch f.kind {
+		case db.CellDefault:
+			value = lipgloss.NewStyle().Foreground(shared.ColorSecondary).Render("DEFAULT")
+		case db.CellNull:
+			value = lipgloss.NewStyle().Foreground(shared.ColorWarning).Render("NULL")
+		default:
+			value = f.input.View()
+		}
+
+		line := cursor +
+			nameStyle.Render(fmt.Sprintf("%-*s", nameW, truncate(name, nameW))) + " " +
+			muted.Render(fmt.Sprintf("%-*s", typeW, truncate(f.col.DataType, typeW))) + "  " +
+			value
+		if note := columnNote(f.col); note != "" {
+			line += "  " + muted.Render(note)
+		}
+		b.WriteString(line + "\n")
+	}
+
+	return b.String()
+}
+
+// columnNote summarizes how the database fills a column when no value is given.
+func columnNote(c db.ColumnInfo) string {
+	switch {
+	case c.IsGenerated:
+		return "generated"
+	case c.Identity != "":
+		return "identity " + strings.ToLower(c.Identity)
+	case c.IsSerial():
+		return "serial"
+	case c.Default != "":
+		return "default " + truncate(c.Default, 30)
+	case c.IsRequired():
+		return "required"
+	case c.IsNullable:
+		return "nullable"
+	}
+	return ""
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	if n <= 3 {
+		return s[:n]
+	}
+	return s[:n-3] + "..."
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/textarea"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/zaffron/ezpg/internal/db"
@@ -16,13 +17,18 @@ import (
 // Padding(0, 1) = 2 chars + BorderRight = 1 char = 3 total.
 const cellExtraWidth = 3
 
+// editMaxHeight is the height of the cell editor for multiline types.
+const editMaxHeight = 5
+
 type TableView struct {
 	table     table.Model
 	connName  string
 	schema    string
 	tableName string
 	columns   []string
+	colTypes  []uint32
 	rows      [][]string
+	values    [][]any
 	width     int
 	height    int
 	page      int
@@ -33,15 +39,23 @@ type TableView struct {
 	// Horizontal scroll
 	colOffset   int // first visible column index
 	visibleCols int // number of currently visible columns
+	colCursor   int // focused column index
 
 	// For cell editing
 	editingRow int
 	editingCol int
 	editing    bool
-	editValue  string
+	editInput  textarea.Model
+	formatter  db.Formatter
+	bulkEdit   bool // the edit sets one column across the selected rows
+
+	// Row selection for bulk actions
+	marked map[int]bool
+	visual bool
+	anchor int // row where visual mode started
 	// For new row insertion
 	inserting    bool
-	insertValues []string
+	insertFields []insertField
 	insertCol    int
 }
 
@@ -72,9 +86,21 @@ func New() TableView {
 
 	t.SetStyles(s)
 
+	ei := textarea.New()
+	ei.Prompt = ""
+	ei.ShowLineNumbers = false
+	ei.CharLimit = 0
+	ei.KeyMap.InsertNewline = key.NewBinding(key.WithKeys("alt+enter", "ctrl+j"))
+	// ctrl+n and ctrl+d are taken by "set NULL" and "set DEFAULT"
+	ei.KeyMap.LineNext = key.NewBinding(key.WithKeys("down"))
+	ei.KeyMap.DeleteCharacterForward = key.NewBinding(key.WithKeys("delete"))
+	ei.FocusedStyle.CursorLine = lipgloss.NewStyle()
+	ei.FocusedStyle.Base = lipgloss.NewStyle().Foreground(shared.ColorFg)
+
 	return TableView{
-		table:    t,
-		pageSize: 100,
+		table:     t,
+		editInput: ei,
+		pageSize:  100,
 	}
 }
 
@@ -82,21 +108,36 @@ func (tv *TableView) SetSize(w, h int) {
 	tv.width = w
 	tv.height = h
 	tv.table.SetWidth(w)
-	tv.table.SetHeight(h - 3) // leave room for info line
+	tv.table.SetHeight(tv.tableHeight())
+	tv.editInput.SetWidth(w - 2)
 	tv.rebuildTable()
 }
 
+// tableHeight leaves room for the info line and, while editing, the cell editor.
+func (tv *TableView) tableHeight() int {
+	h := tv.height - 3
+	if tv.editing {
+		h -= tv.editInput.Height() + 1
+	}
+	return h
+}
+
 func (tv *TableView) SetData(connName, schema, tableName string, result *db.QueryResult) {
 	tv.connName = connName
 	tv.schema = schema
 	tv.tableName = tableName
 	tv.columns = result.Columns
+	tv.colTypes = result.ColumnTypes
 	tv.rows = result.Rows
+	tv.values = result.Values
 	tv.totalRows = result.RowCount
 	tv.hasData = true
 	tv.editing = false
 	tv.inserting = false
 	tv.colOffset = 0
+	tv.colCursor = 0
+	tv.marked = nil
+	tv.visual = false
 
 	tv.rebuildTable()
 	tv.table.GotoTop()
@@ -104,7 +145,9 @@ func (tv *TableView) SetData(connName, schema, tableName string, result *db.Quer
 
 func (tv *TableView) SetQueryResult(result *db.QueryResult) {
 	tv.columns = result.Columns
+	tv.colTypes = result.ColumnTypes
 	tv.rows = result.Rows
+	tv.values = result.Values
 	tv.totalRows = result.RowCount
 	tv.hasData = true
 	tv.schema = ""
@@ -112,14 +155,45 @@ func (tv *TableView) SetQueryResult(result *db.QueryResult) {
 	tv.editing = false
 	tv.inserting = false
 	tv.colOffset = 0
+	tv.colCursor = 0
+	tv.marked = nil
+	tv.visual = false
 
 	tv.rebuildTable()
 	tv.table.GotoTop()
 }
 
+// Clear drops the data shown, e.g. when its connection moved to another
+// database and the rows no longer belong to the one in use.
+func (tv *TableView) Clear() {
+	tv.connName = ""
+	tv.schema = ""
+	tv.tableName = ""
+	tv.columns = nil
+	tv.colTypes = nil
+	tv.rows = nil
+	tv.values = nil
+	tv.totalRows = 0
+	tv.page = 0
+	tv.hasData = false
+	tv.editing = false
+	tv.bulkEdit = false
+	tv.inserting = false
+	tv.marked = nil
+	tv.visual = false
+	tv.table.SetRows(nil)
+}
+
+// SetReport shows a read-only result titled like a table, e.g. a privileges
+// report. With no schema it is guarded like a query result.
+func (tv *TableView) SetReport(title string, result *db.QueryResult) {
+	tv.SetQueryResult(result)
+	tv.tableName = title
+}
+
 // idealColWidth computes the ideal width for a column based on header and data.
 func (tv *TableView) idealColWidth(colIdx int) int {
-	w := len(tv.columns[colIdx])
+	w := len(tv.columns[colIdx]) + 1 // room for the focus marker
 
 	sample := min(50, len(tv.rows))
 	for _, row := range tv.rows[:sample] {
@@ -176,7 +250,11 @@ func (tv *TableView) rebuildTable() {
 	for i := 0; i < tv.visibleCols; i++ {
 		colIdx := tv.colOffset + i
 		w := tv.idealColWidth(colIdx)
-		visCols[i] = table.Column{Title: tv.columns[colIdx], Width: w}
+		title := tv.columns[colIdx]
+		if colIdx == tv.colCursor {
+			title = "▸" + title
+		}
+		visCols[i] = table.Column{Title: title, Width: w}
 		totalUsed += w + cellExtraWidth
 	}
 
@@ -201,6 +279,9 @@ func (tv *TableView) rebuildTable() {
 				vRow[j] = row[colIdx]
 			}
 		}
+		if tv.isSelected(i) {
+			vRow[0] = "✓ " + vRow[0]
+		}
 		rows[i] = vRow
 	}
 
@@ -233,6 +314,116 @@ func (tv *TableView) ScrollLeft() {
 	}
 }
 
+// focusCol moves the column cursor to col, scrolling it into view.
+func (tv *TableView) focusCol(col int) {
+	if col < 0 || col >= len(tv.columns) {
+		return
+	}
+
+	cursor := tv.table.Cursor()
+	tv.colCursor = col
+	if col < tv.colOffset {
+		tv.colOffset = col
+	}
+	tv.rebuildTable()
+	for col >= tv.colOffset+tv.visibleCols && tv.colOffset < col {
+		tv.colOffset++
+		tv.rebuildTable()
+	}
+	if cursor >= 0 && cursor < len(tv.rows) {
+		tv.table.SetCursor(cursor)
+	}
+}
+
+// refresh rebuilds the table in place, keeping the row cursor.
+func (tv *TableView) refresh() {
+	cursor := tv.table.Cursor()
+	tv.rebuildTable()
+	if cursor >= 0 && cursor < len(tv.rows) {
+		tv.table.SetCursor(cursor)
+	}
+}
+
+func (tv *TableView) isSelected(row int) bool {
+	if tv.marked[row] {
+		return true
+	}
+	if !tv.visual {
+		return false
+	}
+	cursor := tv.table.Cursor()
+	return row >= min(tv.anchor, cursor) && row <= max(tv.anchor, cursor)
+}
+
+// ToggleMark marks or unmarks the row under the cursor.
+func (tv *TableView) ToggleMark() {
+	cursor := tv.table.Cursor()
+	if cursor < 0 || cursor >= len(tv.rows) {
+		return
+	}
+	if tv.marked == nil {
+		tv.marked = make(map[int]bool)
+	}
+	if tv.marked[cursor] {
+		delete(tv.marked, cursor)
+	} else {
+		tv.marked[cursor] = true
+	}
+	tv.refresh()
+}
+
+// ToggleVisual starts a range selection at the cursor, or ends it by marking the range.
+func (tv *TableView) ToggleVisual() {
+	if !tv.visual {
+		tv.visual = true
+		tv.anchor = tv.table.Cursor()
+		tv.refresh()
+		return
+	}
+
+	if tv.marked == nil {
+		tv.marked = make(map[int]bool)
+	}
+	for _, i := range tv.SelectedIndices() {
+		tv.marked[i] = true
+	}
+	tv.visual = false
+	tv.refresh()
+}
+
+func (tv *TableView) ClearSelection() {
+	tv.marked = nil
+	tv.visual = false
+	if tv.hasData {
+		tv.refresh()
+	}
+}
+
+func (tv *TableView) IsVisual() bool     { return tv.visual }
+func (tv *TableView) HasSelection() bool { return tv.visual || len(tv.marked) > 0 }
+
+// SelectedIndices returns the selected row indices in ascending order.
+func (tv *TableView) SelectedIndices() []int {
+	var indices []int
+	for i := range tv.rows {
+		if tv.isSelected(i) {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
+
+// ValuesAt returns the raw decoded values of row i.
+func (tv *TableView) ValuesAt(i int) []any {
+	if i < 0 || i >= len(tv.values) {
+		return nil
+	}
+	return tv.values[i]
+}
+
+func (tv *TableView) MoveColLeft()  { tv.focusCol(tv.colCursor - 1) }
+func (tv *TableView) MoveColRight() { tv.focusCol(tv.colCursor + 1) }
+
 func (tv *TableView) Page() int         { return tv.page }
 func (tv *TableView) PageSize() int     { return tv.pageSize }
 func (tv *TableView) HasData() bool     { return tv.hasData }
@@ -243,6 +434,18 @@ func (tv *TableView) Columns() []string { return tv.columns }
 func (tv *TableView) IsEditing() bool   { return tv.editing }
 func (tv *TableView) IsInserting() bool { return tv.inserting }
 func (tv *TableView) Cursor() int       { return tv.table.Cursor() }
+func (tv *TableView) FocusedCol() int   { return tv.colCursor }
+
+// VisibleColumns returns the index of the first visible column and how many are visible.
+func (tv *TableView) VisibleColumns() (int, int) { return tv.colOffset, tv.visibleCols }
+
+// ColumnType returns the type OID of column i, or 0 if unknown.
+func (tv *TableView) ColumnType(i int) uint32 {
+	if i < 0 || i >= len(tv.colTypes) {
+		return 0
+	}
+	return tv.colTypes[i]
+}
 
 func (tv *TableView) SelectedRow() []string {
 	cursor := tv.table.Cursor()
@@ -252,6 +455,15 @@ func (tv *TableView) SelectedRow() []string {
 	return tv.rows[cursor]
 }
 
+// SelectedValues returns the raw decoded values of the selected row.
+func (tv *TableView) SelectedValues() []any {
+	cursor := tv.table.Cursor()
+	if cursor < 0 || cursor >= len(tv.values) {
+		return nil
+	}
+	return tv.values[cursor]
+}
+
 func (tv *TableView) NextPage() {
 	tv.page++
 }
@@ -262,77 +474,83 @@ func (tv *TableView) PrevPage() {
 	}
 }
 
-// StartEdit begins editing the selected cell
-func (tv *TableView) StartEdit() (int, int, string) {
+// StartEdit begins editing the focused cell of the selected row
+func (tv *TableView) StartEdit(f db.Formatter) (int, int, string) {
 	cursor := tv.table.Cursor()
 	if cursor < 0 || cursor >= len(tv.rows) {
 		return -1, -1, ""
 	}
 	tv.editing = true
 	tv.editingRow = cursor
-	tv.editingCol = 0
-	tv.editValue = tv.rows[cursor][0]
-	return cursor, 0, tv.editValue
+	tv.formatter = f
+	tv.bulkEdit = false
+	tv.loadEditCol(tv.colCursor)
+	return cursor, tv.editingCol, tv.EditValue()
 }
 
-func (tv *TableView) CancelEdit() {
-	tv.editing = false
+// StartBulkEdit edits the focused column for every selected row at once.
+func (tv *TableView) StartBulkEdit(f db.Formatter) bool {
+	if !tv.HasSelection() {
+		return false
+	}
+	row, _, _ := tv.StartEdit(f)
+	tv.bulkEdit = row >= 0
+	return tv.bulkEdit
 }
 
-func (tv *TableView) EditingCol() int   { return tv.editingCol }
-func (tv *TableView) EditingRow() int   { return tv.editingRow }
-func (tv *TableView) EditValue() string { return tv.editValue }
+func (tv *TableView) IsBulkEdit() bool { return tv.bulkEdit }
 
-func (tv *TableView) SetEditValue(v string) {
-	tv.editValue = v
-}
+// loadEditCol points the cell editor at col, starting from the cell's full value.
+func (tv *TableView) loadEditCol(col int) {
+	tv.editingCol = col
 
-func (tv *TableView) NextEditCol() {
-	if tv.editingCol < len(tv.columns)-1 {
-		tv.editingCol++
-		tv.editValue = tv.rows[tv.editingRow][tv.editingCol]
+	var v any
+	if tv.editingRow < len(tv.values) && col < len(tv.values[tv.editingRow]) {
+		v = tv.values[tv.editingRow][col]
 	}
-}
+	oid := tv.ColumnType(col)
+	value := tv.formatter.EditText(v, oid)
 
-func (tv *TableView) PrevEditCol() {
-	if tv.editingCol > 0 {
-		tv.editingCol--
-		tv.editValue = tv.rows[tv.editingRow][tv.editingCol]
+	height := 1
+	if db.IsMultilineType(oid) || strings.Contains(value, "\n") {
+		height = editMaxHeight
 	}
-}
+	tv.editInput.SetHeight(height)
+	tv.editInput.SetWidth(tv.width - 2)
+	tv.editInput.SetValue(value)
+	tv.editInput.Focus()
 
-// StartInsert begins inserting a new row
-func (tv *TableView) StartInsert() {
-	tv.inserting = true
-	tv.insertValues = make([]string, len(tv.columns))
-	tv.insertCol = 0
+	tv.table.SetHeight(tv.tableHeight())
+	tv.focusCol(col)
 }
 
-func (tv *TableView) CancelInsert() {
-	tv.inserting = false
-	tv.insertValues = nil
+func (tv *TableView) CancelEdit() {
+	tv.editing = false
+	tv.bulkEdit = false
+	tv.editInput.Blur()
+	tv.table.SetHeight(tv.tableHeight())
 }
 
-func (tv *TableView) InsertValues() []string { return tv.insertValues }
-func (tv *TableView) InsertCol() int         { return tv.insertCol }
+func (tv *TableView) EditingCol() int   { return tv.editingCol }
+func (tv *TableView) EditingRow() int   { return tv.editingRow }
+func (tv *TableView) EditValue() string { return tv.editInput.Value() }
 
-func (tv *TableView) SetInsertValue(v string) {
-	if tv.insertCol < len(tv.insertValues) {
-		tv.insertValues[tv.insertCol] = v
-	}
+// UpdateEdit forwards a key to the cell editor.
+func (tv *TableView) UpdateEdit(msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+	tv.editInput, cmd = tv.editInput.Update(msg)
+	return cmd
 }
 
-func (tv *TableView) NextInsertCol() bool {
-	if tv.insertCol < len(tv.columns)-1 {
-		tv.insertCol++
-		return false
+func (tv *TableView) NextEditCol() {
+	if tv.editingCol < len(tv.columns)-1 {
+		tv.loadEditCol(tv.editingCol + 1)
 	}
-	return true // all columns filled
 }
 
-func (tv *TableView) PrevInsertCol() {
-	if tv.insertCol > 0 {
-		tv.insertCol--
+func (tv *TableView) PrevEditCol() {
+	if tv.editingCol > 0 {
+		tv.loadEditCol(tv.editingCol - 1)
 	}
 }
 
@@ -343,9 +561,9 @@ func (tv *TableView) Update(msg tea.KeyMsg) (TableView, tea.Cmd) {
 	case key.Matches(msg, key.NewBinding(key.WithKeys("k", "up"))):
 		tv.table.MoveUp(1)
 	case key.Matches(msg, key.NewBinding(key.WithKeys("h", "left"))):
-		tv.ScrollLeft()
+		tv.MoveColLeft()
 	case key.Matches(msg, key.NewBinding(key.WithKeys("l", "right"))):
-		tv.ScrollRight()
+		tv.MoveColRight()
 	case key.Matches(msg, key.NewBinding(key.WithKeys("g"))):
 		tv.table.GotoTop()
 	case key.Matches(msg, key.NewBinding(key.WithKeys("G"))):
@@ -357,6 +575,9 @@ func (tv *TableView) Update(msg tea.KeyMsg) (TableView, tea.Cmd) {
 		h := tv.height / 2
 		tv.table.MoveUp(h)
 	}
+	if tv.visual {
+		tv.refresh()
+	}
 	return *tv, nil
 }
 
@@ -371,18 +592,27 @@ func (tv TableView) View(active bool) string {
 		return placeholder
 	}
 
+	if tv.inserting {
+		return tv.insertView()
+	}
+
 	var b strings.Builder
 
 	// Table
 	b.WriteString(tv.table.View())
 	b.WriteString("\n")
 
-	// Insert row indicator
-	if tv.inserting {
-		insertLine := lipgloss.NewStyle().Foreground(shared.ColorSuccess).
-			Render(fmt.Sprintf("  INSERT: column %d/%d [%s]",
-				tv.insertCol+1, len(tv.columns), tv.columns[tv.insertCol]))
-		b.WriteString(insertLine + "\n")
+	// Cell editor
+	if tv.editing {
+		label := "  EDIT " + tv.columns[tv.editingCol]
+		if tv.bulkEdit {
+			label = fmt.Sprintf("  SET %s on %d rows", tv.columns[tv.editingCol], len(tv.SelectedIndices()))
+		}
+		if t := db.TypeName(tv.ColumnType(tv.editingCol)); t != "" {
+			label += " (" + t + ")"
+		}
+		b.WriteString(lipgloss.NewStyle().Foreground(shared.ColorWarning).Render(label) + "\n")
+		b.WriteString(tv.editInput.View() + "\n")
 	}
 
 	// Info line
@@ -395,6 +625,13 @@ func (tv TableView) View(active bool) string {
 		info = " " + tname + " |" + info
 	}
 
+	if n := len(tv.SelectedIndices()); n > 0 {
+		info += fmt.Sprintf(" | %d selected", n)
+	}
+	if tv.visual {
+		info += " | VISUAL"
+	}
+
 	// Column scroll indicator
 	if len(tv.columns) > tv.visibleCols {
 		info += fmt.Sprintf(" | cols %d-%d/%d (h/l)",
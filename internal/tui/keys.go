@@ -21,6 +21,33 @@ type KeyMap struct {
 	Delete       key.Binding
 	Insert       key.Binding
 	Search       key.Binding
+	Reload       key.Binding
+	Mark         key.Binding
+	Visual       key.Binding
+	Duplicate    key.Binding
+	BulkUpdate   key.Binding
+	PasteRows    key.Binding
+	TestConn     key.Binding
+	Databases    key.Binding
+	Role         key.Binding
+	SearchPath   key.Binding
+	Admin        key.Binding
+	Sort         key.Binding
+	SortReverse  key.Binding
+	Explain      key.Binding
+	Cancel       key.Binding
+	Terminate    key.Binding
+	ResetStats   key.Binding
+	Faster       key.Binding
+	Slower       key.Binding
+	Maintenance  key.Binding
+	NonDefault   key.Binding
+	Access       key.Binding
+	Policies     key.Binding
+	Grants       key.Binding
+	Listen       key.Binding
+	Unlisten     key.Binding
+	Notify       key.Binding
 	NextPage     key.Binding
 	PrevPage     key.Binding
 	Tab          key.Binding
@@ -92,6 +119,114 @@ var Keys = KeyMap{
 		key.WithKeys("/"),
 		key.WithHelp("/", "filter"),
 	),
+	Reload: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "reload"),
+	),
+	Mark: key.NewBinding(
+		key.WithKeys(" "),
+		key.WithHelp("space", "mark row"),
+	),
+	Visual: key.NewBinding(
+		key.WithKeys("v"),
+		key.WithHelp("v", "visual select"),
+	),
+	Duplicate: key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "duplicate rows"),
+	),
+	BulkUpdate: key.NewBinding(
+		key.WithKeys("u"),
+		key.WithHelp("u", "set column"),
+	),
+	PasteRows: key.NewBinding(
+		key.WithKeys("P"),
+		key.WithHelp("P", "paste rows"),
+	),
+	TestConn: key.NewBinding(
+		key.WithKeys("ctrl+t"),
+		key.WithHelp("ctrl+t", "test connection"),
+	),
+	Databases: key.NewBinding(
+		key.WithKeys("D"),
+		key.WithHelp("D", "list databases"),
+	),
+	Role: key.NewBinding(
+		key.WithKeys("R"),
+		key.WithHelp("R", "set role"),
+	),
+	SearchPath: key.NewBinding(
+		key.WithKeys("S"),
+		key.WithHelp("S", "set search_path"),
+	),
+	Admin: key.NewBinding(
+		key.WithKeys("A"),
+		key.WithHelp("A", "admin screens"),
+	),
+	Sort: key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "sort by next column"),
+	),
+	SortReverse: key.NewBinding(
+		key.WithKeys("S"),
+		key.WithHelp("S", "reverse sort"),
+	),
+	Explain: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "explain"),
+	),
+	Cancel: key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "cancel query"),
+	),
+	Terminate: key.NewBinding(
+		key.WithKeys("T"),
+		key.WithHelp("T", "terminate session"),
+	),
+	ResetStats: key.NewBinding(
+		key.WithKeys("R"),
+		key.WithHelp("R", "reset statistics"),
+	),
+	Faster: key.NewBinding(
+		key.WithKeys("-"),
+		key.WithHelp("-", "refresh more often"),
+	),
+	Slower: key.NewBinding(
+		key.WithKeys("+", "="),
+		key.WithHelp("+", "refresh less often"),
+	),
+	Maintenance: key.NewBinding(
+		key.WithKeys("m"),
+		key.WithHelp("m", "maintenance"),
+	),
+	NonDefault: key.NewBinding(
+		key.WithKeys("*"),
+		key.WithHelp("*", "only non-default settings"),
+	),
+	Access: key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "who can access the table"),
+	),
+	Policies: key.NewBinding(
+		key.WithKeys("L"),
+		key.WithHelp("L", "row-level security policies"),
+	),
+	Grants: key.NewBinding(
+		key.WithKeys("M"),
+		key.WithHelp("M", "edit grants"),
+	),
+	Listen: key.NewBinding(
+		key.WithKeys("l"),
+		key.WithHelp("l", "listen on channels"),
+	),
+	Unlisten: key.NewBinding(
+		key.WithKeys("u"),
+		key.WithHelp("u", "unlisten"),
+	),
+	Notify: key.NewBinding(
+		key.WithKeys("n"),
+		key.WithHelp("n", "send a notification"),
+	),
 	NextPage: key.NewBinding(
 		key.WithKeys("n"),
 		key.WithHelp("n", "next page"),
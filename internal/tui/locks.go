@@ -0,0 +1,254 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/zaffron/ezpg/internal/db"
+	"github.com/zaffron/ezpg/internal/tui/components/adminview"
+	"github.com/zaffron/ezpg/internal/tui/components/keyhints"
+)
+
+var lockColumns = []adminview.Column{
+	{Title: "pid", Width: 16},
+	{Title: "user", Width: 12},
+	{Title: "state", Width: 14},
+	{Title: "relation", Width: 20},
+	{Title: "mode", Width: 20},
+	{Title: "waiting", Width: 9, Numeric: true},
+	{Title: "xact", Width: 9, Numeric: true},
+	{Title: "query"},
+}
+
+var lockHints = []keyhints.Hint{
+	{Key: "enter", Desc: "query"},
+	{Key: "c", Desc: "cancel root blocker"},
+	{Key: "T", Desc: "terminate root blocker"},
+}
+
+// blockingRow is a session placed in the blocking tree.
+type blockingRow struct {
+	proc   db.BlockingProcess
+	path   []int32 // pids from the root blocker down to proc
+	prefix string  // tree lines drawn before the pid
+}
+
+// blockingTree puts each blocker above the sessions waiting on it. A session
+// waiting on several blockers shows up under each of them.
+func blockingTree(procs []db.BlockingProcess) []blockingRow {
+	byPID := make(map[int32]db.BlockingProcess, len(procs))
+	children := make(map[int32][]int32)
+	for _, p := range procs {
+		byPID[p.PID] = p
+		for _, b := range p.BlockedBy {
+			children[b] = append(children[b], p.PID)
+		}
+	}
+
+	var roots []int32
+	for _, p := range procs {
+		if len(p.BlockedBy) == 0 {
+			roots = append(roots, p.PID)
+		}
+		for _, b := range p.BlockedBy {
+			// pg_blocking_pids reports prepared transactions as pid 0
+			if _, ok := byPID[b]; !ok && !slices.Contains(roots, b) {
+				roots = append(roots, b)
+			}
+		}
+	}
+
+	var rows []blockingRow
+	seen := make(map[int32]bool)
+	var walk func(pid int32, path []int32, prefix, indent string)
+	walk = func(pid int32, path []int32, prefix, indent string) {
+		seen[pid] = true
+		path = append(slices.Clone(path), pid)
+
+		p, ok := byPID[pid]
+		if !ok {
+			p = db.BlockingProcess{PID: pid, State: "prepared xact"}
+		}
+		rows = append(rows, blockingRow{proc: p, path: path, prefix: prefix})
+
+		kids := children[pid]
+		for i, k := range kids {
+			if slices.Contains(path, k) {
+				continue // a deadlock, until the server breaks it
+			}
+			branch, next := "├─ ", "│  "
+			if i == len(kids)-1 {
+				branch, next = "└─ ", "   "
+			}
+			walk(k, path, indent+branch, indent+next)
+		}
+	}
+
+	for _, r := range roots {
+		walk(r, nil, "", "")
+	}
+	// Sessions waiting on each other in a cycle have no root
+	for _, p := range procs {
+		if !seen[p.PID] {
+			walk(p.PID, nil, "", "")
+		}
+	}
+
+	return rows
+}
+
+func blockingKey(path []int32) string {
+	parts := make([]string, len(path))
+	for i, pid := range path {
+		parts[i] = strconv.Itoa(int(pid))
+	}
+	return strings.Join(parts, "/")
+}
+
+func (a *App) setBlocking(procs []db.BlockingProcess) {
+	a.blocking = procs
+
+	tree := blockingTree(procs)
+	rows := make([]adminview.Row, len(tree))
+	for i, r := range tree {
+		p := r.proc
+		waiting := ""
+		if !p.Granted && p.Waiting > 0 {
+			waiting = adminview.FormatDuration(p.Waiting)
+		}
+		xact := ""
+		if p.XactAge > 0 {
+			xact = adminview.FormatDuration(p.XactAge)
+		}
+
+		color := ColorFg
+		if len(r.path) == 1 {
+			color = ColorWarning // a root blocker, the one to deal with
+		}
+
+		rows[i] = adminview.Row{
+			Key: blockingKey(r.path),
+			Cells: []string{
+				r.prefix + strconv.Itoa(int(p.PID)),
+				p.User,
+				p.State,
+				p.Relation,
+				p.Mode,
+				waiting,
+				xact,
+				p.Query,
+			},
+			Sort:  []float64{5: p.Waiting.Seconds(), 6: p.XactAge.Seconds()},
+			Color: color,
+		}
+	}
+	a.admin.SetRows(rows)
+}
+
+// selectedBlocking returns the session under the cursor and the path down
+// to it from its root blocker.
+func (a App) selectedBlocking() (db.BlockingProcess, []int32, bool) {
+	row, ok := a.admin.Selected()
+	if !ok {
+		return db.BlockingProcess{}, nil, false
+	}
+
+	var path []int32
+	for part := range strings.SplitSeq(row.Key, "/") {
+		pid, err := strconv.Atoi(part)
+		if err != nil {
+			return db.BlockingProcess{}, nil, false
+		}
+		path = append(path, int32(pid))
+	}
+
+	pid := path[len(path)-1]
+	for _, p := range a.blocking {
+		if p.PID == pid {
+			return p, path, true
+		}
+	}
+	return db.BlockingProcess{PID: pid}, path, true
+}
+
+// handleLocksKey handles the keys of the lock viewer, reporting false for
+// the ones it leaves to the list.
+func (a App) handleLocksKey(msg tea.KeyMsg) (tea.Model, tea.Cmd, bool) {
+	p, path, ok := a.selectedBlocking()
+
+	switch {
+	case key.Matches(msg, Keys.Enter):
+		if ok {
+			a.admin.ShowDetail(fmt.Sprintf("pid %d (%s on %s)", p.PID, p.User, p.Database), blockingDetail(p, path))
+			a.updateHints()
+		}
+		return a, nil, true
+
+	case key.Matches(msg, Keys.Cancel), key.Matches(msg, Keys.Terminate):
+		if !ok {
+			return a, nil, true
+		}
+		root := path[0]
+		if root == 0 {
+			a.statusbar.SetMessage("The root blocker is a prepared transaction, end it with COMMIT or ROLLBACK PREPARED", true)
+			return a, statusTimeoutCmd(5 * time.Second), true
+		}
+		who := "root blocker"
+		for _, b := range a.blocking {
+			if b.PID == root {
+				who = "root blocker, " + b.User + " on " + b.Database
+			}
+		}
+		m, cmd := a.signalBackend(root, who, key.Matches(msg, Keys.Terminate))
+		return m, cmd, true
+	}
+
+	return a, nil, false
+}
+
+// blockingDetail is the full query of p, under the chain of sessions it
+// waits behind.
+func blockingDetail(p db.BlockingProcess, path []int32) string {
+	var b strings.Builder
+	field := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "%-12s %s\n", name, value)
+		}
+	}
+
+	chain := make([]string, len(path))
+	for i, pid := range path {
+		chain[i] = strconv.Itoa(int(pid))
+	}
+	field("chain", strings.Join(chain, " → "))
+	if len(p.BlockedBy) > 0 {
+		blockers := make([]string, len(p.BlockedBy))
+		for i, pid := range p.BlockedBy {
+			blockers[i] = strconv.Itoa(int(pid))
+		}
+		field("blocked by", strings.Join(blockers, ", "))
+	}
+	field("state", p.State)
+	lock := p.Mode + " on " + p.Relation
+	if p.Granted {
+		field("holds", lock)
+	} else if p.Mode != "" {
+		field("waits for", lock)
+		field("waiting", adminview.FormatDuration(p.Waiting))
+	}
+	if p.XactAge > 0 {
+		field("xact age", adminview.FormatDuration(p.XactAge))
+	}
+
+	query := p.Query
+	if query == "" {
+		query = "(no query)"
+	}
+	b.WriteString("\n" + query)
+	return b.String()
+}
@@ -0,0 +1,360 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/zaffron/ezpg/internal/db"
+	"github.com/zaffron/ezpg/internal/tui/components/adminview"
+	"github.com/zaffron/ezpg/internal/tui/components/keyhints"
+)
+
+var maintenanceColumns = []adminview.Column{
+	{Title: "time", Width: 8},
+	{Title: "level", Width: 8},
+	{Title: "message"},
+}
+
+var maintenanceHints = []keyhints.Hint{
+	{Key: "c", Desc: "cancel command"},
+}
+
+// maxMaintenanceLog is how many log lines are kept, oldest dropped first.
+const maxMaintenanceLog = 2000
+
+// maintenanceJob is the maintenance command last run from the sidebar.
+type maintenanceJob struct {
+	conn      string
+	op        db.Maintenance
+	pid       int32 // of the session running it, 0 until it started
+	started   time.Time
+	elapsed   time.Duration // once done
+	running   bool
+	cancelled bool // by the user, rather than failed
+	err       error
+	cancel    context.CancelFunc
+	events    chan tea.Msg
+	progress  db.Progress
+	hasProg   bool
+}
+
+// maintenanceLine is one line of the maintenance log.
+type maintenanceLine struct {
+	at    time.Time
+	level string
+	text  string
+}
+
+// maintenanceActions lists the commands offered for a table, or for a
+// materialized view when matView is set.
+func maintenanceActions(schema, table string, matView bool) []db.Maintenance {
+	var actions []db.Maintenance
+	if matView {
+		actions = append(actions,
+			db.Maintenance{Kind: db.MaintenanceRefresh, Concurrently: true},
+			db.Maintenance{Kind: db.MaintenanceRefresh},
+		)
+	}
+	actions = append(actions,
+		db.Maintenance{Kind: db.MaintenanceVacuum},
+		db.Maintenance{Kind: db.MaintenanceVacuum, Analyze: true},
+		db.Maintenance{Kind: db.MaintenanceVacuum, Verbose: true, Analyze: true},
+		db.Maintenance{Kind: db.MaintenanceVacuum, Full: true},
+		db.Maintenance{Kind: db.MaintenanceVacuum, Full: true, Verbose: true, Analyze: true},
+		db.Maintenance{Kind: db.MaintenanceAnalyze},
+		db.Maintenance{Kind: db.MaintenanceAnalyze, Verbose: true},
+		db.Maintenance{Kind: db.MaintenanceReindex, Concurrently: true},
+		db.Maintenance{Kind: db.MaintenanceReindex},
+	)
+	if !matView {
+		actions = append(actions,
+			db.Maintenance{Kind: db.MaintenanceTruncate},
+			db.Maintenance{Kind: db.MaintenanceTruncate, RestartIdentity: true},
+			db.Maintenance{Kind: db.MaintenanceTruncate, Cascade: true},
+			db.Maintenance{Kind: db.MaintenanceTruncate, RestartIdentity: true, Cascade: true},
+		)
+	}
+	actions = append(actions,
+		db.Maintenance{Kind: db.MaintenanceDrop},
+		db.Maintenance{Kind: db.MaintenanceDrop, Cascade: true},
+	)
+
+	for i := range actions {
+		actions[i].Schema = schema
+		actions[i].Table = table
+		actions[i].MatView = matView
+	}
+	return actions
+}
+
+// maintenanceMenu offers the maintenance commands for the table selected in
+// the sidebar.
+func (a App) maintenanceMenu() (tea.Model, tea.Cmd) {
+	connName, schema, table, isConn := a.sidebar.SelectedItem()
+	database, isDB := a.sidebar.SelectedDatabase()
+	if connName == "" || isConn || isDB || table == "" {
+		return a, nil
+	}
+	if c, ok := a.mgr.ConnectionConfig(connName); ok && c.IsReadOnly() {
+		a.statusbar.SetMessage("Connection is read-only", true)
+		return a, statusTimeoutCmd(3 * time.Second)
+	}
+	if a.maint.running {
+		a.statusbar.SetMessage(a.maint.op.Label()+" is still running, cancel it from the admin screen first", true)
+		return a, statusTimeoutCmd(3 * time.Second)
+	}
+
+	actions := maintenanceActions(schema, table, a.sidebar.IsMatView())
+	labels := make([]string, len(actions))
+	for i, mt := range actions {
+		labels[i] = mt.Label()
+	}
+
+	a.startPick("Maintenance on "+qualifiedName(schema, table), labels, "", func(a App, label string) (tea.Model, tea.Cmd) {
+		var mt db.Maintenance
+		found := false
+		for _, act := range actions {
+			if act.Label() == label {
+				mt, found = act, true
+				break
+			}
+		}
+		if !found {
+			a.statusbar.SetMessage("Unknown maintenance command: "+label, true)
+			return a, statusTimeoutCmd(3 * time.Second)
+		}
+
+		if database != "" && database != a.mgr.Database(connName) {
+			if !a.mgr.SwitchDatabase(connName, database) {
+				a.statusbar.SetMessage("Database "+database+" is not open", true)
+				return a, statusTimeoutCmd(3 * time.Second)
+			}
+			a.switchedDatabase(connName)
+		}
+
+		run := func() tea.Msg {
+			return MaintenanceRequestedMsg{ConnName: connName, Maintenance: mt}
+		}
+		if !mt.Destructive() || !a.cfg.Settings.ConfirmDestructive {
+			return a, run
+		}
+		a.confirming = true
+		a.confirmText = fmt.Sprintf("Run %s on %s? (y/n)", mt.SQL(), connName)
+		a.statusbar.SetMessage(a.confirmText, true)
+		a.onConfirm = func() tea.Cmd { return run }
+		a.updateHints()
+		return a, nil
+	})
+	return a, nil
+}
+
+// startMaintenance runs mt on connName and shows its log on the admin screen.
+func (a App) startMaintenance(connName string, mt db.Maintenance) (tea.Model, tea.Cmd) {
+	if a.maint.running {
+		return a, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan tea.Msg, 64)
+	a.maint = maintenanceJob{
+		conn:    connName,
+		op:      mt,
+		started: time.Now(),
+		running: true,
+		cancel:  cancel,
+		events:  events,
+	}
+	a.logMaintenance("RUN", mt.SQL()+" on "+connName+"/"+a.mgr.Database(connName))
+
+	a.activeConn = connName
+	m, cmd := a.openAdmin(adminMaintenance)
+	return m, tea.Batch(cmd, runMaintenanceCmd(ctx, a.mgr, connName, mt, events), waitMaintenanceCmd(events))
+}
+
+// maintenanceEvent records an event of the running command and waits for
+// the next one.
+func (a App) maintenanceEvent(msg tea.Msg) (tea.Model, tea.Cmd) {
+	next := waitMaintenanceCmd(a.maint.events)
+
+	switch msg := msg.(type) {
+	case MaintenanceStartedMsg:
+		a.maint.pid = msg.PID
+		a.logMaintenance("INFO", "running as pid "+strconv.Itoa(int(msg.PID)))
+
+	case MaintenanceNoticeMsg:
+		a.logMaintenance(msg.Notice.Severity, msg.Notice.Message)
+
+	case MaintenanceDoneMsg:
+		a.maint.running = false
+		a.maint.err = msg.Err
+		a.maint.elapsed = time.Since(a.maint.started)
+		a.maint.cancel()
+
+		took := adminview.FormatDuration(a.maint.elapsed)
+		switch {
+		case msg.Err != nil && a.maint.cancelled:
+			a.logMaintenance("ERROR", "cancelled after "+took)
+			a.statusbar.SetMessage(a.maint.op.Label()+" cancelled", true)
+		case msg.Err != nil:
+			a.logMaintenance("ERROR", msg.Err.Error())
+			a.statusbar.SetMessage(msg.Err.Error(), true)
+		default:
+			a.logMaintenance("DONE", "finished in "+took)
+			a.statusbar.SetMessage(a.maint.op.Label()+" "+qualifiedName(a.maint.op.Schema, a.maint.op.Table)+" finished in "+took, false)
+		}
+
+		cmds := []tea.Cmd{statusTimeoutCmd(5 * time.Second)}
+		if msg.Err == nil && a.maint.op.Kind == db.MaintenanceDrop {
+			cmds = append(cmds, loadTablesCmd(a.mgr, a.maint.conn))
+		}
+		next = tea.Batch(cmds...)
+	}
+
+	if a.screen == ScreenAdmin && a.adminPage == adminMaintenance {
+		a.showMaintenance()
+	}
+	return a, next
+}
+
+// logMaintenance adds a line to the maintenance log, one per line of text.
+func (a *App) logMaintenance(level, text string) {
+	now := time.Now()
+	for line := range strings.SplitSeq(strings.TrimRight(text, "\n"), "\n") {
+		a.maintLog = append(a.maintLog, maintenanceLine{at: now, level: level, text: line})
+	}
+	if over := len(a.maintLog) - maxMaintenanceLog; over > 0 {
+		a.maintLog = a.maintLog[over:]
+		a.maintLogBase += over
+	}
+}
+
+// showMaintenance puts the log in the admin view, following its end unless
+// the cursor was moved up, and the command's state in the info line.
+func (a *App) showMaintenance() {
+	follow := a.admin.AtEnd()
+
+	rows := make([]adminview.Row, len(a.maintLog))
+	for i, l := range a.maintLog {
+		var color lipgloss.Color
+		switch l.level {
+		case "WARNING":
+			color = ColorWarning
+		case "ERROR":
+			color = ColorDanger
+		case "RUN", "DONE":
+			color = ColorSuccess
+		}
+		rows[i] = adminview.Row{
+			Key:   strconv.Itoa(a.maintLogBase + i),
+			Cells: []string{l.at.Format("15:04:05"), l.level, l.text},
+			Color: color,
+		}
+	}
+	a.admin.SetRows(rows)
+	if follow {
+		a.admin.SelectLast()
+	}
+
+	a.admin.SetInfo(a.maintenanceInfo())
+}
+
+// maintenanceInfo says what the last command is doing, e.g.
+// "VACUUM orders, running 12s, scanning heap 45% (450/1000 blocks)".
+func (a App) maintenanceInfo() string {
+	job := a.maint
+	if job.started.IsZero() {
+		return ""
+	}
+
+	info := job.op.Label() + " " + qualifiedName(job.op.Schema, job.op.Table)
+	switch {
+	case job.running:
+		info += ", running " + adminview.FormatDuration(time.Since(job.started))
+		if job.hasProg {
+			info += ", " + job.progress.Phase
+			if job.progress.Total > 0 {
+				info += fmt.Sprintf(" %d%% (%d/%d blocks)",
+					job.progress.Done*100/job.progress.Total, job.progress.Done, job.progress.Total)
+			}
+		}
+	case job.err != nil:
+		info += ", failed after " + adminview.FormatDuration(job.elapsed)
+	default:
+		info += ", finished in " + adminview.FormatDuration(job.elapsed)
+	}
+	return info
+}
+
+// refreshMaintenance polls the progress of the running command. With none
+// running there is nothing to load, so the log is just redrawn.
+func (a *App) refreshMaintenance() tea.Cmd {
+	if a.maint.running && a.maint.pid != 0 {
+		return loadProgressCmd(a.mgr, a.maint.conn, a.maint.op, a.maint.pid)
+	}
+	a.adminLoading = false
+	a.showMaintenance()
+	return nil
+}
+
+// progressLoaded records the progress of the running command.
+func (a *App) progressLoaded(msg ProgressLoadedMsg) tea.Cmd {
+	if msg.Err == nil {
+		a.maint.progress = msg.Progress
+		a.maint.hasProg = msg.OK
+	}
+	cmd := a.adminLoaded(msg.Err)
+	a.showMaintenance()
+	return cmd
+}
+
+// handleMaintenanceKey handles the keys of the maintenance log, reporting
+// false for the ones it leaves to the list.
+func (a App) handleMaintenanceKey(msg tea.KeyMsg) (tea.Model, tea.Cmd, bool) {
+	if !key.Matches(msg, Keys.Cancel) {
+		return a, nil, false
+	}
+	if !a.maint.running {
+		a.statusbar.SetMessage("No maintenance command is running", true)
+		return a, statusTimeoutCmd(3 * time.Second), true
+	}
+	a.maint.cancelled = true
+	a.statusbar.SetMessage("Cancelling "+a.maint.op.Label()+"...", false)
+	if a.maint.pid == 0 {
+		// Not sent to the server yet, so dropping it is enough
+		a.maint.cancel()
+		return a, nil, true
+	}
+	// Cancelling the context would only drop the session's socket, leaving
+	// the command and its locks on the server until it notices
+	a.logMaintenance("INFO", "asking the server to cancel pid "+strconv.Itoa(int(a.maint.pid)))
+	a.showMaintenance()
+	return a, cancelMaintenanceCmd(a.mgr, a.maint.conn, a.maint.pid), true
+}
+
+// maintenanceCancelSent logs what the server said to cancelling the command.
+// The command itself ends with an error once the server has stopped it.
+func (a *App) maintenanceCancelSent(msg MaintenanceCancelSentMsg) tea.Cmd {
+	pid := strconv.Itoa(int(msg.PID))
+	switch {
+	case msg.Err != nil:
+		a.logMaintenance("ERROR", "cancelling pid "+pid+": "+msg.Err.Error())
+		a.statusbar.SetMessage(msg.Err.Error(), true)
+	case !msg.OK:
+		a.logMaintenance("WARNING", "the server could not signal pid "+pid+", the command may have ended already")
+	default:
+		a.logMaintenance("INFO", "the server is cancelling pid "+pid)
+	}
+	if msg.Err != nil || !msg.OK {
+		a.maint.cancelled = false
+	}
+	if a.screen == ScreenAdmin && a.adminPage == adminMaintenance {
+		a.showMaintenance()
+	}
+	return statusTimeoutCmd(5 * time.Second)
+}
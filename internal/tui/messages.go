@@ -1,12 +1,24 @@
 package tui
 
-import "github.com/zaffron/ezpg/internal/db"
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/zaffron/ezpg/internal/db"
+)
 
 // I want to define some of the messages that I have to show to the user
 
 // Connection messages
 type ConnectMsg struct {
 	Name string
+	TLS  string // negotiated TLS version and cipher
+	Err  error
+}
+
+type ConnectionTestedMsg struct {
+	Name string
+	Diag *db.Diagnostics
 	Err  error
 }
 
@@ -14,15 +26,59 @@ type DisconnectMsg struct {
 	Name string
 }
 
+// Health messages
+type HealthTickMsg struct{}
+
+type HealthMsg struct {
+	Name    string
+	Health  db.Health
+	Latency time.Duration
+	Err     error
+}
+
+type ReconnectMsg struct {
+	Name    string
+	Attempt int // 0 for the first try
+	Err     error
+}
+
 // Schema messages
 type TablesLoadedMsg struct {
 	ConnName string
+	Database string
 	Tables   []db.TableInfo
 	Err      error
 }
 
+type DatabasesLoadedMsg struct {
+	ConnName  string
+	Databases []string
+	Err       error
+}
+
+type RolesLoadedMsg struct {
+	ConnName string
+	Roles    []string
+	Current  string
+	Err      error
+}
+
+type SchemasLoadedMsg struct {
+	ConnName   string
+	Schemas    []string
+	SearchPath string
+	Err        error
+}
+
+type DatabaseSwitchedMsg struct {
+	ConnName string
+	Database string
+	Err      error
+}
+
 type ColumnsLoadedMsg struct {
 	ConnName string
+	Database string
 	Schema   string
 	Table    string
 	Columns  []db.ColumnInfo
@@ -32,6 +88,7 @@ type ColumnsLoadedMsg struct {
 // Data messages
 type TableDataMsg struct {
 	ConnName string
+	Database string
 	Schema   string
 	Table    string
 	Result   *db.QueryResult
@@ -39,10 +96,174 @@ type TableDataMsg struct {
 }
 
 type QueryResultMsg struct {
-	Result *db.QueryResult
+	ConnName string
+	Query    string
+	Result   *db.QueryResult
+	Err      error
+}
+
+// Admin screen messages
+type AdminTickMsg struct {
+	Seq int // drops ticks from an admin screen that has since been left
+}
+
+type ActivityLoadedMsg struct {
+	ConnName string
+	Activity []db.Activity
+	Err      error
+}
+
+type BlockingLoadedMsg struct {
+	ConnName  string
+	Processes []db.BlockingProcess
+	Err       error
+}
+
+type StatementsLoadedMsg struct {
+	ConnName   string
+	Statements []db.StatementStats
+	Err        error
+}
+
+type StatementsResetMsg struct {
+	Err error
+}
+
+type SizesLoadedMsg struct {
+	ConnName string
+	Tables   []db.TableSize
+	Indexes  []db.IndexSize
+	Err      error
+}
+
+type ServerInfoLoadedMsg struct {
+	ConnName   string
+	Info       db.ServerInfo
+	Extensions []db.Extension
+	Err        error
+}
+
+type SettingsLoadedMsg struct {
+	ConnName string
+	Settings []db.Setting
+	Err      error
+}
+
+type ReplicationLoadedMsg struct {
+	ConnName string
+	Status   db.ReplicationStatus
+	Err      error
+}
+
+type BackendSignaledMsg struct {
+	PID       int32
+	Terminate bool
+	OK        bool // false when the server found no such process
+	Err       error
+}
+
+type PlanLoadedMsg struct {
+	Title string
+	Plan  string
+	Err   error
+}
+
+// Maintenance messages, sent one at a time while a command runs
+type MaintenanceRequestedMsg struct {
+	ConnName    string
+	Maintenance db.Maintenance
+}
+
+type MaintenanceStartedMsg struct {
+	PID int32
+}
+
+type MaintenanceNoticeMsg struct {
+	Notice db.Notice
+}
+
+type MaintenanceDoneMsg struct {
+	Err error
+}
+
+type ProgressLoadedMsg struct {
+	Progress db.Progress
+	OK       bool // false when the server reports no progress
+	Err      error
+}
+
+// MaintenanceCancelSentMsg is the server's answer to cancelling the command.
+type MaintenanceCancelSentMsg struct {
+	PID int32
+	OK  bool // false when the server found no such process
+	Err error
+}
+
+// LISTEN/NOTIFY console messages. Notifications and the end of the
+// listener come through its events channel, which they carry so the next
+// wait is on the same one.
+type ListenerOpenedMsg struct {
+	ConnName string
+	Database string
+	Listener *db.Listener
+	Channels []string // to LISTEN on once open
+	Err      error
+}
+
+type ListenedMsg struct {
+	Listener *db.Listener
+	Channels []string // the ones done, up to an error
+	Unlisten bool
+	Err      error
+}
+
+type NotificationMsg struct {
+	Notification db.Notification
+	Events       chan tea.Msg
+}
+
+type ListenerDoneMsg struct {
+	Events chan tea.Msg
 	Err    error
 }
 
+type NotifySentMsg struct {
+	Channel string
+	Err     error
+}
+
+// Security messages, for the sidebar's security folder
+type RoleInfoLoadedMsg struct {
+	ConnName string
+	Roles    []db.RoleInfo
+	Err      error
+}
+
+type AccessLoadedMsg struct {
+	ConnName string
+	Role     string // the role reported on, or empty for a table's report
+	Schema   string
+	Table    string
+	Access   []db.TableAccess
+	Err      error
+}
+
+type RowSecurityLoadedMsg struct {
+	ConnName    string
+	Schema      string
+	Table       string
+	RowSecurity db.RowSecurity
+	Err         error
+}
+
+type GrantsLoadedMsg struct {
+	ConnName string
+	Schema   string
+	Table    string
+	Grants   []db.Grant
+	Err      error
+}
+
 // UI messages
 type StatusMsg struct {
 	Text  string
@@ -51,17 +272,40 @@ type StatusMsg struct {
 
 type ClearStatusMsg struct{}
 
+// OpenInEditorMsg loads Query into the SQL editor once replacing what was
+// there is confirmed.
+type OpenInEditorMsg struct {
+	Query string
+	Note  string
+}
+
 // CRUD messages
 type RowDeletedMsg struct {
-	Err error
+	ConnName string
+	Err      error
 }
 
 type RowInsertedMsg struct {
-	Err error
+	ConnName string
+	Result   *db.QueryResult // the inserted row, from RETURNING *
+	Err      error
 }
 
 type RowUpdatedMsg struct {
-	Err error
+	ConnName string
+	Err      error
+}
+
+type BulkDoneMsg struct {
+	ConnName string
+	Action   string // e.g. "Deleted", "Duplicated"
+	Affected int64
+	Err      error
+}
+
+type ClipboardMsg struct {
+	Text string
+	Err  error
 }
 
 // Connection config management messages
@@ -9,6 +9,7 @@ type Panel = shared.Panel
 const (
 	ScreenHome   = shared.ScreenHome
 	ScreenBrowse = shared.ScreenBrowse
+	ScreenAdmin  = shared.ScreenAdmin
 )
 
 const (
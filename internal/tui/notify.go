@@ -0,0 +1,385 @@
+package tui
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/zaffron/ezpg/internal/db"
+	"github.com/zaffron/ezpg/internal/tui/components/adminview"
+	"github.com/zaffron/ezpg/internal/tui/components/keyhints"
+)
+
+var notifyColumns = []adminview.Column{
+	{Title: "time", Width: 12},
+	{Title: "channel", Width: 20},
+	{Title: "pid", Width: 8, Numeric: true},
+	{Title: "payload"},
+}
+
+var notifyHints = []keyhints.Hint{
+	{Key: "enter", Desc: "payload"},
+	{Key: "l", Desc: "listen"},
+	{Key: "u", Desc: "unlisten"},
+	{Key: "n", Desc: "notify"},
+}
+
+// maxNotifyLog is how many notifications are kept, oldest dropped first.
+const maxNotifyLog = 2000
+
+// notifyConsole is the listener of the LISTEN/NOTIFY console, with what it
+// received.
+type notifyConsole struct {
+	conn     string
+	database string
+	listener *db.Listener // nil when not listening
+	cancel   context.CancelFunc
+	events   chan tea.Msg
+	channels []string
+	log      []notifyLine
+	logBase  int // lines dropped from the front of log, so row keys stay put
+}
+
+// notifyLine is a notification, or an event of the console itself such as
+// a LISTEN, marked by its level.
+type notifyLine struct {
+	at      time.Time
+	level   string // empty for a notification
+	channel string
+	pid     uint32
+	payload string
+}
+
+// listen LISTENs on channels, opening the listener's connection first when
+// there is none.
+func (a App) listen(channels []string) (tea.Model, tea.Cmd) {
+	if a.notify.listener == nil {
+		a.statusbar.SetMessage("Opening a connection to listen on...", false)
+		return a, tea.Batch(openListenerCmd(a.mgr, a.adminConn, channels), statusTimeoutCmd(3*time.Second))
+	}
+	if a.notify.conn != a.adminConn {
+		a.statusbar.SetMessage("Already listening on "+a.notify.conn+", unlisten there first", true)
+		return a, statusTimeoutCmd(3 * time.Second)
+	}
+	return a, listenCmd(a.notify.listener, channels, false)
+}
+
+// listenerOpened starts receiving on a new listener and LISTENs on the
+// channels it was opened for.
+func (a App) listenerOpened(msg ListenerOpenedMsg) (tea.Model, tea.Cmd) {
+	if msg.Err != nil {
+		a.logNotify("ERROR", "", msg.Err.Error())
+		a.statusbar.SetMessage(msg.Err.Error(), true)
+		a.redrawNotify()
+		return a, statusTimeoutCmd(5 * time.Second)
+	}
+	if a.notify.listener != nil {
+		// Opened twice by keys pressed in a row; use the first
+		return a, tea.Batch(closeListenerCmd(msg.Listener), listenCmd(a.notify.listener, msg.Channels, false))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan tea.Msg, 64)
+	a.notify.conn = msg.ConnName
+	a.notify.database = msg.Database
+	a.notify.listener = msg.Listener
+	a.notify.cancel = cancel
+	a.notify.events = events
+	a.notify.channels = nil
+
+	return a, tea.Batch(
+		runListenerCmd(ctx, msg.Listener, events),
+		waitNotifyCmd(events),
+		listenCmd(msg.Listener, msg.Channels, false),
+	)
+}
+
+// listened records the channels LISTENed on or UNLISTENed from, closing the
+// listener once it has none left.
+func (a App) listened(msg ListenedMsg) (tea.Model, tea.Cmd) {
+	if msg.Listener != a.notify.listener {
+		return a, nil
+	}
+
+	verb := "LISTEN"
+	if msg.Unlisten {
+		verb = "UNLISTEN"
+	}
+	for _, ch := range msg.Channels {
+		a.logNotify(verb, ch, "")
+		if msg.Unlisten {
+			a.notify.channels = slices.DeleteFunc(a.notify.channels, func(c string) bool { return c == ch })
+		} else if !slices.Contains(a.notify.channels, ch) {
+			a.notify.channels = append(a.notify.channels, ch)
+		}
+	}
+
+	var cmd tea.Cmd
+	if msg.Err != nil {
+		a.logNotify("ERROR", "", msg.Err.Error())
+		a.statusbar.SetMessage(msg.Err.Error(), true)
+		cmd = statusTimeoutCmd(5 * time.Second)
+	}
+	if len(a.notify.channels) == 0 {
+		a.stopListener()
+	}
+	a.redrawNotify()
+	return a, cmd
+}
+
+// stopListener closes the listener's connection. Its end still comes
+// through the events channel, and is ignored.
+func (a *App) stopListener() {
+	if a.notify.listener == nil {
+		return
+	}
+	a.notify.cancel()
+	a.notify.listener = nil
+	a.notify.events = nil
+	a.notify.channels = nil
+	a.logNotify("INFO", "", "stopped listening")
+}
+
+// listenerConnClosed stops the listener when connName, the connection it
+// listens for, is closed or edited, since it would outlive the pool.
+func (a *App) listenerConnClosed(connName string) {
+	if a.notify.listener != nil && a.notify.conn == connName {
+		a.stopListener()
+		a.redrawNotify()
+	}
+}
+
+// relisten opens a new listener after connName was reconnected, on the
+// channels the old one listened on; its connection likely went down too.
+func (a *App) relisten(connName string) tea.Cmd {
+	if a.notify.listener == nil || a.notify.conn != connName {
+		return nil
+	}
+	channels := slices.Clone(a.notify.channels)
+	a.stopListener()
+	a.redrawNotify()
+	if len(channels) == 0 {
+		return nil
+	}
+	return openListenerCmd(a.mgr, connName, channels)
+}
+
+// notifyEvent records a notification, or the end of the listener, and
+// waits for the next one.
+func (a App) notifyEvent(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case NotificationMsg:
+		n := msg.Notification
+		a.notify.log = append(a.notify.log, notifyLine{at: n.At, channel: n.Channel, pid: n.PID, payload: n.Payload})
+		a.trimNotifyLog()
+		a.redrawNotify()
+		return a, waitNotifyCmd(msg.Events)
+
+	case ListenerDoneMsg:
+		if msg.Events != a.notify.events {
+			return a, nil // a listener already stopped
+		}
+		a.notify.listener = nil
+		a.notify.events = nil
+		a.notify.channels = nil
+		text := "listener closed"
+		if msg.Err != nil {
+			text = msg.Err.Error()
+			a.statusbar.SetMessage("Listener failed: "+text, true)
+		}
+		a.logNotify("ERROR", "", text)
+		a.redrawNotify()
+		return a, statusTimeoutCmd(5 * time.Second)
+	}
+	return a, nil
+}
+
+// logNotify adds an event of the console to its log.
+func (a *App) logNotify(level, channel, text string) {
+	a.notify.log = append(a.notify.log, notifyLine{at: time.Now(), level: level, channel: channel, payload: text})
+	a.trimNotifyLog()
+}
+
+func (a *App) trimNotifyLog() {
+	if over := len(a.notify.log) - maxNotifyLog; over > 0 {
+		a.notify.log = a.notify.log[over:]
+		a.notify.logBase += over
+	}
+}
+
+// redrawNotify shows the log again when its page is the one on screen.
+func (a *App) redrawNotify() {
+	if a.screen == ScreenAdmin && a.adminPage == adminNotify {
+		a.showNotify()
+	}
+}
+
+// showNotify puts the log in the admin view, following its end unless the
+// cursor was moved up, and what is listened on in the info line.
+func (a *App) showNotify() {
+	follow := a.admin.AtEnd()
+
+	rows := make([]adminview.Row, len(a.notify.log))
+	for i, l := range a.notify.log {
+		channel, pid, payload := l.channel, "", oneLine(l.payload)
+		var color lipgloss.Color
+		switch l.level {
+		case "":
+			pid = strconv.FormatUint(uint64(l.pid), 10)
+		case "ERROR":
+			color = ColorDanger
+		default:
+			color = ColorSuccess
+		}
+		if l.level != "" {
+			payload = strings.TrimSpace(l.level + " " + payload)
+		}
+		rows[i] = adminview.Row{
+			Key:   strconv.Itoa(a.notify.logBase + i),
+			Cells: []string{l.at.Format("15:04:05.000"), channel, pid, payload},
+			Sort:  []float64{2: float64(l.pid)},
+			Color: color,
+		}
+	}
+	a.admin.SetRows(rows)
+	if follow {
+		a.admin.SelectLast()
+	}
+
+	if a.notify.listener == nil {
+		a.admin.SetInfo("not listening")
+	} else {
+		a.admin.SetInfo(fmt.Sprintf("listening on %s/%s: %s",
+			a.notify.conn, a.notify.database, strings.Join(a.notify.channels, ", ")))
+	}
+}
+
+// oneLine fits a payload on a line of the log, JSON compacted.
+func oneLine(payload string) string {
+	var b bytes.Buffer
+	if json.Valid([]byte(payload)) && json.Compact(&b, []byte(payload)) == nil {
+		return b.String()
+	}
+	return strings.Join(strings.Fields(payload), " ")
+}
+
+// prettyPayload indents a JSON payload, leaving any other as it is.
+func prettyPayload(payload string) string {
+	var b bytes.Buffer
+	if json.Valid([]byte(payload)) && json.Indent(&b, []byte(payload), "", "  ") == nil {
+		return b.String()
+	}
+	return payload
+}
+
+func (a App) selectedNotification() (notifyLine, bool) {
+	row, ok := a.admin.Selected()
+	if !ok {
+		return notifyLine{}, false
+	}
+	i, err := strconv.Atoi(row.Key)
+	if err != nil || i-a.notify.logBase < 0 || i-a.notify.logBase >= len(a.notify.log) {
+		return notifyLine{}, false
+	}
+	return a.notify.log[i-a.notify.logBase], true
+}
+
+// handleNotifyKey handles the keys of the LISTEN/NOTIFY console, reporting
+// false for the ones it leaves to the list.
+func (a App) handleNotifyKey(msg tea.KeyMsg) (tea.Model, tea.Cmd, bool) {
+	switch {
+	case key.Matches(msg, Keys.Enter):
+		if l, ok := a.selectedNotification(); ok && l.level == "" {
+			title := fmt.Sprintf("%s at %s from pid %d", l.channel, l.at.Format("15:04:05.000"), l.pid)
+			a.admin.ShowDetail(title, prettyPayload(l.payload))
+			a.updateHints()
+		}
+		return a, nil, true
+
+	case key.Matches(msg, Keys.Listen):
+		a.startPrompt("LISTEN on (channels separated by commas)", false, func(a App, value string) (tea.Model, tea.Cmd) {
+			channels := splitChannels(value)
+			if len(channels) == 0 {
+				return a, nil
+			}
+			return a.listen(channels)
+		})
+		return a, nil, true
+
+	case key.Matches(msg, Keys.Unlisten):
+		if a.notify.listener == nil {
+			a.statusbar.SetMessage("Not listening on any channel", true)
+			return a, statusTimeoutCmd(3 * time.Second), true
+		}
+		label := "UNLISTEN from " + strings.Join(a.notify.channels, ", ") + " (empty for all)"
+		a.startPrompt(label, false, func(a App, value string) (tea.Model, tea.Cmd) {
+			if a.notify.listener == nil {
+				return a, nil
+			}
+			channels := splitChannels(value)
+			if len(channels) == 0 {
+				channels = slices.Clone(a.notify.channels)
+			}
+			return a, listenCmd(a.notify.listener, channels, true)
+		})
+		return a, nil, true
+
+	case key.Matches(msg, Keys.Notify):
+		return a.notifyPrompt()
+	}
+
+	return a, nil, false
+}
+
+// notifyPrompt asks for a channel, offering the selected notification's,
+// then for the payload to send on it.
+func (a App) notifyPrompt() (tea.Model, tea.Cmd, bool) {
+	if c, ok := a.mgr.ConnectionConfig(a.adminConn); ok && c.IsReadOnly() {
+		a.statusbar.SetMessage("Connection is read-only", true)
+		return a, statusTimeoutCmd(3 * time.Second), true
+	}
+
+	connName := a.adminConn
+	selected := ""
+	if l, ok := a.selectedNotification(); ok {
+		selected = l.channel
+	}
+	label := "NOTIFY channel"
+	if selected != "" {
+		label += " (empty for " + selected + ")"
+	}
+	a.startPrompt(label, false, func(a App, channel string) (tea.Model, tea.Cmd) {
+		channel = strings.TrimSpace(channel)
+		if channel == "" {
+			channel = selected
+		}
+		if channel == "" {
+			return a, nil
+		}
+		a.startPrompt("Payload for "+channel, false, func(a App, payload string) (tea.Model, tea.Cmd) {
+			return a, notifyCmd(a.mgr, connName, channel, payload)
+		})
+		return a, nil
+	})
+	return a, nil, true
+}
+
+// splitChannels splits channel names separated by commas or spaces,
+// dropping repeats.
+func splitChannels(s string) []string {
+	var channels []string
+	for _, f := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' }) {
+		if !slices.Contains(channels, f) {
+			channels = append(channels, f)
+		}
+	}
+	return channels
+}
@@ -0,0 +1,224 @@
+package tui
+
+import (
+	"encoding/csv"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/atotto/clipboard"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/zaffron/ezpg/internal/db"
+)
+
+// pasteMapping decides which table columns the pasted fields land in.
+type pasteMapping int
+
+const (
+	pasteVisible pasteMapping = iota // the columns currently on screen
+	pasteFirstN                      // the first N table columns
+	pasteHeader                      // the first pasted row names the columns
+)
+
+func (m pasteMapping) String() string {
+	switch m {
+	case pasteFirstN:
+		return "first N columns"
+	case pasteHeader:
+		return "header row"
+	default:
+		return "visible columns"
+	}
+}
+
+func readClipboardCmd() tea.Cmd {
+	return func() tea.Msg {
+		text, err := clipboard.ReadAll()
+		return ClipboardMsg{Text: text, Err: err}
+	}
+}
+
+// parseTabular reads spreadsheet-style TSV, falling back to CSV when there are no tabs.
+func parseTabular(text string) ([][]string, error) {
+	text = strings.TrimRight(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	if text == "" {
+		return nil, fmt.Errorf("clipboard is empty")
+	}
+
+	r := csv.NewReader(strings.NewReader(text))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	if strings.Contains(text, "\t") {
+		r.Comma = '\t'
+	}
+
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("parsing clipboard: %w", err)
+	}
+	return records, nil
+}
+
+func (a App) handlePasteRows() (tea.Model, tea.Cmd) {
+	if reason := a.readOnlyReason(); reason != "" {
+		a.statusbar.SetMessage(reason, true)
+		return a, statusTimeoutCmd(3 * time.Second)
+	}
+	return a, readClipboardCmd()
+}
+
+func (a App) handleClipboard(msg ClipboardMsg) (tea.Model, tea.Cmd) {
+	if msg.Err != nil {
+		a.statusbar.SetMessage("Reading clipboard failed: "+msg.Err.Error(), true)
+		return a, statusTimeoutCmd(5 * time.Second)
+	}
+
+	records, err := parseTabular(msg.Text)
+	if err != nil {
+		a.statusbar.SetMessage(err.Error(), true)
+		return a, statusTimeoutCmd(5 * time.Second)
+	}
+
+	a.pasteRecords = records
+	a.pasteMapping = pasteVisible
+	if a.hasPasteHeader() {
+		a.pasteMapping = pasteHeader
+	}
+	return a.stagePaste()
+}
+
+// hasPasteHeader reports whether every field of the first pasted row is a column name.
+func (a App) hasPasteHeader() bool {
+	if len(a.pasteRecords) < 2 {
+		return false
+	}
+	for _, name := range a.pasteRecords[0] {
+		if !slices.Contains(a.tableview.Columns(), strings.TrimSpace(name)) {
+			return false
+		}
+	}
+	return true
+}
+
+// pasteColumns maps pasted fields to column indices and returns the data rows.
+func (a App) pasteColumns() ([]int, [][]string, error) {
+	columns := a.tableview.Columns()
+	records := a.pasteRecords
+	width := 0
+	for _, r := range records {
+		width = max(width, len(r))
+	}
+
+	var cols []int
+	switch a.pasteMapping {
+	case pasteHeader:
+		for _, name := range records[0] {
+			cols = append(cols, slices.Index(columns, strings.TrimSpace(name)))
+		}
+		records = records[1:]
+	case pasteFirstN:
+		if width > len(columns) {
+			return nil, nil, fmt.Errorf("%d fields per row but the table has %d columns", width, len(columns))
+		}
+		for i := range width {
+			cols = append(cols, i)
+		}
+	default:
+		start, n := a.tableview.VisibleColumns()
+		if width > n {
+			return nil, nil, fmt.Errorf("%d fields per row but only %d columns are visible (m to change mapping)", width, n)
+		}
+		for i := range width {
+			cols = append(cols, start+i)
+		}
+	}
+
+	return cols, records, nil
+}
+
+// stagePaste builds the statements for the pasted rows and asks for confirmation.
+// Rows become upserts when the mapped columns include the whole primary key.
+func (a App) stagePaste() (tea.Model, tea.Cmd) {
+	cols, records, err := a.pasteColumns()
+	if err != nil {
+		return a.pasteFailed(err.Error())
+	}
+
+	schema := a.tableview.Schema()
+	tableName := a.tableview.TableName()
+	allColumns := a.tableview.Columns()
+	pkCols := a.pkCache[a.cacheKey(a.tableview.ConnName(), schema, tableName)]
+
+	names := make([]string, 0, len(cols))
+	for _, c := range cols {
+		names = append(names, allColumns[c])
+	}
+	upsert := len(pkCols) > 0
+	for _, pk := range pkCols {
+		if !slices.Contains(names, pk) {
+			upsert = false
+		}
+	}
+
+	var stmts []db.Statement
+	for r, record := range records {
+		values := make([]db.CellValue, len(cols))
+		for i, c := range cols {
+			if i >= len(record) || record[i] == "" {
+				values[i] = db.CellValue{Kind: db.CellNull}
+				continue
+			}
+			if err := db.ValidateValue(a.tableview.ColumnType(c), record[i]); err != nil {
+				return a.pasteFailed(fmt.Sprintf("Row %d, %s: %v", r+1, allColumns[c], err))
+			}
+			values[i] = db.CellValue{Kind: db.CellText, Text: record[i]}
+		}
+
+		if upsert {
+			stmts = append(stmts, db.UpsertRowStatement(schema, tableName, names, pkCols, values))
+		} else {
+			stmts = append(stmts, db.InsertRowStatement(schema, tableName, names, values))
+		}
+	}
+
+	if len(stmts) == 0 {
+		return a.pasteFailed("Nothing to paste")
+	}
+
+	verb, done := "Insert", "Pasted"
+	if upsert {
+		verb = "Upsert"
+	}
+
+	model, cmd := a.confirmBulk(verb, done, stmts)
+	a = model.(App)
+	a.confirmPreview = fmt.Sprintf("-- mapping: %s (%s), press m to change\n%s",
+		a.pasteMapping, strings.Join(names, ", "), a.confirmPreview)
+	return a, cmd
+}
+
+// cyclePasteMapping switches to the next mapping that fits the pasted rows and restages the paste.
+func (a App) cyclePasteMapping() (tea.Model, tea.Cmd) {
+	for range 3 {
+		a.pasteMapping = (a.pasteMapping + 1) % 3
+		if a.pasteMapping == pasteHeader && !a.hasPasteHeader() {
+			continue
+		}
+		if _, _, err := a.pasteColumns(); err == nil {
+			break
+		}
+	}
+	return a.stagePaste()
+}
+
+// pasteFailed drops the staged paste and reports why.
+func (a App) pasteFailed(text string) (tea.Model, tea.Cmd) {
+	a.pasteRecords = nil
+	a.confirming = false
+	a.confirmPreview = ""
+	a.onConfirm = nil
+	a.statusbar.SetMessage(text, true)
+	a.updateHints()
+	return a, statusTimeoutCmd(5 * time.Second)
+}
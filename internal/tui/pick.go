@@ -0,0 +1,77 @@
+package tui
+
+import (
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jackc/pgx/v5"
+	"github.com/zaffron/ezpg/internal/tui/components/picker"
+)
+
+// startPick shows a picker in place of the table. onPick receives the chosen
+// item, or the typed text when it matches nothing.
+func (a *App) startPick(title string, items []string, current string, onPick func(a App, value string) (tea.Model, tea.Cmd)) {
+	a.picker = picker.New(title, items, current)
+	a.picker.SetHeight(a.height - 16)
+	a.picking = true
+	a.onPick = onPick
+	a.updateHints()
+}
+
+func (a *App) endPick() {
+	a.picking = false
+	a.onPick = nil
+}
+
+func (a App) handlePickKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, Keys.Enter):
+		value, onPick := a.picker.Value(), a.onPick
+		a.endPick()
+		a.updateHints()
+		if onPick != nil && value != "" {
+			return onPick(a, value)
+		}
+		return a, nil
+
+	case key.Matches(msg, Keys.Escape):
+		a.endPick()
+		a.updateHints()
+		return a, nil
+	}
+
+	var cmd tea.Cmd
+	a.picker, cmd = a.picker.Update(msg)
+	return a, cmd
+}
+
+// pickRole offers the roles the session user can switch to.
+func (a *App) pickRole(msg RolesLoadedMsg) {
+	roles := append([]string{"none"}, msg.Roles...) // back to the login role
+	a.startPick("SET ROLE on "+msg.ConnName, roles, msg.Current, func(a App, role string) (tea.Model, tea.Cmd) {
+		a.mgr.SetSessionRole(msg.ConnName, role)
+		a.statusbar.SetMessage("Role set to "+role, false)
+		return a, tea.Batch(loadTablesCmd(a.mgr, msg.ConnName), statusTimeoutCmd(3*time.Second))
+	})
+}
+
+// pickSearchPath offers a schema to put first on the search_path, or takes a
+// typed path such as "app, public".
+func (a *App) pickSearchPath(msg SchemasLoadedMsg) {
+	a.startPick("search_path on "+msg.ConnName+" (now "+msg.SearchPath+")", msg.Schemas, "", func(a App, value string) (tea.Model, tea.Cmd) {
+		path := value
+		for _, s := range msg.Schemas {
+			if s == value {
+				path = pgx.Identifier{s}.Sanitize() + ", public"
+				if s == "public" {
+					path = "public"
+				}
+				break
+			}
+		}
+		a.mgr.SetSearchPath(msg.ConnName, path)
+		a.statusbar.SetMessage("search_path set to "+path, false)
+		return a, tea.Batch(loadTablesCmd(a.mgr, msg.ConnName), statusTimeoutCmd(3*time.Second))
+	})
+}
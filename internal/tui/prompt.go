@@ -0,0 +1,74 @@
+package tui
+
+import (
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// startPrompt asks for a line of input in the status bar. onSubmit receives
+// the entered text; secret input is masked.
+func (a *App) startPrompt(label string, secret bool, onSubmit func(a App, value string) (tea.Model, tea.Cmd)) {
+	ti := textinput.New()
+	ti.Prompt = label + ": "
+	ti.PromptStyle = lipgloss.NewStyle().Foreground(ColorWarning).Bold(true)
+	ti.TextStyle = lipgloss.NewStyle().Foreground(ColorFg)
+	ti.CharLimit = 0
+	if secret {
+		ti.EchoMode = textinput.EchoPassword
+	}
+	ti.Focus()
+
+	a.prompting = true
+	a.promptInput = ti
+	a.onPrompt = onSubmit
+	a.statusbar.SetPrompt(ti.View())
+	a.updateHints()
+}
+
+func (a *App) endPrompt() {
+	a.prompting = false
+	a.promptInput = textinput.Model{}
+	a.onPrompt = nil
+	a.statusbar.SetPrompt("")
+}
+
+func (a App) handlePromptKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, Keys.Enter):
+		value, onSubmit := a.promptInput.Value(), a.onPrompt
+		a.endPrompt()
+		a.updateHints()
+		if onSubmit != nil {
+			return onSubmit(a, value)
+		}
+		return a, nil
+
+	case key.Matches(msg, Keys.Escape):
+		a.endPrompt()
+		a.statusbar.SetMessage("Cancelled", false)
+		a.updateHints()
+		return a, statusTimeoutCmd(2 * time.Second)
+	}
+
+	var cmd tea.Cmd
+	a.promptInput, cmd = a.promptInput.Update(msg)
+	a.statusbar.SetPrompt(a.promptInput.View())
+	return a, cmd
+}
+
+// promptPassword asks for the password of a connection that had none
+// configured and retries connecting with it.
+func (a *App) promptPassword(connName string) {
+	a.startPrompt("Password for "+connName, true, func(a App, password string) (tea.Model, tea.Cmd) {
+		a.mgr.SetPassword(connName, password)
+		a.loading = true
+		a.activeConn = connName
+		a.statusbar.SetLoading(true, "Connecting to "+connName+"...")
+		a.updateHints()
+		return a, connectCmd(a.mgr, connName)
+	})
+}
@@ -0,0 +1,254 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/zaffron/ezpg/internal/db"
+	"github.com/zaffron/ezpg/internal/tui/components/adminview"
+	"github.com/zaffron/ezpg/internal/tui/components/keyhints"
+)
+
+var replicationColumns = []adminview.Column{
+	{Title: "kind", Width: 12},
+	{Title: "name", Width: 24},
+	{Title: "state", Width: 16},
+	{Title: "lag", Width: 9, Numeric: true},
+	{Title: "wal", Width: 10, Numeric: true},
+	{Title: "detail"},
+}
+
+var replicationHints = []keyhints.Hint{
+	{Key: "enter", Desc: "details"},
+}
+
+// setReplication lists the standbys, slots, WAL receiver, publications and
+// subscriptions together, the server's role and WAL position above them.
+// Inactive slots are flagged since they keep WAL until the disk fills.
+func (a *App) setReplication(st db.ReplicationStatus) {
+	a.replDetails = make(map[string]string)
+	var rows []adminview.Row
+	// id tells rows of a kind apart: standbys can share an application_name,
+	// walreceiver by default, so theirs is the pid
+	add := func(kind, id, name, state string, lag time.Duration, wal int64, detail string, color lipgloss.Color, drill string) {
+		k := kind + "/" + id
+		a.replDetails[k] = drill
+		rows = append(rows, adminview.Row{
+			Key:   k,
+			Cells: []string{kind, name, state, lagText(lag), walText(wal), detail},
+			Sort:  []float64{3: lag.Seconds(), 4: float64(wal)},
+			Color: color,
+		})
+	}
+
+	synchronous := 0
+	for _, s := range st.Standbys {
+		if s.SyncState == "sync" || s.SyncState == "quorum" {
+			synchronous++
+		}
+		name := s.Application
+		if name == "" {
+			name = "pid " + strconv.Itoa(int(s.PID))
+		}
+		add("standby", strconv.Itoa(int(s.PID)), name, s.State+" "+s.SyncState, s.ReplayLag, s.BytesBehind,
+			s.Client+", replayed to "+s.ReplayLSN, "", standbyDetail(s))
+	}
+
+	inactive, held := 0, int64(0)
+	for _, s := range st.Slots {
+		state := "active"
+		var color lipgloss.Color
+		if !s.Active {
+			state = "inactive"
+			color = ColorWarning
+			inactive++
+			held += max(s.RetainedWAL, 0)
+		}
+		if s.WALStatus == "lost" || s.WALStatus == "unreserved" {
+			state += ", " + s.WALStatus
+			color = ColorDanger
+		}
+		detail := s.Type
+		if s.Plugin != "" {
+			detail += ", " + s.Plugin + " on " + s.Database
+		}
+		add("slot", s.Name, s.Name, state, -1, s.RetainedWAL, detail, color, slotDetail(s))
+	}
+
+	if r := st.Receiver; r != nil {
+		name := r.Sender
+		if name == "" {
+			name = "primary"
+		}
+		var color lipgloss.Color
+		if r.Status != "streaming" {
+			color = ColorWarning
+		}
+		add("receiver", "", name, r.Status, r.ReplayDelay, -1, "received to "+r.ReceivedLSN, color, receiverDetail(r))
+	}
+
+	for _, p := range st.Publications {
+		state := strconv.Itoa(p.Tables) + " tables"
+		if p.AllTables {
+			state = "all tables"
+		}
+		add("publication", p.Name, p.Name, state, -1, -1, strings.Join(p.Operations, ", "), "", publicationDetail(p))
+	}
+
+	for _, s := range st.Subscriptions {
+		state := "enabled"
+		var color lipgloss.Color
+		switch {
+		case !s.Enabled:
+			state = "disabled"
+			color = ColorMuted
+		case s.WorkerPID == 0:
+			state = "no worker"
+			color = ColorWarning
+		}
+		add("subscription", s.Name, s.Name, state, s.Delay, -1, "from "+s.Publications, color, subscriptionDetail(s))
+	}
+
+	a.admin.SetRows(rows)
+
+	var b strings.Builder
+	field := func(name, value string) {
+		fmt.Fprintf(&b, "%-12s %s\n", name, value)
+	}
+	if st.InRecovery {
+		field("role", "standby, replayed WAL to "+st.WALPosition)
+	} else {
+		field("role", "primary, WAL at "+st.WALPosition)
+	}
+	field("standbys", fmt.Sprintf("%d, %d synchronous", len(st.Standbys), synchronous))
+	slots := strconv.Itoa(len(st.Slots))
+	if inactive > 0 {
+		slots += fmt.Sprintf(", %d inactive holding %s of WAL", inactive, db.FormatSize(held))
+	}
+	field("slots", slots)
+	a.admin.SetSummary(strings.TrimSuffix(b.String(), "\n"))
+}
+
+// lagText renders a lag, blank when there is none to report.
+func lagText(d time.Duration) string {
+	if d <= 0 {
+		return ""
+	}
+	return adminview.FormatDuration(d)
+}
+
+// walText renders an amount of WAL, blank when unknown.
+func walText(n int64) string {
+	if n < 0 {
+		return ""
+	}
+	return db.FormatSize(n)
+}
+
+// handleReplicationKey handles the keys of the replication view, reporting
+// false for the ones it leaves to the list.
+func (a App) handleReplicationKey(msg tea.KeyMsg) (tea.Model, tea.Cmd, bool) {
+	if !key.Matches(msg, Keys.Enter) {
+		return a, nil, false
+	}
+	row, ok := a.admin.Selected()
+	if ok {
+		a.admin.ShowDetail(strings.Join(row.Cells[:2], " "), a.replDetails[row.Key])
+		a.updateHints()
+	}
+	return a, nil, true
+}
+
+// detailFields lays out name/value pairs, skipping empty values.
+func detailFields(pairs ...string) string {
+	var b strings.Builder
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i+1] != "" {
+			fmt.Fprintf(&b, "%-14s %s\n", pairs[i], pairs[i+1])
+		}
+	}
+	return b.String()
+}
+
+func standbyDetail(s db.Standby) string {
+	return detailFields(
+		"pid", strconv.Itoa(int(s.PID)),
+		"user", s.User,
+		"client", s.Client,
+		"state", s.State,
+		"sync state", s.SyncState,
+		"sent", s.SentLSN,
+		"replayed", s.ReplayLSN,
+		"behind", walText(s.BytesBehind),
+		"write lag", lagText(s.WriteLag),
+		"flush lag", lagText(s.FlushLag),
+		"replay lag", lagText(s.ReplayLag),
+	)
+}
+
+func slotDetail(s db.ReplicationSlot) string {
+	text := detailFields(
+		"type", s.Type,
+		"plugin", s.Plugin,
+		"database", s.Database,
+		"active", strconv.FormatBool(s.Active),
+		"retained WAL", walText(s.RetainedWAL),
+		"WAL status", s.WALStatus,
+	)
+	if !s.Active {
+		text += "\nAn inactive slot keeps all WAL from its restart point, which can fill\n" +
+			"the disk. If nothing will use it again, drop it with:\n\n" +
+			"  SELECT pg_drop_replication_slot('" + strings.ReplaceAll(s.Name, "'", "''") + "');\n"
+	}
+	return text
+}
+
+func receiverDetail(r *db.WALReceiver) string {
+	last := ""
+	if !r.LastMessage.IsZero() {
+		last = since(r.LastMessage)
+	}
+	return detailFields(
+		"status", r.Status,
+		"sender", r.Sender,
+		"slot", r.Slot,
+		"received", r.ReceivedLSN,
+		"last message", last,
+		"replay delay", lagText(r.ReplayDelay),
+	)
+}
+
+func publicationDetail(p db.Publication) string {
+	tables := strconv.Itoa(p.Tables)
+	if p.AllTables {
+		tables = "all (" + tables + " now)"
+	}
+	return detailFields(
+		"owner", p.Owner,
+		"tables", tables,
+		"publishes", strings.Join(p.Operations, ", "),
+	)
+}
+
+func subscriptionDetail(s db.Subscription) string {
+	last, worker := "", ""
+	if !s.LastMessage.IsZero() {
+		last = since(s.LastMessage)
+	}
+	if s.WorkerPID != 0 {
+		worker = "pid " + strconv.Itoa(int(s.WorkerPID))
+	}
+	return detailFields(
+		"enabled", strconv.FormatBool(s.Enabled),
+		"publications", s.Publications,
+		"apply worker", worker,
+		"received", s.ReceivedLSN,
+		"last message", last,
+		"delay", lagText(s.Delay),
+	)
+}
@@ -0,0 +1,255 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jackc/pgx/v5"
+	"github.com/zaffron/ezpg/internal/db"
+	"github.com/zaffron/ezpg/internal/tui/components/privmatrix"
+)
+
+// toggleSecurity opens the security folder of connName, listing its roles,
+// or closes it.
+func (a App) toggleSecurity(connName string) (tea.Model, tea.Cmd) {
+	if a.sidebar.IsExpanded() {
+		a.sidebar.CollapseSecurity(connName)
+		a.updateHints()
+		return a, nil
+	}
+	a.loading = true
+	a.statusbar.SetLoading(true, "Loading roles...")
+	return a, loadRoleInfoCmd(a.mgr, connName)
+}
+
+// roleAccess shows what role can do with the tables of the database in use.
+func (a App) roleAccess(connName, role string) (tea.Model, tea.Cmd) {
+	a.activeConn = connName
+	a.loading = true
+	a.statusbar.SetLoading(true, "Loading privileges of "+role+"...")
+	return a, loadRoleAccessCmd(a.mgr, connName, role)
+}
+
+// sidebarTable returns the table selected in the sidebar, switching its
+// connection to the table's database. It returns an error message when no
+// table is selected or its database isn't open.
+func (a *App) sidebarTable() (connName, schema, table, errText string) {
+	connName, schema, table, isConn := a.sidebar.SelectedItem()
+	database, isDB := a.sidebar.SelectedDatabase()
+	if connName == "" || isConn || isDB || table == "" {
+		return "", "", "", "Select a table first"
+	}
+	if database != "" && database != a.mgr.Database(connName) {
+		if !a.mgr.SwitchDatabase(connName, database) {
+			return "", "", "", "Database " + database + " is not open"
+		}
+		a.switchedDatabase(connName)
+	}
+	a.activeConn = connName
+	return connName, schema, table, ""
+}
+
+// tableAccess reports who can access the table selected in the sidebar.
+func (a App) tableAccess() (tea.Model, tea.Cmd) {
+	connName, schema, table, errText := a.sidebarTable()
+	if errText != "" {
+		a.statusbar.SetMessage(errText, true)
+		return a, statusTimeoutCmd(3 * time.Second)
+	}
+	a.loading = true
+	a.statusbar.SetLoading(true, "Loading access to "+table+"...")
+	return a, loadTableAccessCmd(a.mgr, connName, schema, table)
+}
+
+// tablePolicies lists the row-level security policies of the table selected
+// in the sidebar.
+func (a App) tablePolicies() (tea.Model, tea.Cmd) {
+	connName, schema, table, errText := a.sidebarTable()
+	if errText != "" {
+		a.statusbar.SetMessage(errText, true)
+		return a, statusTimeoutCmd(3 * time.Second)
+	}
+	a.loading = true
+	a.statusbar.SetLoading(true, "Loading policies of "+table+"...")
+	return a, loadRowSecurityCmd(a.mgr, connName, schema, table)
+}
+
+// grantMatrix loads the grants on the table selected in the sidebar, to
+// edit them as a matrix.
+func (a App) grantMatrix() (tea.Model, tea.Cmd) {
+	connName, schema, table, errText := a.sidebarTable()
+	if errText != "" {
+		a.statusbar.SetMessage(errText, true)
+		return a, statusTimeoutCmd(3 * time.Second)
+	}
+	a.loading = true
+	a.statusbar.SetLoading(true, "Loading grants on "+table+"...")
+	return a, loadGrantsCmd(a.mgr, connName, schema, table)
+}
+
+// report builds a read-only result for the table view from rows of text.
+func report(columns []string, rows [][]string) *db.QueryResult {
+	return &db.QueryResult{Columns: columns, Rows: rows, RowCount: len(rows)}
+}
+
+// privilegeList names the privileges set in privs, or ALL for every one.
+func privilegeList(privs []bool) string {
+	var names []string
+	for i, ok := range privs {
+		if ok && i < len(db.TablePrivileges) {
+			names = append(names, db.TablePrivileges[i])
+		}
+	}
+	if len(names) == len(db.TablePrivileges) {
+		return "ALL"
+	}
+	return strings.Join(names, ", ")
+}
+
+// showAccess puts an access report in the table view: the tables a role can
+// use, or the roles that can use a table.
+func (a *App) showAccess(msg AccessLoadedMsg) {
+	var rows [][]string
+	if msg.Role != "" {
+		for _, t := range msg.Access {
+			rows = append(rows, []string{qualifiedName(t.Schema, t.Table), privilegeList(t.Privileges), t.Via, t.RLS})
+		}
+		title := "privileges of " + msg.Role + " in " + a.mgr.Database(msg.ConnName)
+		a.tableview.SetReport(title, report([]string{"table", "privileges", "via", "row security"}, rows))
+		a.statusbar.SetMessage(a.roleSummary(msg.ConnName, msg.Role), false)
+	} else {
+		for _, t := range msg.Access {
+			can := ""
+			if t.Login {
+				can = "yes"
+			}
+			rows = append(rows, []string{t.Role, can, privilegeList(t.Privileges), t.Via, t.RLS})
+		}
+		title := "access to " + qualifiedName(msg.Schema, msg.Table)
+		a.tableview.SetReport(title, report([]string{"role", "login", "privileges", "via", "row security"}, rows))
+		a.statusbar.SetMessage(fmt.Sprintf("%d roles can access %s", len(rows), qualifiedName(msg.Schema, msg.Table)), false)
+	}
+	a.panel = PanelTable
+}
+
+// roleSummary is a role's attributes and memberships for the status bar,
+// e.g. "alice: LOGIN, CREATEDB; member of readers".
+func (a App) roleSummary(connName, role string) string {
+	for _, r := range a.roleInfo[connName] {
+		if r.Name != role {
+			continue
+		}
+		attrs := r.Attributes()
+		if len(attrs) == 0 {
+			attrs = []string{"NOLOGIN"}
+		}
+		text := role + ": " + strings.Join(attrs, ", ")
+		if len(r.MemberOf) > 0 {
+			text += "; member of " + strings.Join(r.MemberOf, ", ")
+		}
+		return text
+	}
+	return role
+}
+
+// showPolicies puts the row-level security policies of a table in the
+// table view.
+func (a *App) showPolicies(msg RowSecurityLoadedMsg) {
+	rs := msg.RowSecurity
+	rows := make([][]string, 0, len(rs.Policies))
+	for _, p := range rs.Policies {
+		kind := "permissive"
+		if !p.Permissive {
+			kind = "restrictive"
+		}
+		rows = append(rows, []string{p.Name, p.Command, kind, strings.Join(p.Roles, ", "), p.Using, p.WithCheck})
+	}
+	name := qualifiedName(msg.Schema, msg.Table)
+	a.tableview.SetReport("policies on "+name,
+		report([]string{"policy", "command", "type", "roles", "using", "with check"}, rows))
+	a.panel = PanelTable
+
+	var state string
+	switch {
+	case !rs.Enabled:
+		state = "Row-level security is off for " + name
+		if len(rows) > 0 {
+			state += ", its policies are not applied"
+		}
+	case rs.Forced:
+		state = "Row-level security is on and forced for " + name
+	default:
+		state = "Row-level security is on for " + name + ", the owner bypasses it"
+	}
+	if rs.Enabled && len(rows) == 0 {
+		state += ", and with no policy only the owner and superusers see rows"
+	}
+	a.statusbar.SetMessage(state, false)
+}
+
+// startGrants shows the grants on a table as a matrix of roles by
+// privileges, to edit into GRANT and REVOKE statements.
+func (a *App) startGrants(msg GrantsLoadedMsg) {
+	roles := make([]string, len(msg.Grants))
+	granted := make([][]bool, len(msg.Grants))
+	for i, g := range msg.Grants {
+		roles[i] = g.Role
+		granted[i] = g.Privileges
+	}
+	title := "Privileges on " + qualifiedName(msg.Schema, msg.Table)
+	a.grants = privmatrix.New(title, roles, db.TablePrivileges, granted)
+	a.grants.SetHeight(a.height - 20)
+	a.grantSchema, a.grantTable = msg.Schema, msg.Table
+	a.granting = true
+	a.updateHints()
+}
+
+// handleGrantKey edits the privilege matrix; enter writes the statements
+// for the changes into the editor, to review before running them.
+func (a App) handleGrantKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, Keys.Enter):
+		a.granting = false
+		changes := a.grants.Changes()
+		if len(changes) == 0 {
+			a.updateHints()
+			a.statusbar.SetMessage("No privileges changed", false)
+			return a, statusTimeoutCmd(3 * time.Second)
+		}
+		note := fmt.Sprintf("%d statements written to the editor, run them with ctrl+e", len(changes))
+		return a.openInEditor(grantStatements(a.grantSchema, a.grantTable, changes), note)
+
+	case key.Matches(msg, Keys.Escape):
+		a.granting = false
+		a.updateHints()
+		return a, nil
+	}
+
+	var cmd tea.Cmd
+	a.grants, cmd = a.grants.Update(msg)
+	return a, cmd
+}
+
+// grantStatements spells out changes as GRANT and REVOKE statements on a
+// table, one per line. Privileges are always listed: ALL would also cover
+// ones the matrix doesn't show, such as MAINTAIN from PostgreSQL 17.
+func grantStatements(schema, table string, changes []privmatrix.Change) string {
+	target := pgx.Identifier{schema, table}.Sanitize()
+	var b strings.Builder
+	for _, c := range changes {
+		privs := strings.Join(c.Privileges, ", ")
+		role := c.Role
+		if role != "PUBLIC" {
+			role = pgx.Identifier{role}.Sanitize()
+		}
+		if c.Grant {
+			fmt.Fprintf(&b, "GRANT %s ON %s TO %s;\n", privs, target, role)
+		} else {
+			fmt.Fprintf(&b, "REVOKE %s ON %s FROM %s;\n", privs, target, role)
+		}
+	}
+	return b.String()
+}
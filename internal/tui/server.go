@@ -0,0 +1,239 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jackc/pgx/v5"
+	"github.com/zaffron/ezpg/internal/db"
+	"github.com/zaffron/ezpg/internal/tui/components/adminview"
+	"github.com/zaffron/ezpg/internal/tui/components/keyhints"
+)
+
+var extensionColumns = []adminview.Column{
+	{Title: "extension", Width: 24},
+	{Title: "schema", Width: 14},
+	{Title: "installed", Width: 10},
+	{Title: "available", Width: 10},
+	{Title: "description"},
+}
+
+var serverHints = []keyhints.Hint{
+	{Key: "enter", Desc: "extension"},
+}
+
+var settingColumns = []adminview.Column{
+	{Title: "", Width: 2},
+	{Title: "name", Width: 36},
+	{Title: "value", Width: 24},
+	{Title: "source", Width: 20},
+	{Title: "context", Width: 18},
+	{Title: "description"},
+}
+
+var settingHints = []keyhints.Hint{
+	{Key: "enter", Desc: "setting"},
+	{Key: "*", Desc: "non-default only"},
+}
+
+// setServerInfo shows the server overview above its extensions, the ones
+// with an update available flagged.
+func (a *App) setServerInfo(info db.ServerInfo, extensions []db.Extension) {
+	a.extensions = extensions
+
+	upgradable := 0
+	rows := make([]adminview.Row, len(extensions))
+	for i, e := range extensions {
+		var color lipgloss.Color
+		if e.Upgradable() {
+			upgradable++
+			color = ColorWarning
+		}
+		rows[i] = adminview.Row{
+			Key:   e.Name,
+			Cells: []string{e.Name, e.Schema, e.Version, e.Available, e.Description},
+			Color: color,
+		}
+	}
+	a.admin.SetRows(rows)
+
+	role := "primary"
+	if info.InRecovery {
+		role = "standby (in recovery)"
+	}
+	exts := fmt.Sprintf("%d installed in %s", len(extensions), a.mgr.Database(a.adminConn))
+	if upgradable > 0 {
+		exts += fmt.Sprintf(", %d with an update available", upgradable)
+	}
+
+	var b strings.Builder
+	field := func(name, value string) {
+		fmt.Fprintf(&b, "%-12s %s\n", name, value)
+	}
+	field("version", info.Version)
+	field("uptime", adminview.FormatDuration(time.Since(info.StartedAt))+", since "+info.StartedAt.Local().Format("2006-01-02 15:04"))
+	field("data size", db.FormatSize(info.DataSize)+" across the databases you can connect to")
+	field("role", role)
+	field("connections", fmt.Sprintf("%d of %d, %d reserved for superusers", info.Connections, info.MaxConnections, info.Reserved))
+	field("extensions", exts)
+	a.admin.SetSummary(strings.TrimSuffix(b.String(), "\n"))
+}
+
+func (a App) selectedExtension() (db.Extension, bool) {
+	row, ok := a.admin.Selected()
+	if !ok {
+		return db.Extension{}, false
+	}
+	for _, e := range a.extensions {
+		if e.Name == row.Key {
+			return e, true
+		}
+	}
+	return db.Extension{}, false
+}
+
+// handleServerKey handles the keys of the server page, reporting false for
+// the ones it leaves to the list.
+func (a App) handleServerKey(msg tea.KeyMsg) (tea.Model, tea.Cmd, bool) {
+	if !key.Matches(msg, Keys.Enter) {
+		return a, nil, false
+	}
+	if e, ok := a.selectedExtension(); ok {
+		a.admin.ShowDetail("extension "+e.Name, extensionDetail(e))
+		a.updateHints()
+	}
+	return a, nil, true
+}
+
+func extensionDetail(e db.Extension) string {
+	var b strings.Builder
+	field := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "%-12s %s\n", name, value)
+		}
+	}
+	field("schema", e.Schema)
+	field("installed", e.Version)
+	field("available", e.Available)
+	if e.Description != "" {
+		b.WriteString("\n" + e.Description + "\n")
+	}
+	if e.Upgradable() {
+		fmt.Fprintf(&b, "\nTo update it, run in this database:\n\n  ALTER EXTENSION %s UPDATE;\n", pgx.Identifier{e.Name}.Sanitize())
+	}
+	return b.String()
+}
+
+// setSettings lists pg_settings, flagging with * the values set somewhere and
+// with ! those waiting for a restart.
+func (a *App) setSettings(settings []db.Setting) {
+	a.settings = settings
+
+	rows := make([]adminview.Row, 0, len(settings))
+	for _, s := range settings {
+		if a.onlyChanged && !s.NonDefault() && !s.PendingRestart {
+			continue
+		}
+
+		flag := ""
+		var color lipgloss.Color
+		switch {
+		case s.PendingRestart:
+			flag = "!"
+			color = ColorWarning
+		case s.NonDefault():
+			flag = "*"
+			color = ColorPrimary
+		}
+
+		rows = append(rows, adminview.Row{
+			Key:   s.Name,
+			Cells: []string{flag, s.Name, settingValue(s.Value, s.Unit), s.Source, s.Context, s.Description},
+			Color: color,
+		})
+	}
+	a.admin.SetRows(rows)
+
+	if a.onlyChanged {
+		a.admin.SetEmptyText("every setting has its default value")
+	} else {
+		a.admin.SetEmptyText("")
+	}
+}
+
+// settingValue shows value with its unit, e.g. "128 8kB" for shared_buffers.
+func settingValue(value, unit string) string {
+	if unit == "" || value == "" {
+		return value
+	}
+	return value + " " + unit
+}
+
+func (a App) selectedSetting() (db.Setting, bool) {
+	row, ok := a.admin.Selected()
+	if !ok {
+		return db.Setting{}, false
+	}
+	for _, s := range a.settings {
+		if s.Name == row.Key {
+			return s, true
+		}
+	}
+	return db.Setting{}, false
+}
+
+// handleSettingsKey handles the keys of the settings browser, reporting
+// false for the ones it leaves to the list.
+func (a App) handleSettingsKey(msg tea.KeyMsg) (tea.Model, tea.Cmd, bool) {
+	switch {
+	case key.Matches(msg, Keys.Enter):
+		if s, ok := a.selectedSetting(); ok {
+			a.admin.ShowDetail(s.Name, settingDetail(s))
+			a.updateHints()
+		}
+		return a, nil, true
+
+	case key.Matches(msg, Keys.NonDefault):
+		a.onlyChanged = !a.onlyChanged
+		a.setSettings(a.settings)
+		return a, nil, true
+	}
+
+	return a, nil, false
+}
+
+func settingDetail(s db.Setting) string {
+	var b strings.Builder
+	field := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "%-16s %s\n", name, value)
+		}
+	}
+	field("value", settingValue(s.Value, s.Unit))
+	field("default", settingValue(s.BootValue, s.Unit))
+	if s.ResetValue != s.Value {
+		field("reset value", settingValue(s.ResetValue, s.Unit))
+	}
+	field("source", s.Source)
+	if s.SourceFile != "" {
+		field("set in", s.SourceFile+":"+strconv.Itoa(s.SourceLine))
+	}
+	if s.PendingRestart {
+		field("pending restart", "yes, the file has a new value that needs a restart")
+	}
+	field("context", s.Context)
+	field("type", s.Type)
+	if s.Min != "" || s.Max != "" {
+		field("range", s.Min+" to "+s.Max)
+	}
+	field("values", strings.Join(s.EnumValues, ", "))
+	field("category", s.Category)
+
+	b.WriteString("\n" + s.Description)
+	return b.String()
+}
@@ -0,0 +1,32 @@
+package shared
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// EnvColor looks up the configured color of an environment tag.
+func EnvColor(env string, colors map[string]string) (lipgloss.Color, bool) {
+	env = strings.ToLower(env)
+	c, ok := colors[env]
+	if !ok && env == "production" {
+		c, ok = colors["prod"]
+	}
+	if !ok || c == "" {
+		return "", false
+	}
+	return lipgloss.Color(c), true
+}
+
+// EnvBadge renders an environment tag like "[prod]" in its color, or "" when untagged.
+func EnvBadge(env string, colors map[string]string) string {
+	if env == "" {
+		return ""
+	}
+	style := lipgloss.NewStyle().Bold(true).Foreground(ColorMuted)
+	if c, ok := EnvColor(env, colors); ok {
+		style = style.Foreground(c)
+	}
+	return style.Render("[" + env + "]")
+}
@@ -0,0 +1,25 @@
+package shared
+
+import (
+	"github.com/charmbracelet/lipgloss"
+	"github.com/zaffron/ezpg/internal/db"
+)
+
+// HealthColor is the color a connection is drawn in for its health.
+func HealthColor(h db.Health) lipgloss.Color {
+	switch h {
+	case db.HealthDegraded:
+		return ColorWarning
+	case db.HealthLost:
+		return ColorDanger
+	}
+	return ColorSuccess
+}
+
+// HealthBadge renders anything but a healthy connection, e.g. "[lost]".
+func HealthBadge(h db.Health) string {
+	if h == db.HealthConnected {
+		return ""
+	}
+	return lipgloss.NewStyle().Bold(true).Foreground(HealthColor(h)).Render("[" + h.String() + "]")
+}
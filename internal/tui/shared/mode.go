@@ -5,6 +5,7 @@ type AppScreen int
 const (
 	ScreenHome AppScreen = iota
 	ScreenBrowse
+	ScreenAdmin
 )
 
 func (s AppScreen) String() string {
@@ -13,6 +14,8 @@ func (s AppScreen) String() string {
 		return "HOME"
 	case ScreenBrowse:
 		return "BROWSE"
+	case ScreenAdmin:
+		return "ADMIN"
 	default:
 		return "UNKNOWN"
 	}
@@ -0,0 +1,170 @@
+package tui
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/zaffron/ezpg/internal/db"
+	"github.com/zaffron/ezpg/internal/tui/components/adminview"
+	"github.com/zaffron/ezpg/internal/tui/components/keyhints"
+)
+
+var sizeColumns = []adminview.Column{
+	{Title: "name"},
+	{Title: "kind", Width: 6},
+	{Title: "total", Width: 10, Numeric: true},
+	{Title: "table", Width: 10, Numeric: true},
+	{Title: "toast", Width: 10, Numeric: true},
+	{Title: "indexes", Width: 10, Numeric: true},
+	{Title: "~bloat", Width: 10, Numeric: true},
+	{Title: "dead", Width: 9, Numeric: true},
+	{Title: "scans", Width: 9, Numeric: true},
+	{Title: "vacuumed", Width: 10, Numeric: true},
+	{Title: "analyzed", Width: 10, Numeric: true},
+}
+
+var sizeHints = []keyhints.Hint{
+	{Key: "enter", Desc: "show in sidebar"},
+}
+
+// deadTupleWarning is the share of dead rows at which a table is flagged
+// as overdue for a vacuum.
+const deadTupleWarning = 0.2
+
+func (a *App) setSizes(tables []db.TableSize, indexes []db.IndexSize) {
+	a.sizeTables = make(map[string]db.TableSize, len(tables))
+	a.sizeIndexes = make(map[string]db.IndexSize, len(indexes))
+
+	rows := make([]adminview.Row, 0, len(tables)+len(indexes))
+	for _, t := range tables {
+		k := "table/" + t.Schema + "." + t.Name
+		a.sizeTables[k] = t
+
+		bloat := ""
+		if t.Bloat >= 0 {
+			bloat = db.FormatSize(t.Bloat)
+		}
+		var color lipgloss.Color
+		if t.DeadTuples > 0 && float64(t.DeadTuples) >= deadTupleWarning*float64(t.LiveTuples+t.DeadTuples) {
+			color = ColorWarning
+		}
+
+		rows = append(rows, adminview.Row{
+			Key: k,
+			Cells: []string{
+				qualifiedName(t.Schema, t.Name),
+				"table",
+				db.FormatSize(t.Total),
+				db.FormatSize(t.Table),
+				db.FormatSize(t.Toast),
+				db.FormatSize(t.Indexes),
+				bloat,
+				strconv.FormatInt(t.DeadTuples, 10),
+				"",
+				since(t.LastVacuum),
+				since(t.LastAnalyze),
+			},
+			Sort: []float64{
+				2:  float64(t.Total),
+				3:  float64(t.Table),
+				4:  float64(t.Toast),
+				5:  float64(t.Indexes),
+				6:  float64(t.Bloat),
+				7:  float64(t.DeadTuples),
+				8:  -1,
+				9:  age(t.LastVacuum),
+				10: age(t.LastAnalyze),
+			},
+			Color: color,
+		})
+	}
+
+	for _, ix := range indexes {
+		k := "index/" + ix.Schema + "." + ix.Name
+		a.sizeIndexes[k] = ix
+
+		kind := "index"
+		var color lipgloss.Color
+		if ix.Scans == 0 && !ix.Unique {
+			kind = "unused"
+			color = ColorWarning
+		}
+
+		rows = append(rows, adminview.Row{
+			Key: k,
+			Cells: []string{
+				qualifiedName(ix.Schema, ix.Name) + " on " + ix.Table,
+				kind,
+				db.FormatSize(ix.Size),
+				"", "", "", "", "",
+				strconv.FormatInt(ix.Scans, 10),
+				"", "",
+			},
+			Sort:  []float64{2: float64(ix.Size), 8: float64(ix.Scans)},
+			Color: color,
+		})
+	}
+
+	a.admin.SetRows(rows)
+}
+
+func qualifiedName(schema, name string) string {
+	if schema == "public" {
+		return name
+	}
+	return schema + "." + name
+}
+
+// since renders how long ago t was, or "never".
+func since(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return adminview.FormatDuration(time.Since(t)) + " ago"
+}
+
+// age is the sort key of a vacuum or analyze time, never being the oldest.
+func age(t time.Time) float64 {
+	if t.IsZero() {
+		return math.Inf(1)
+	}
+	return time.Since(t).Seconds()
+}
+
+// handleSizesKey handles the keys of the size dashboard, reporting false
+// for the ones it leaves to the list.
+func (a App) handleSizesKey(msg tea.KeyMsg) (tea.Model, tea.Cmd, bool) {
+	if !key.Matches(msg, Keys.Enter) {
+		return a, nil, false
+	}
+
+	row, ok := a.admin.Selected()
+	if !ok {
+		return a, nil, true
+	}
+	var schema, table string
+	if t, ok := a.sizeTables[row.Key]; ok {
+		schema, table = t.Schema, t.Name
+	} else if ix, ok := a.sizeIndexes[row.Key]; ok {
+		schema, table = ix.Schema, ix.Table
+	} else {
+		return a, nil, true
+	}
+
+	if !a.sidebar.SelectTable(a.adminConn, a.mgr.Database(a.adminConn), schema, table) {
+		a.statusbar.SetMessage(qualifiedName(schema, table)+" is not listed in the sidebar, expand its database first", true)
+		return a, statusTimeoutCmd(3 * time.Second), true
+	}
+
+	a.screen = ScreenBrowse
+	a.adminSeq++
+	a.panel = PanelSidebar
+	a.inputFocused = false
+	a.layoutResize()
+	a.updateHints()
+	return a, nil, true
+}
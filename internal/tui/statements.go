@@ -0,0 +1,231 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/zaffron/ezpg/internal/db"
+	"github.com/zaffron/ezpg/internal/tui/components/adminview"
+	"github.com/zaffron/ezpg/internal/tui/components/keyhints"
+)
+
+// topStatements is how many statements, by total time, the view lists.
+const topStatements = 200
+
+var statementColumns = []adminview.Column{
+	{Title: "total", Width: 10, Numeric: true},
+	{Title: "mean", Width: 10, Numeric: true},
+	{Title: "calls", Width: 10, Numeric: true},
+	{Title: "rows", Width: 10, Numeric: true},
+	{Title: "hit%", Width: 6, Numeric: true},
+	{Title: "user", Width: 12},
+	{Title: "database", Width: 12},
+	{Title: "query"},
+}
+
+var statementHints = []keyhints.Hint{
+	{Key: "enter", Desc: "query"},
+	{Key: "x", Desc: "plan"},
+	{Key: "e", Desc: "open in editor"},
+	{Key: "R", Desc: "reset stats"},
+}
+
+const statStatementsHelp = `pg_stat_statements is not available on this server.
+
+To enable it:
+
+  1. Load the library when the server starts, in postgresql.conf:
+
+       shared_preload_libraries = 'pg_stat_statements'
+
+     then restart PostgreSQL. Managed services set this in their
+     parameter group or database flags instead.
+
+  2. Create the extension in this database:
+
+       CREATE EXTENSION pg_stat_statements;
+
+Press r to check again.`
+
+// statementsLoaded shows the statements, or how to enable pg_stat_statements
+// when it is missing.
+func (a *App) statementsLoaded(msg StatementsLoadedMsg) tea.Cmd {
+	if errors.Is(msg.Err, db.ErrStatStatementsMissing) || errors.Is(msg.Err, db.ErrStatStatementsNotLoaded) {
+		a.statements = nil
+		a.admin.SetRows(nil)
+		a.admin.SetEmptyText(msg.Err.Error() + "\n\n" + statStatementsHelp)
+		return a.adminLoaded(nil)
+	}
+	if msg.Err != nil {
+		return a.adminLoaded(msg.Err)
+	}
+
+	a.statements = msg.Statements
+	a.admin.SetEmptyText("no statements recorded yet")
+
+	rows := make([]adminview.Row, len(msg.Statements))
+	for i, s := range msg.Statements {
+		hit := ""
+		if s.HitRatio >= 0 {
+			hit = fmt.Sprintf("%.1f", s.HitRatio*100)
+		}
+		rows[i] = adminview.Row{
+			Key: statementKey(s),
+			Cells: []string{
+				adminview.FormatDuration(s.TotalTime),
+				adminview.FormatDuration(s.MeanTime),
+				strconv.FormatInt(s.Calls, 10),
+				strconv.FormatInt(s.Rows, 10),
+				hit,
+				s.User,
+				s.Database,
+				s.Query,
+			},
+			Sort: []float64{
+				s.TotalTime.Seconds(),
+				s.MeanTime.Seconds(),
+				float64(s.Calls),
+				float64(s.Rows),
+				s.HitRatio,
+			},
+		}
+	}
+	a.admin.SetRows(rows)
+	return a.adminLoaded(nil)
+}
+
+// statementKey identifies a statement: the same query id is kept apart per
+// user and database.
+func statementKey(s db.StatementStats) string {
+	return fmt.Sprintf("%d/%s/%s", s.QueryID, s.User, s.Database)
+}
+
+func (a App) selectedStatement() (db.StatementStats, bool) {
+	row, ok := a.admin.Selected()
+	if !ok {
+		return db.StatementStats{}, false
+	}
+	for _, s := range a.statements {
+		if statementKey(s) == row.Key {
+			return s, true
+		}
+	}
+	return db.StatementStats{}, false
+}
+
+// handleStatementsKey handles the keys of the statements view, reporting
+// false for the ones it leaves to the list.
+func (a App) handleStatementsKey(msg tea.KeyMsg) (tea.Model, tea.Cmd, bool) {
+	if key.Matches(msg, Keys.ResetStats) {
+		m, cmd := a.resetStatements()
+		return m, cmd, true
+	}
+
+	s, ok := a.selectedStatement()
+
+	switch {
+	case key.Matches(msg, Keys.Enter):
+		if ok {
+			a.admin.ShowDetail(statementTitle(s), statementDetail(s))
+			a.updateHints()
+		}
+		return a, nil, true
+
+	case key.Matches(msg, Keys.Explain):
+		if !ok {
+			return a, nil, true
+		}
+		m, cmd := a.explainAdmin("Plan of "+statementTitle(s), s.Database, s.Query)
+		return m, cmd, true
+
+	case key.Matches(msg, Keys.ToggleEditor):
+		if !ok {
+			return a, nil, true
+		}
+		m, cmd := a.openInEditor(s.Query, "")
+		return m, cmd, true
+	}
+
+	return a, nil, false
+}
+
+// resetStatements clears pg_stat_statements, always after confirming since
+// the history can't be brought back.
+func (a App) resetStatements() (tea.Model, tea.Cmd) {
+	if c, ok := a.mgr.ConnectionConfig(a.adminConn); ok && c.IsReadOnly() {
+		a.statusbar.SetMessage("Connection is read-only", true)
+		return a, statusTimeoutCmd(3 * time.Second)
+	}
+
+	connName := a.adminConn
+	a.confirming = true
+	a.confirmText = fmt.Sprintf("Reset pg_stat_statements on %s? (y/n)", connName)
+	a.statusbar.SetMessage(a.confirmText, true)
+	a.onConfirm = func() tea.Cmd {
+		return resetStatementsCmd(a.mgr, connName)
+	}
+	a.updateHints()
+	return a, nil
+}
+
+// openInEditor leaves the admin screen for the SQL editor, loaded with query,
+// and shows note once it is. SQL already in the editor is only replaced
+// after confirming.
+func (a App) openInEditor(query, note string) (tea.Model, tea.Cmd) {
+	if current := strings.TrimSpace(a.editor.Value()); current == "" || current == strings.TrimSpace(query) {
+		return a.loadEditor(query, note)
+	}
+
+	a.confirming = true
+	a.confirmText = "Replace the SQL in the editor? (y/n)"
+	a.statusbar.SetMessage(a.confirmText, true)
+	a.onConfirm = func() tea.Cmd {
+		return func() tea.Msg { return OpenInEditorMsg{Query: query, Note: note} }
+	}
+	a.updateHints()
+	return a, nil
+}
+
+func (a App) loadEditor(query, note string) (tea.Model, tea.Cmd) {
+	a.screen = ScreenBrowse
+	a.adminSeq++
+	a.showEditor = true
+	a.panel = PanelEditor
+	a.inputFocused = true
+	a.editor.SetValue(query)
+	a.editor.Focus()
+	a.layoutResize()
+	a.updateHints()
+	if note == "" {
+		return a, nil
+	}
+	a.statusbar.SetMessage(note, false)
+	return a, statusTimeoutCmd(5 * time.Second)
+}
+
+func statementTitle(s db.StatementStats) string {
+	return fmt.Sprintf("statement %d (%s on %s)", s.QueryID, s.User, s.Database)
+}
+
+// statementDetail is the full normalized query of s, under its numbers.
+func statementDetail(s db.StatementStats) string {
+	var b strings.Builder
+	field := func(name, value string) {
+		fmt.Fprintf(&b, "%-12s %s\n", name, value)
+	}
+	field("calls", strconv.FormatInt(s.Calls, 10))
+	field("total time", adminview.FormatDuration(s.TotalTime))
+	field("mean time", adminview.FormatDuration(s.MeanTime))
+	field("rows", strconv.FormatInt(s.Rows, 10))
+	if s.HitRatio >= 0 {
+		field("hit ratio", fmt.Sprintf("%.2f%%", s.HitRatio*100))
+	}
+
+	b.WriteString("\n" + s.Query)
+	return b.String()
+}